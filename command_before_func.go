@@ -3,11 +3,15 @@ package main
 import (
 	"fmt"
 	"os"
+	"path"
+	"path/filepath"
 	"slices"
 	"strings"
 
+	"github.com/Azure/aztfexport/internal"
 	"github.com/Azure/aztfexport/internal/meta"
 	"github.com/Azure/aztfexport/internal/utils"
+	"github.com/Azure/aztfexport/pkg/config"
 	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/resourcegraph/armresourcegraph"
 	"github.com/hashicorp/terraform-config-inspect/tfconfig"
 	"github.com/urfave/cli/v2"
@@ -21,6 +25,17 @@ func commandBeforeFunc(fset *FlagSet, mode Mode) func(ctx *cli.Context) error {
 				return fmt.Errorf("`--append` conflicts with `--overwrite`")
 			}
 		}
+		if fset.flagResume {
+			if fset.flagOverwrite {
+				return fmt.Errorf("`--resume` conflicts with `--overwrite`")
+			}
+			if fset.flagGenerateMappingFile {
+				return fmt.Errorf("`--resume` conflicts with `--generate-mapping-file`")
+			}
+			if _, err := os.Stat(filepath.Join(fset.flagOutputDir, meta.CheckpointFileName)); err != nil {
+				return fmt.Errorf("`--resume` requires the checkpoint journal %s in the output directory: %v", meta.CheckpointFileName, err)
+			}
+		}
 		if !fset.flagNonInteractive {
 			if fset.flagContinue {
 				return fmt.Errorf("`--continue` must be used together with `--non-interactive`")
@@ -51,6 +66,9 @@ func commandBeforeFunc(fset *FlagSet, mode Mode) func(ctx *cli.Context) error {
 			if !fset.flagHCLOnly {
 				return fmt.Errorf("`--tfclient-plugin-path` must be used together with `--hcl-only`")
 			}
+			if fset.flagResume {
+				return fmt.Errorf("`--tfclient-plugin-path` conflicts with `--resume`")
+			}
 		}
 
 		for _, ext := range fset.flagIncludeExtension.Value() {
@@ -59,9 +77,77 @@ func commandBeforeFunc(fset *FlagSet, mode Mode) func(ctx *cli.Context) error {
 			}
 		}
 
+		if _, err := fset.BuildTagFilter(); err != nil {
+			return err
+		}
+
+		switch config.OutputFormat(fset.flagOutputFormat) {
+		case "", config.OutputFormatHCL, config.OutputFormatJSON:
+		default:
+			return fmt.Errorf("invalid value of `--output-format`: %q", fset.flagOutputFormat)
+		}
+		switch config.FileLayout(fset.flagFileLayout) {
+		case "", config.FileLayoutSingle, config.FileLayoutResourceType, config.FileLayoutProvider, config.FileLayoutResourceGroup:
+		default:
+			return fmt.Errorf("invalid value of `--file-layout`: %q", fset.flagFileLayout)
+		}
+		switch config.ModuleLayout(fset.flagModuleLayout) {
+		case "", config.ModuleLayoutNone:
+		case config.ModuleLayoutResourceGroup, config.ModuleLayoutResourceType, config.ModuleLayoutProvider, config.ModuleLayoutRule:
+			if fset.flagModulePath != "" {
+				return fmt.Errorf("`--module-layout` conflicts with `--module-path`")
+			}
+			if fset.flagHoistVariables {
+				return fmt.Errorf("`--module-layout` conflicts with `--hoist-variables`")
+			}
+			if fset.flagDataSourceForExternalRefs {
+				return fmt.Errorf("`--module-layout` conflicts with `--data-source-for-external-refs`")
+			}
+		default:
+			return fmt.Errorf("invalid value of `--module-layout`: %q", fset.flagModuleLayout)
+		}
+		if config.ModuleLayout(fset.flagModuleLayout) == config.ModuleLayoutRule {
+			if fset.flagModuleRuleFile == "" {
+				return fmt.Errorf("`--module-layout=rule` must be used together with `--module-rule-file`")
+			}
+			if _, err := meta.ParseModuleRuleFile(fset.flagModuleRuleFile); err != nil {
+				return fmt.Errorf("invalid value of `--module-rule-file`: %v", err)
+			}
+		} else if fset.flagModuleRuleFile != "" {
+			return fmt.Errorf("`--module-rule-file` must be used together with `--module-layout=rule`")
+		}
+
+		if len(fset.flagAdditionalSubscriptionId.Value()) != 0 {
+			if fset.flagModulePath != "" {
+				return fmt.Errorf("`--additional-subscription-id` conflicts with `--module-path`")
+			}
+			if fset.flagModuleLayout != "" && fset.flagModuleLayout != string(config.ModuleLayoutNone) {
+				return fmt.Errorf("`--additional-subscription-id` conflicts with `--module-layout`")
+			}
+		}
+
+		if len(fset.flagOutputAttribute.Value()) != 0 {
+			if !fset.flagGenerateOutputs {
+				return fmt.Errorf("`--output-attribute` must be used together with `--generate-outputs`")
+			}
+			if _, err := parseOutputAttributes(fset.flagOutputAttribute.Value()); err != nil {
+				return fmt.Errorf("invalid value of `--output-attribute`: %v", err)
+			}
+		}
+
 		if err := meta.ValidateNamePattern(fset.flagPattern); err != nil {
 			return fmt.Errorf("invalid value of `--name-pattern`: %v", err)
 		}
+		if fset.flagNamingPolicy != "" {
+			if _, err := meta.ParseNamingPolicyFile(fset.flagNamingPolicy); err != nil {
+				return fmt.Errorf("invalid value of `--naming-policy`: %v", err)
+			}
+		}
+		if fset.flagDependencyResolutionFile != "" {
+			if _, err := meta.ParseDependencyResolutionFile(fset.flagDependencyResolutionFile); err != nil {
+				return fmt.Errorf("invalid value of `--dependency-resolution-file`: %v", err)
+			}
+		}
 
 		if err := conflictArgs([]argDesc{
 			{
@@ -126,7 +212,20 @@ func commandBeforeFunc(fset *FlagSet, mode Mode) func(ctx *cli.Context) error {
 					return fmt.Errorf("`--name` can't be specified for multi-resource mode")
 				}
 			}
-		case ModeQuery:
+		case ModeResourceGroup:
+			for _, rg := range ctx.Args().Slice() {
+				if _, err := path.Match(rg, ""); err != nil {
+					return fmt.Errorf("invalid resource group pattern %q: %v", rg, err)
+				}
+			}
+		case ModeManagementGroup:
+			if fset.flagModulePath != "" {
+				return fmt.Errorf("`management-group` conflicts with `--module-path`")
+			}
+			if fset.flagModuleLayout != "" && fset.flagModuleLayout != string(config.ModuleLayoutNone) {
+				return fmt.Errorf("`management-group` conflicts with `--module-layout`")
+			}
+		case ModeQuery, ModeKQL:
 			if fset.flagARGAuthorizationScopeFilter != "" {
 				if !slices.Contains(armresourcegraph.PossibleAuthorizationScopeFilterValues(), armresourcegraph.AuthorizationScopeFilter(fset.flagARGAuthorizationScopeFilter)) {
 					return fmt.Errorf("invalid value of `--arg-authorization-scope-filter`")
@@ -149,7 +248,7 @@ func commandBeforeFunc(fset *FlagSet, mode Mode) func(ctx *cli.Context) error {
 		if !empty {
 			switch {
 			case fset.flagOverwrite:
-			case fset.flagAppend:
+			case fset.flagAppend, fset.flagResume:
 				tfblock, err = utils.InspecTerraformBlock(fset.flagOutputDir)
 				if err != nil {
 					return fmt.Errorf("determine the backend type from the existing files: %v", err)
@@ -251,6 +350,41 @@ The output directory is not empty. Please choose one of actions below:
 	}
 }
 
+func diffCommandBeforeFunc(fset *FlagSet, mode Mode) func(ctx *cli.Context) error {
+	return func(ctx *cli.Context) error {
+		switch fset.flagDiffFormat {
+		case internal.DiffOutputFormatText, internal.DiffOutputFormatJSON:
+		default:
+			return fmt.Errorf("invalid value of `--format`: %q", fset.flagDiffFormat)
+		}
+
+		for _, ext := range fset.flagIncludeExtension.Value() {
+			if !slices.Contains(meta.SupportedExtensionResourceTypes, ext) {
+				return fmt.Errorf("invalid value of `--include-extension`: %v is not supported", ext)
+			}
+		}
+
+		if _, err := fset.BuildTagFilter(); err != nil {
+			return err
+		}
+
+		if mode == ModeDiffQuery && fset.flagARGAuthorizationScopeFilter != "" {
+			if !slices.Contains(armresourcegraph.PossibleAuthorizationScopeFilterValues(), armresourcegraph.AuthorizationScopeFilter(fset.flagARGAuthorizationScopeFilter)) {
+				return fmt.Errorf("invalid value of `--arg-authorization-scope-filter`")
+			}
+		}
+
+		if fset.flagSubscriptionId == "" {
+			var err error
+			fset.flagSubscriptionId, err = subscriptionIdFromCLI()
+			if err != nil {
+				return fmt.Errorf("retrieving subscription id from CLI: %v", err)
+			}
+		}
+		return nil
+	}
+}
+
 type argDesc struct {
 	name  string
 	isSet bool
@@ -26,6 +26,7 @@ func TestCommondBeforeFunc(t *testing.T) {
 	cases := []struct {
 		name      string
 		fset      FlagSet
+		mode      Mode
 		dirGen    func(t *testing.T) string
 		err       string
 		postCheck func(t *testing.T, flagset FlagSet)
@@ -115,6 +116,37 @@ func TestCommondBeforeFunc(t *testing.T) {
 				flagAppend:     true,
 			},
 		},
+		{
+			name: "--resume conflicts with --overwrite",
+			fset: FlagSet{
+				flagResume:    true,
+				flagOverwrite: true,
+			},
+			err: "`--resume` conflicts with `--overwrite`",
+		},
+		{
+			name: "--resume requires the checkpoint journal",
+			fset: FlagSet{
+				flagResume: true,
+			},
+			err: "`--resume` requires the checkpoint journal",
+		},
+		{
+			name: "--resume works with a non empty dir containing the checkpoint journal",
+			fset: FlagSet{
+				flagResume: true,
+			},
+			dirGen: func(t *testing.T) string {
+				dir := dirGenWithTFBlock("terraform {}")(t)
+				if err := os.WriteFile(filepath.Join(dir, "aztfexportCheckpoint.jsonl"), nil, 0640); err != nil {
+					t.Fatal(err)
+				}
+				return dir
+			},
+			postCheck: func(t *testing.T, flagset FlagSet) {
+				require.Equal(t, "local", flagset.flagBackendType)
+			},
+		},
 		{
 			name: "--dev-provider conflicts with --provider-version",
 			fset: FlagSet{
@@ -123,6 +155,80 @@ func TestCommondBeforeFunc(t *testing.T) {
 			},
 			err: "`--dev-provider` conflicts with `--provider-version`",
 		},
+		{
+			name: "invalid --output-format",
+			fset: FlagSet{
+				flagOutputFormat: "yaml",
+			},
+			err: "invalid value of `--output-format`",
+		},
+		{
+			name: "invalid --file-layout",
+			fset: FlagSet{
+				flagFileLayout: "foo",
+			},
+			err: "invalid value of `--file-layout`",
+		},
+		{
+			name: "invalid --module-layout",
+			fset: FlagSet{
+				flagModuleLayout: "foo",
+			},
+			err: "invalid value of `--module-layout`",
+		},
+		{
+			name: "--module-layout conflicts with --hoist-variables",
+			fset: FlagSet{
+				flagModuleLayout:   "resource-group",
+				flagHoistVariables: true,
+			},
+			err: "`--module-layout` conflicts with `--hoist-variables`",
+		},
+		{
+			name: "--additional-subscription-id conflicts with --module-layout",
+			fset: FlagSet{
+				flagAdditionalSubscriptionId: *cli.NewStringSlice("00000000-0000-0000-0000-000000000000"),
+				flagModuleLayout:             "resource-group",
+			},
+			err: "`--additional-subscription-id` conflicts with `--module-layout`",
+		},
+		{
+			name: "invalid --tag",
+			fset: FlagSet{
+				flagTag: *cli.NewStringSlice("env=prod", "=foo"),
+			},
+			err: "invalid value of `--tag`",
+		},
+		{
+			name: "management-group conflicts with --module-layout",
+			fset: FlagSet{
+				flagModuleLayout: "resource-group",
+			},
+			mode: ModeManagementGroup,
+			err:  "`management-group` conflicts with `--module-layout`",
+		},
+		{
+			name: "--output-attribute must be used together with --generate-outputs",
+			fset: FlagSet{
+				flagOutputAttribute: *cli.NewStringSlice("azurerm_user_assigned_identity.principal_id"),
+			},
+			err: "`--output-attribute` must be used together with `--generate-outputs`",
+		},
+		{
+			name: "invalid --output-attribute",
+			fset: FlagSet{
+				flagGenerateOutputs: true,
+				flagOutputAttribute: *cli.NewStringSlice("azurerm_user_assigned_identity"),
+			},
+			err: "invalid value of `--output-attribute`",
+		},
+		{
+			name: "--output-attribute with --generate-outputs works",
+			fset: FlagSet{
+				flagGenerateOutputs: true,
+				flagOutputAttribute: *cli.NewStringSlice("azurerm_linux_virtual_machine.identity[0].principal_id"),
+			},
+		},
 		{
 			name: "non empty dir but overwrite",
 			fset: FlagSet{
@@ -226,7 +332,7 @@ func TestCommondBeforeFunc(t *testing.T) {
 				tt.fset.flagSubscriptionId = "test"
 			}
 
-			err := commandBeforeFunc(&tt.fset, "")(nil)
+			err := commandBeforeFunc(&tt.fset, tt.mode)(nil)
 			if tt.err == "" {
 				require.NoError(t, err)
 				if tt.postCheck != nil {
@@ -12,6 +12,7 @@ import (
 
 	"github.com/Azure/aztfexport/internal/cfgfile"
 	"github.com/Azure/aztfexport/internal/log"
+	"github.com/Azure/aztfexport/internal/meta"
 	"github.com/Azure/aztfexport/pkg/config"
 	"github.com/Azure/aztfexport/pkg/telemetry"
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore/arm"
@@ -19,6 +20,7 @@ import (
 	azlog "github.com/Azure/azure-sdk-for-go/sdk/azcore/log"
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
 	"github.com/gofrs/uuid"
+	"github.com/magodo/armid"
 	"github.com/urfave/cli/v2"
 )
 
@@ -30,12 +32,23 @@ type FlagSet struct {
 	flagOutputDir                    string
 	flagOverwrite                    bool
 	flagAppend                       bool
+	flagResume                       bool
 	flagDevProvider                  bool
 	flagProviderVersion              string
 	flagProviderName                 string
 	flagBackendType                  string
 	flagBackendConfig                cli.StringSlice
 	flagConfigMode                   string
+	flagOutputFormat                 string
+	flagFileLayout                   string
+	flagModuleLayout                 string
+	flagModuleRuleFile               string
+	flagHoistVariables               bool
+	flagDataSourceForExternalRefs    bool
+	flagGenerateDependencyGraph      bool
+	flagGenerateOutputs              bool
+	flagConsolidateForEach           bool
+	flagOutputAttribute              cli.StringSlice
 	flagMaskSensitive                bool
 	flagParallelism                  int
 	flagContinue                     bool
@@ -45,6 +58,8 @@ type FlagSet struct {
 	flagHCLOnly                      bool
 	flagModulePath                   string
 	flagGenerateImportBlock          bool
+	flagPreviousMappingFile          string
+	flagDependencyResolutionFile     string
 	flagLogPath                      string
 	flagLogLevel                     string
 	flagExcludeAzureResource         cli.StringSlice
@@ -84,34 +99,61 @@ type FlagSet struct {
 	// flagResName (for single resource)
 	// flagResType (for single resource)
 	// flagPattern (for multi resources)
+	// flagNamingPolicy (for multi resources)
 	// flagRecursive
 	// flagIncludeResourceGroup
 	//
 	// rg:
 	// flagPattern
+	// flagNamingPolicy
+	// flagTag
 	//
 	// query:
 	// flagPattern
+	// flagNamingPolicy
+	// flagTag
 	// flagRecursive
 	// flagIncludeResourceGroup
 	// flagARGTable
 	// flagARGAuthorizationScopeFilter
+	// flagAdditionalSubscriptionId
+	//
+	// subscription, management-group:
+	// flagPattern
+	// flagNamingPolicy
+	// flagGovernanceOnly
+	//
+	// diff:
+	// flagDiffFormat
+	// flagDiffExitCode
 	flagPattern                     string
+	flagNamingPolicy                string
 	flagRecursive                   bool
 	flagResName                     string
 	flagResType                     string
 	flagIncludeResourceGroup        bool
 	flagARGTable                    string
 	flagARGAuthorizationScopeFilter string
+	flagAdditionalSubscriptionId    cli.StringSlice
+	flagTag                         cli.StringSlice
+	flagGovernanceOnly              bool
+	flagDiffFormat                  string
+	flagDiffExitCode                bool
 }
 
 type Mode string
 
 const (
-	ModeResource      Mode = "resource"
-	ModeResourceGroup Mode = "resource-group"
-	ModeQuery         Mode = "query"
-	ModeMappingFile   Mode = "mapping-file"
+	ModeResource        Mode = "resource"
+	ModeResourceGroup   Mode = "resource-group"
+	ModeQuery           Mode = "query"
+	ModeKQL             Mode = "kql"
+	ModeMappingFile     Mode = "mapping-file"
+	ModeSubscription    Mode = "subscription"
+	ModeManagementGroup Mode = "management-group"
+
+	ModeDiffResourceGroup Mode = "diff resource-group"
+	ModeDiffQuery         Mode = "diff query"
 )
 
 // DescribeCLI construct a description of the CLI based on the flag set and the specified mode.
@@ -124,6 +166,10 @@ func (flag FlagSet) DescribeCLI(mode Mode) string {
 	// - flagOutputDir
 	// - flagDevProvider
 	// - flagBackendConfig
+	// - flagNamingPolicy
+	// - flagModuleRuleFile
+	// - flagPreviousMappingFile
+	// - flagDependencyResolutionFile
 	// - all hflags
 
 	if flag.flagSubscriptionId != "" {
@@ -135,6 +181,9 @@ func (flag FlagSet) DescribeCLI(mode Mode) string {
 	if flag.flagAppend {
 		args = append(args, "--append=true")
 	}
+	if flag.flagResume {
+		args = append(args, "--resume=true")
+	}
 	if flag.flagProviderVersion != "" {
 		args = append(args, fmt.Sprintf(`-provider-version=%s`, flag.flagProviderVersion))
 	}
@@ -147,6 +196,33 @@ func (flag FlagSet) DescribeCLI(mode Mode) string {
 	if flag.flagConfigMode != "" && flag.flagConfigMode != string(config.ConfigModeMinimal) {
 		args = append(args, "--config-mode="+flag.flagConfigMode)
 	}
+	if flag.flagOutputFormat != "" && flag.flagOutputFormat != string(config.OutputFormatHCL) {
+		args = append(args, "--output-format="+flag.flagOutputFormat)
+	}
+	if flag.flagFileLayout != "" && flag.flagFileLayout != string(config.FileLayoutSingle) {
+		args = append(args, "--file-layout="+flag.flagFileLayout)
+	}
+	if flag.flagModuleLayout != "" && flag.flagModuleLayout != string(config.ModuleLayoutNone) {
+		args = append(args, "--module-layout="+flag.flagModuleLayout)
+	}
+	if flag.flagHoistVariables {
+		args = append(args, "--hoist-variables=true")
+	}
+	if flag.flagDataSourceForExternalRefs {
+		args = append(args, "--data-source-for-external-refs=true")
+	}
+	if flag.flagGenerateDependencyGraph {
+		args = append(args, "--generate-dependency-graph=true")
+	}
+	if flag.flagGenerateOutputs {
+		args = append(args, "--generate-outputs=true")
+	}
+	if v := flag.flagOutputAttribute.Value(); len(v) != 0 {
+		args = append(args, "--output-attribute="+strings.Join(v, ","))
+	}
+	if flag.flagConsolidateForEach {
+		args = append(args, "--consolidate-for-each=true")
+	}
 	if flag.flagMaskSensitive {
 		args = append(args, "--mask-sensitive=true")
 	}
@@ -258,6 +334,23 @@ func (flag FlagSet) DescribeCLI(mode Mode) string {
 	if flag.flagARGAuthorizationScopeFilter != "" {
 		args = append(args, "--arg-authorization-scope-filter="+flag.flagARGAuthorizationScopeFilter)
 	}
+	if v := flag.flagAdditionalSubscriptionId.Value(); len(v) != 0 {
+		for _, id := range v {
+			args = append(args, "--additional-subscription-id="+id)
+		}
+	}
+	for range flag.flagTag.Value() {
+		args = append(args, "--tag=*")
+	}
+	if flag.flagGovernanceOnly {
+		args = append(args, "--governance-only=true")
+	}
+	if flag.flagDiffFormat != "" {
+		args = append(args, "--format="+flag.flagDiffFormat)
+	}
+	if flag.flagDiffExitCode {
+		args = append(args, "--exit-code=true")
+	}
 	return "aztfexport " + strings.Join(args, " ")
 }
 
@@ -346,6 +439,214 @@ func (f FlagSet) buildAuthConfig() (*config.AuthConfig, error) {
 	return &c, nil
 }
 
+// BuildTagFilter builds the tag filter from the `--tag` values, each of which is in form of "key=value", or "key" to match any value of the tag.
+func (f FlagSet) BuildTagFilter() (map[string]string, error) {
+	var filter map[string]string
+	for _, v := range f.flagTag.Value() {
+		key, value, _ := strings.Cut(v, "=")
+		if strings.TrimSpace(key) == "" {
+			return nil, fmt.Errorf("invalid value of `--tag`: %q, expect `key=value` or `key`", v)
+		}
+		if filter == nil {
+			filter = map[string]string{}
+		}
+		filter[key] = value
+	}
+	return filter, nil
+}
+
+// BuildConfig builds the Config of the mode from the FlagSet, together with the command line arguments of the corresponding subcommand.
+// This is shared by the subcommands and the jobs of the spec file.
+func (f FlagSet) BuildConfig(mode Mode, args []string) (config.Config, error) {
+	switch mode {
+	case ModeResource:
+		if len(args) == 0 {
+			return config.Config{}, fmt.Errorf("No resource id specified")
+		}
+	case ModeResourceGroup:
+		if len(args) == 0 {
+			return config.Config{}, fmt.Errorf("No resource group specified")
+		}
+	case ModeQuery:
+		if len(args) == 0 {
+			return config.Config{}, fmt.Errorf("No query specified")
+		}
+		if len(args) > 1 {
+			return config.Config{}, fmt.Errorf("More than one queries specified. Use `and` with double quotes to run multiple query parameters.")
+		}
+	case ModeKQL:
+		if len(args) == 0 {
+			return config.Config{}, fmt.Errorf("No KQL query specified")
+		}
+		if len(args) > 1 {
+			return config.Config{}, fmt.Errorf("More than one KQL queries specified")
+		}
+	case ModeMappingFile:
+		if len(args) == 0 {
+			return config.Config{}, fmt.Errorf("No resource mapping file specified")
+		}
+		if len(args) > 1 {
+			return config.Config{}, fmt.Errorf("More than one resource mapping files specified")
+		}
+	case ModeSubscription:
+		if len(args) != 0 {
+			return config.Config{}, fmt.Errorf("No argument is expected, use `--subscription-id` to specify the subscription")
+		}
+	case ModeManagementGroup:
+		if len(args) == 0 {
+			return config.Config{}, fmt.Errorf("No management group specified")
+		}
+		if len(args) > 1 {
+			return config.Config{}, fmt.Errorf("More than one management groups specified")
+		}
+	default:
+		return config.Config{}, fmt.Errorf("unknown mode %q", mode)
+	}
+
+	commonConfig, err := f.BuildCommonConfig()
+	if err != nil {
+		return config.Config{}, err
+	}
+
+	switch mode {
+	case ModeResource:
+		resIds, err := parseResourceIdArgs(args)
+		if err != nil {
+			return config.Config{}, err
+		}
+		return config.Config{
+			CommonConfig:           commonConfig,
+			ResourceIds:            resIds,
+			TFResourceName:         f.flagResName,
+			TFResourceType:         f.flagResType,
+			ResourceNamePattern:    f.flagPattern,
+			NamingPolicyFile:       f.flagNamingPolicy,
+			RecursiveQuery:         f.flagRecursive,
+			IncludeResourceGroup:   f.flagIncludeResourceGroup,
+			IncludeExtensions:      f.flagIncludeExtension.Value(),
+			IncludeManagedResource: f.flagIncludeManagedResource,
+		}, nil
+	case ModeResourceGroup:
+		tagFilter, err := f.BuildTagFilter()
+		if err != nil {
+			return config.Config{}, err
+		}
+		cfg := config.Config{
+			CommonConfig:           commonConfig,
+			ResourceNamePattern:    f.flagPattern,
+			NamingPolicyFile:       f.flagNamingPolicy,
+			TagFilter:              tagFilter,
+			RecursiveQuery:         true,
+			IncludeExtensions:      f.flagIncludeExtension.Value(),
+			IncludeManagedResource: f.flagIncludeManagedResource,
+		}
+		if len(args) == 1 {
+			cfg.ResourceGroupName = args[0]
+		} else {
+			cfg.ResourceGroupNames = args
+		}
+		return cfg, nil
+	case ModeQuery:
+		tagFilter, err := f.BuildTagFilter()
+		if err != nil {
+			return config.Config{}, err
+		}
+		return config.Config{
+			CommonConfig:                commonConfig,
+			ARGPredicate:                args[0],
+			TagFilter:                   tagFilter,
+			ResourceNamePattern:         f.flagPattern,
+			NamingPolicyFile:            f.flagNamingPolicy,
+			RecursiveQuery:              f.flagRecursive,
+			IncludeExtensions:           f.flagIncludeExtension.Value(),
+			IncludeManagedResource:      f.flagIncludeManagedResource,
+			IncludeResourceGroup:        f.flagIncludeResourceGroup,
+			ARGTable:                    f.flagARGTable,
+			ARGAuthorizationScopeFilter: f.flagARGAuthorizationScopeFilter,
+			AdditionalSubscriptionIds:   f.flagAdditionalSubscriptionId.Value(),
+		}, nil
+	case ModeKQL:
+		query, err := meta.ReadKQLQuery(args[0])
+		if err != nil {
+			return config.Config{}, err
+		}
+		return config.Config{
+			CommonConfig:                commonConfig,
+			ARGQuery:                    query,
+			ResourceNamePattern:         f.flagPattern,
+			NamingPolicyFile:            f.flagNamingPolicy,
+			RecursiveQuery:              f.flagRecursive,
+			IncludeExtensions:           f.flagIncludeExtension.Value(),
+			IncludeManagedResource:      f.flagIncludeManagedResource,
+			IncludeResourceGroup:        f.flagIncludeResourceGroup,
+			ARGAuthorizationScopeFilter: f.flagARGAuthorizationScopeFilter,
+			AdditionalSubscriptionIds:   f.flagAdditionalSubscriptionId.Value(),
+		}, nil
+	case ModeMappingFile:
+		return config.Config{
+			CommonConfig: commonConfig,
+			MappingFile:  args[0],
+		}, nil
+	case ModeSubscription:
+		return config.Config{
+			CommonConfig:           commonConfig,
+			ExportSubscription:     true,
+			GovernanceOnly:         f.flagGovernanceOnly,
+			ResourceNamePattern:    f.flagPattern,
+			NamingPolicyFile:       f.flagNamingPolicy,
+			IncludeExtensions:      f.flagIncludeExtension.Value(),
+			IncludeManagedResource: f.flagIncludeManagedResource,
+		}, nil
+	default: // ModeManagementGroup
+		return config.Config{
+			CommonConfig:           commonConfig,
+			ManagementGroupName:    args[0],
+			GovernanceOnly:         f.flagGovernanceOnly,
+			ResourceNamePattern:    f.flagPattern,
+			NamingPolicyFile:       f.flagNamingPolicy,
+			IncludeExtensions:      f.flagIncludeExtension.Value(),
+			IncludeManagedResource: f.flagIncludeManagedResource,
+		}, nil
+	}
+}
+
+// parseResourceIdArgs parses the resource id arguments, each of which is either a resource id, or a path to a file (prefixed with `@`) that contains a resource id in each line.
+func parseResourceIdArgs(args []string) ([]string, error) {
+	var resIds []string
+	for _, arg := range args {
+		if !strings.HasPrefix(arg, "@") {
+			if _, err := armid.ParseResourceId(arg); err != nil {
+				return nil, fmt.Errorf("invalid resource id: %v", err)
+			}
+			resIds = append(resIds, arg)
+			continue
+		}
+
+		path := strings.TrimPrefix(arg, "@")
+		// #nosec G304
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open file %q: %v", path, err)
+		}
+
+		if err := func() error {
+			defer f.Close()
+			scanner := bufio.NewScanner(f)
+			for scanner.Scan() {
+				resId := strings.TrimSpace(scanner.Text())
+				if _, err := armid.ParseResourceId(resId); err != nil {
+					return fmt.Errorf("invalid resource id (contained in %q): %v", path, err)
+				}
+				resIds = append(resIds, resId)
+			}
+			return scanner.Err()
+		}(); err != nil {
+			return nil, fmt.Errorf("scanning %q: %v", path, err)
+		}
+	}
+	return resIds, nil
+}
+
 // BuildCommonConfig builds the CommonConfig from the FlagSet, except the TFClient, which is built afterwards as it requires a logger.
 func (f FlagSet) BuildCommonConfig() (config.CommonConfig, error) {
 	// Logger is only enabled when the log path is specified.
@@ -447,6 +748,11 @@ func (f FlagSet) BuildCommonConfig() (config.CommonConfig, error) {
 		}
 	}
 
+	outputAttributes, err := parseOutputAttributes(f.flagOutputAttribute.Value())
+	if err != nil {
+		return config.CommonConfig{}, err
+	}
+
 	cfg := config.CommonConfig{
 		Logger:                    logger,
 		AuthConfig:                *authConfig,
@@ -461,28 +767,69 @@ func (f FlagSet) BuildCommonConfig() (config.CommonConfig, error) {
 		BackendType:               f.flagBackendType,
 		BackendConfig:             f.flagBackendConfig.Value(),
 		ConfigMode:                config.ConfigMode(f.flagConfigMode),
+		OutputFormat:              config.OutputFormat(f.flagOutputFormat),
+		FileLayout:                config.FileLayout(f.flagFileLayout),
+		ModuleLayout:              config.ModuleLayout(f.flagModuleLayout),
+		ModuleRuleFile:            f.flagModuleRuleFile,
+		HoistVariables:            f.flagHoistVariables,
+		DataSourceForExternalRefs: f.flagDataSourceForExternalRefs,
+		GenerateDependencyGraph:   f.flagGenerateDependencyGraph,
+		GenerateOutputs:           f.flagGenerateOutputs,
+		ConsolidateForEach:        f.flagConsolidateForEach,
+		OutputAttributes:          outputAttributes,
 		MaskSensitive:             f.flagMaskSensitive,
 		Parallelism:               f.flagParallelism,
 		HCLOnly:                   f.flagHCLOnly,
 		ModulePath:                f.flagModulePath,
 		GenerateImportBlock:       f.flagGenerateImportBlock,
+		PreviousMappingFile:       f.flagPreviousMappingFile,
+		DependencyResolutionFile:  f.flagDependencyResolutionFile,
 		TelemetryClient:           initTelemetryClient(f.flagSubscriptionId),
 		ExcludeAzureResources:     excludeAzureResource,
 		ExcludeTerraformResources: excludeTerraformResource,
+		Resume:                    f.flagResume,
 	}
 
 	if f.flagAppend {
+		cfgFileExt := ".tf"
+		if cfg.OutputFormat == config.OutputFormatJSON {
+			cfgFileExt = ".tf.json"
+		}
 		cfg.OutputFileNames = config.OutputFileNames{
 			TerraformFileName:   "terraform.aztfexport.tf",
 			ProviderFileName:    "provider.aztfexport.tf",
-			MainFileName:        "main.aztfexport.tf",
-			ImportBlockFileName: "import.aztfexport.tf",
+			MainFileName:        "main.aztfexport" + cfgFileExt,
+			ImportBlockFileName: "import.aztfexport" + cfgFileExt,
+			MovedBlockFileName:  "moved.aztfexport" + cfgFileExt,
+			DataSourceFileName:  "data.aztfexport" + cfgFileExt,
+			VariableFileName:    "variables.aztfexport" + cfgFileExt,
+			OutputFileName:      "outputs.aztfexport" + cfgFileExt,
+			ModuleFileName:      "modules.aztfexport" + cfgFileExt,
 		}
 	}
 
 	return cfg, nil
 }
 
+// parseOutputAttributes parses the values of `--output-attribute` in the form of "<type>.<attribute>" into a map keyed by the TF resource type.
+func parseOutputAttributes(values []string) (map[string][]string, error) {
+	if len(values) == 0 {
+		return nil, nil
+	}
+	out := map[string][]string{}
+	for _, v := range values {
+		tfType, attr, ok := strings.Cut(v, ".")
+		if !ok || tfType == "" || attr == "" {
+			return nil, fmt.Errorf(`invalid output attribute %q: must be in the form of "<type>.<attribute>"`, v)
+		}
+		if _, err := meta.ParseOutputAttribute(attr); err != nil {
+			return nil, fmt.Errorf("invalid output attribute %q: %v", v, err)
+		}
+		out[tfType] = append(out[tfType], attr)
+	}
+	return out, nil
+}
+
 func logLevel(level string) (slog.Level, error) {
 	switch strings.ToUpper(level) {
 	case "ERROR":
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildConfig(t *testing.T) {
+	dir := t.TempDir()
+	idFile := filepath.Join(dir, "ids.txt")
+	require.NoError(t, os.WriteFile(idFile, []byte("/subscriptions/123/resourceGroups/rg2\n/subscriptions/123/resourceGroups/rg3\n"), 0644))
+	kqlFile := filepath.Join(dir, "network.kql")
+	require.NoError(t, os.WriteFile(kqlFile, []byte("resources"), 0644))
+
+	fset := FlagSet{
+		flagSubscriptionId: "123",
+		flagEnv:            "public",
+		flagTenantId:       "00000000-0000-0000-0000-000000000000",
+		flagClientId:       "00000000-0000-0000-0000-000000000000",
+		flagClientSecret:   "secret",
+		flagOutputDir:      dir,
+		flagProviderName:   "azurerm",
+		flagGovernanceOnly: true,
+	}
+
+	cfg, err := fset.BuildConfig(ModeResource, []string{"/subscriptions/123/resourceGroups/rg1", "@" + idFile})
+	require.NoError(t, err)
+	require.Equal(t, []string{"/subscriptions/123/resourceGroups/rg1", "/subscriptions/123/resourceGroups/rg2", "/subscriptions/123/resourceGroups/rg3"}, cfg.ResourceIds)
+
+	cfg, err = fset.BuildConfig(ModeResourceGroup, []string{"rg1"})
+	require.NoError(t, err)
+	require.Equal(t, "rg1", cfg.ResourceGroupName)
+
+	cfg, err = fset.BuildConfig(ModeResourceGroup, []string{"rg1", "app-*"})
+	require.NoError(t, err)
+	require.Equal(t, []string{"rg1", "app-*"}, cfg.ResourceGroupNames)
+
+	cfg, err = fset.BuildConfig(ModeKQL, []string{kqlFile})
+	require.NoError(t, err)
+	require.Equal(t, "resources", cfg.ARGQuery)
+
+	cfg, err = fset.BuildConfig(ModeSubscription, nil)
+	require.NoError(t, err)
+	require.True(t, cfg.ExportSubscription)
+	require.True(t, cfg.GovernanceOnly)
+
+	cfg, err = fset.BuildConfig(ModeManagementGroup, []string{"mg1"})
+	require.NoError(t, err)
+	require.Equal(t, "mg1", cfg.ManagementGroupName)
+
+	_, err = fset.BuildConfig(ModeResource, []string{"foo"})
+	require.ErrorContains(t, err, "invalid resource id")
+
+	_, err = fset.BuildConfig(ModeQuery, []string{"a", "b"})
+	require.ErrorContains(t, err, "More than one queries specified")
+
+	_, err = fset.BuildConfig(ModeSubscription, []string{"123"})
+	require.ErrorContains(t, err, "No argument is expected")
+}
@@ -4,6 +4,7 @@ import (
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore/arm"
 	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/keyvault/armkeyvault"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/resourcegraph/armresourcegraph"
 	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/resources/armresources"
 )
 
@@ -35,3 +36,20 @@ func (b *ClientBuilder) NewResourcesClient(subscriptionId string) (*armresources
 		&b.Opt,
 	)
 }
+
+func (b *ClientBuilder) NewResourceGraphClient() (*armresourcegraph.Client, error) {
+	return armresourcegraph.NewClient(
+		b.Credential,
+		&b.Opt,
+	)
+}
+
+// NewARMClient builds a generic ARM client, which is used for the APIs that are not covered by the SDK clients.
+func (b *ClientBuilder) NewARMClient() (*arm.Client, error) {
+	return arm.NewClient(
+		"aztfexport",
+		"v0.0.0",
+		b.Credential,
+		&b.Opt,
+	)
+}
@@ -0,0 +1,14 @@
+package config
+
+import "github.com/Azure/aztfexport/pkg/config"
+
+type DiffConfig struct {
+	config.Config
+
+	// BaseMappingFile is the path of the resource mapping file from a previous export, which is compared against the live resources of the scope.
+	BaseMappingFile string
+	// OutputFormat is the format of the diff report, either "text" or "json".
+	OutputFormat string
+	// ExitCode indicates to return an error when there is any difference.
+	ExitCode bool
+}
@@ -0,0 +1,65 @@
+package internal
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/Azure/aztfexport/internal/config"
+	"github.com/Azure/aztfexport/internal/resmap"
+	"github.com/Azure/aztfexport/pkg/meta"
+)
+
+const (
+	DiffOutputFormatText = "text"
+	DiffOutputFormatJSON = "json"
+)
+
+// ErrDiffHasChanges is returned by Diff when the ExitCode is set and there is any difference.
+var ErrDiffHasChanges = errors.New("the resource mapping differs from the live resources")
+
+// Diff lists the resources of the scope, and compares them against the resource mapping file from a previous export.
+func Diff(ctx context.Context, cfg config.DiffConfig) error {
+	// #nosec G304
+	b, err := os.ReadFile(cfg.BaseMappingFile)
+	if err != nil {
+		return fmt.Errorf("reading mapping file %s: %v", cfg.BaseMappingFile, err)
+	}
+	var base resmap.ResourceMapping
+	if err := json.Unmarshal(b, &base); err != nil {
+		return fmt.Errorf("unmarshalling the mapping file: %v", err)
+	}
+	if err := base.Validate(); err != nil {
+		return fmt.Errorf("invalid mapping file %s: %v", cfg.BaseMappingFile, err)
+	}
+
+	c, err := meta.NewMeta(cfg.Config)
+	if err != nil {
+		return err
+	}
+
+	list, err := c.ListResource(ctx)
+	if err != nil {
+		return err
+	}
+
+	result := resmap.Diff(base, list.ResourceMapping())
+
+	switch cfg.OutputFormat {
+	case DiffOutputFormatJSON:
+		b, err := json.MarshalIndent(result, "", "  ")
+		if err != nil {
+			return fmt.Errorf("marshalling the diff result: %v", err)
+		}
+		fmt.Println(string(b))
+	default:
+		fmt.Print(result.String())
+	}
+
+	if cfg.ExitCode && result.HasChanges() {
+		return ErrDiffHasChanges
+	}
+	return nil
+}
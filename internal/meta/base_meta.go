@@ -1,7 +1,6 @@
 package meta
 
 import (
-	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
@@ -45,6 +44,9 @@ import (
 
 const ResourceMappingFileName = "aztfexportResourceMapping.json"
 const SkippedResourcesFileName = "aztfexportSkippedResources.txt"
+const DependencyGraphDOTFileName = "aztfexportDependencyGraph.dot"
+const DependencyGraphJSONFileName = "aztfexportDependencyGraph.json"
+const DependencyResolutionFileName = "aztfexportDependencyResolution.json"
 
 type TFConfigTransformer func(configs ConfigInfos) (ConfigInfos, error)
 
@@ -61,14 +63,19 @@ type BaseMeta interface {
 	Workspace() string
 	// ParallelImport imports the specified import list in parallel (parallelism is set during the meta builder function).
 	// Import error won't be returned in the error, but is recorded in each ImportItem.
+	// The outcome of each item is also recorded in the checkpoint journal in the output directory, so that an interrupted export can be resumed.
 	ParallelImport(ctx context.Context, items []*ImportItem) error
 	// PushState pushes the terraform state file (the base state of the workspace, adding the newly imported resources) back to the workspace.
 	PushState(ctx context.Context) error
 	// CleanTFState clean up the specified TF resource from the workspace's state file.
 	CleanTFState(ctx context.Context, addr string)
 	// GetTerraformCfg generates the TF configuration from the import list. Only resources successfully imported will be processed.
+	// The configuration is a single file of the root module, which is not supported in case the module layout, the data sources or the variable hoisting is specified.
 	GetTerraformCfg(ctx context.Context, l ImportList) ([]byte, error)
 	// WriteTerraformCfg writes the TF configuration generated from the import list. Only resources successfully imported will be processed.
+	// The configuration is split into multiple files in case a file layout other than the single file is specified.
+	// In case variable hoisting is specified, the repeated literal values are hoisted into the variable file.
+	// In case output generation is specified, the outputs of the resources are appended to the output file.
 	WriteTerraformCfg(ctx context.Context, l ImportList) error
 	// GetSkippedResources get list of resources that are skipped to be imported.
 	GetSkippedResources(ctx context.Context, l ImportList) []string
@@ -78,8 +85,14 @@ type BaseMeta interface {
 	GetImportBlocks(ctx context.Context, l ImportList) []byte
 	// WriteResourceMapping writes a resource mapping file to the output directory. In case import block generation is specified, a TF import block file will also be generated.
 	WriteResourceMapping(ctx context.Context, l ImportList) error
+	// GetAmbiguousDependencies returns the unresolved ambiguous dependencies of the resources successfully imported, i.e. the references to the TF resource ids
+	// that map to multiple resources, which can be resolved via SetDependencyResolutions.
+	GetAmbiguousDependencies(ctx context.Context, l ImportList) ([]AmbiguousDependency, error)
+	// SetDependencyResolutions adds the resolutions of the ambiguous dependencies, which take precedence over the ones from the dependency resolution file.
+	// The resolved dependencies are applied by WriteTerraformCfg, which also writes all the resolutions to the output directory.
+	SetDependencyResolutions(r DependencyResolutions)
 	// CleanUpWorkspace is a weired method that is only meant to be used internally by aztfexport, which under the hood will remove everything in the output directory, except the generated TF config.
-	// This method does nothing if HCLOnly in the Config is not set.
+	// Other than removing the checkpoint files, this method does nothing if HCLOnly in the Config is not set.
 	CleanUpWorkspace(ctx context.Context) error
 
 	SetPreImportHook(config.ImportCallback)
@@ -89,20 +102,29 @@ type BaseMeta interface {
 var _ BaseMeta = &baseMeta{}
 
 type baseMeta struct {
-	logger            *slog.Logger
-	subscriptionId    string
-	azureSDKCred      azcore.TokenCredential
-	azureSDKClientOpt arm.ClientOptions
-	outdir            string
-	outputFileNames   config.OutputFileNames
-	tf                *tfexec.Terraform
-	resourceClient    *armresources.Client
-	providerVersion   string
-	devProvider       bool
-	providerName      string
-	backendType       string
-	backendConfig     []string
-	providerConfig    map[string]cty.Value
+	logger                    *slog.Logger
+	subscriptionId            string
+	azureSDKCred              azcore.TokenCredential
+	azureSDKClientOpt         arm.ClientOptions
+	outdir                    string
+	outputFileNames           config.OutputFileNames
+	outputFormatter           outputFormatter
+	fileLayout                config.FileLayout
+	moduleLayout              moduleLayout
+	hoistVariables            bool
+	dataSourceForExternalRefs bool
+	generateDependencyGraph   bool
+	generateOutputs           bool
+	consolidateForEach        bool
+	outputAttributes          map[string][]string
+	tf                        *tfexec.Terraform
+	resourceClient            *armresources.Client
+	providerVersion           string
+	devProvider               bool
+	providerName              string
+	backendType               string
+	backendConfig             []string
+	providerConfig            map[string]cty.Value
 
 	// tfadd options
 	configMode    config.ConfigMode
@@ -112,6 +134,10 @@ type baseMeta struct {
 	preImportHook      config.ImportCallback
 	postImportHook     config.ImportCallback
 	generateImportFile bool
+	// The resource mapping of a previous export, against which the moved blocks are generated.
+	previousMapping resmap.ResourceMapping
+	// The resolutions of the ambiguous dependencies, which is never nil.
+	dependencyResolutions DependencyResolutions
 
 	hclOnly  bool
 	tfclient tfclient.Client
@@ -127,6 +153,10 @@ type baseMeta struct {
 	importBaseDirs   []string
 	importModuleDirs []string
 	importTFs        []*tfexec.Terraform
+	// The child modules of the module layout that are declared in each import directory
+	importChildModules []map[string]bool
+	// The aliased provider configs that are defined in each import directory
+	importProviderAliases []map[string]bool
 
 	// The original base state, which is retrieved prior to the import, and is compared with the actual base state prior to the mutated state is pushed,
 	// to ensure the base state has no out of band changes during the importing.
@@ -134,6 +164,11 @@ type baseMeta struct {
 	// The current base state, which is mutated during the importing
 	baseState []byte
 
+	// Whether to resume from the checkpoint of a previous interrupted run
+	resume bool
+	// The checkpoint journal, which records the outcome of each imported item together with a snapshot of the base state
+	checkpoint *checkpoint
+
 	// Azure resource ID patterns (regexp, case insensitive) to exclude
 	excludeAzureResources []regexp.Regexp
 	// Terrraform resource types to exclude
@@ -152,6 +187,9 @@ func NewBaseMeta(cfg config.CommonConfig) (*baseMeta, error) {
 	if cfg.TFClient != nil && !cfg.HCLOnly {
 		return nil, fmt.Errorf("TFClient must be used together with HCLOnly")
 	}
+	if cfg.TFClient != nil && cfg.Resume {
+		return nil, fmt.Errorf("TFClient conflicts with Resume in the config")
+	}
 
 	// Determine the module directory and module address
 	var (
@@ -185,6 +223,84 @@ func NewBaseMeta(cfg config.CommonConfig) (*baseMeta, error) {
 		return nil, fmt.Errorf("new resource client")
 	}
 
+	// Resolve OutputFormat.
+	outputFormat := cfg.OutputFormat
+	switch outputFormat {
+	case "":
+		outputFormat = config.OutputFormatHCL
+	case config.OutputFormatHCL, config.OutputFormatJSON:
+		// ok
+	default:
+		return nil, fmt.Errorf("invalid OutputFormat %q: must be one of %q, %q",
+			cfg.OutputFormat,
+			config.OutputFormatHCL,
+			config.OutputFormatJSON,
+		)
+	}
+	// Resolve FileLayout.
+	fileLayout := cfg.FileLayout
+	switch fileLayout {
+	case "":
+		fileLayout = config.FileLayoutSingle
+	case config.FileLayoutSingle, config.FileLayoutResourceType, config.FileLayoutProvider, config.FileLayoutResourceGroup:
+		// ok
+	default:
+		return nil, fmt.Errorf("invalid FileLayout %q: must be one of %q, %q, %q, %q",
+			cfg.FileLayout,
+			config.FileLayoutSingle,
+			config.FileLayoutResourceType,
+			config.FileLayoutProvider,
+			config.FileLayoutResourceGroup,
+		)
+	}
+
+	// Resolve ModuleLayout.
+	modLayout := cfg.ModuleLayout
+	switch modLayout {
+	case "":
+		modLayout = config.ModuleLayoutNone
+	case config.ModuleLayoutNone, config.ModuleLayoutResourceGroup, config.ModuleLayoutResourceType, config.ModuleLayoutProvider, config.ModuleLayoutRule:
+		// ok
+	default:
+		return nil, fmt.Errorf("invalid ModuleLayout %q: must be one of %q, %q, %q, %q, %q",
+			cfg.ModuleLayout,
+			config.ModuleLayoutNone,
+			config.ModuleLayoutResourceGroup,
+			config.ModuleLayoutResourceType,
+			config.ModuleLayoutProvider,
+			config.ModuleLayoutRule,
+		)
+	}
+	var moduleRules *ModuleRules
+	if modLayout == config.ModuleLayoutRule {
+		if cfg.ModuleRuleFile == "" {
+			return nil, fmt.Errorf("ModuleRuleFile is required by the ModuleLayout %q", modLayout)
+		}
+		rules, err := ParseModuleRuleFile(cfg.ModuleRuleFile)
+		if err != nil {
+			return nil, fmt.Errorf("parsing the module rule file: %v", err)
+		}
+		moduleRules = rules
+	} else if cfg.ModuleRuleFile != "" {
+		return nil, fmt.Errorf("ModuleRuleFile only applies to the ModuleLayout %q", config.ModuleLayoutRule)
+	}
+	if modLayout != config.ModuleLayoutNone {
+		if cfg.ModulePath != "" {
+			return nil, fmt.Errorf("ModuleLayout conflicts with ModulePath in the config")
+		}
+		if cfg.HoistVariables {
+			return nil, fmt.Errorf("ModuleLayout conflicts with HoistVariables in the config")
+		}
+		if cfg.DataSourceForExternalRefs {
+			return nil, fmt.Errorf("ModuleLayout conflicts with DataSourceForExternalRefs in the config")
+		}
+	}
+
+	cfgFileExt := ".tf"
+	if outputFormat == config.OutputFormatJSON {
+		cfgFileExt = ".tf.json"
+	}
+
 	outputFileNames := cfg.OutputFileNames
 	if outputFileNames.TerraformFileName == "" {
 		outputFileNames.TerraformFileName = "terraform.tf"
@@ -193,10 +309,33 @@ func NewBaseMeta(cfg config.CommonConfig) (*baseMeta, error) {
 		outputFileNames.ProviderFileName = "provider.tf"
 	}
 	if outputFileNames.MainFileName == "" {
-		outputFileNames.MainFileName = "main.tf"
+		outputFileNames.MainFileName = "main" + cfgFileExt
 	}
 	if outputFileNames.ImportBlockFileName == "" {
-		outputFileNames.ImportBlockFileName = "import.tf"
+		outputFileNames.ImportBlockFileName = "import" + cfgFileExt
+	}
+	if outputFileNames.MovedBlockFileName == "" {
+		outputFileNames.MovedBlockFileName = "moved" + cfgFileExt
+	}
+	if outputFileNames.DataSourceFileName == "" {
+		outputFileNames.DataSourceFileName = "data" + cfgFileExt
+	}
+	if outputFileNames.VariableFileName == "" {
+		outputFileNames.VariableFileName = "variables" + cfgFileExt
+	}
+	if outputFileNames.OutputFileName == "" {
+		outputFileNames.OutputFileName = "outputs" + cfgFileExt
+	}
+	if outputFileNames.ModuleFileName == "" {
+		outputFileNames.ModuleFileName = "modules" + cfgFileExt
+	}
+
+	for tfType, attrs := range cfg.OutputAttributes {
+		for _, attr := range attrs {
+			if _, err := ParseOutputAttribute(attr); err != nil {
+				return nil, fmt.Errorf("invalid OutputAttributes of %s: %v", tfType, err)
+			}
+		}
 	}
 
 	tc := cfg.TelemetryClient
@@ -287,6 +426,34 @@ func NewBaseMeta(cfg config.CommonConfig) (*baseMeta, error) {
 		excludeAzureResources = append(excludeAzureResources, *re)
 	}
 
+	var previousMapping resmap.ResourceMapping
+	if cfg.PreviousMappingFile != "" {
+		// #nosec G304
+		b, err := os.ReadFile(cfg.PreviousMappingFile)
+		if err != nil {
+			return nil, fmt.Errorf("reading the previous mapping file %s: %v", cfg.PreviousMappingFile, err)
+		}
+		if err := json.Unmarshal(b, &previousMapping); err != nil {
+			return nil, fmt.Errorf("unmarshalling the previous mapping file %s: %v", cfg.PreviousMappingFile, err)
+		}
+		if err := previousMapping.Validate(); err != nil {
+			return nil, fmt.Errorf("invalid previous mapping file %s: %v", cfg.PreviousMappingFile, err)
+		}
+	}
+
+	dependencyResolutions := DependencyResolutions{}
+	if cfg.DependencyResolutionFile != "" {
+		r, err := ParseDependencyResolutionFile(cfg.DependencyResolutionFile)
+		if err != nil {
+			return nil, fmt.Errorf("parsing the dependency resolution file: %v", err)
+		}
+		for id, m := range r {
+			for tfResourceId, chosen := range m {
+				dependencyResolutions.Set(id, tfResourceId, chosen)
+			}
+		}
+	}
+
 	// Resolve ConfigMode.
 	configMode := cfg.ConfigMode
 	switch configMode {
@@ -304,31 +471,45 @@ func NewBaseMeta(cfg config.CommonConfig) (*baseMeta, error) {
 	}
 
 	meta := &baseMeta{
-		logger:             cfg.Logger,
-		subscriptionId:     cfg.SubscriptionId,
-		azureSDKCred:       cfg.AzureSDKCredential,
-		azureSDKClientOpt:  cfg.AzureSDKClientOption,
-		outdir:             cfg.OutputDir,
-		outputFileNames:    outputFileNames,
-		resourceClient:     resClient,
-		providerVersion:    cfg.ProviderVersion,
-		devProvider:        cfg.DevProvider,
-		backendType:        cfg.BackendType,
-		backendConfig:      cfg.BackendConfig,
-		providerConfig:     providerConfig,
-		providerName:       cfg.ProviderName,
-		configMode:         configMode,
-		maskSensitive:      cfg.MaskSensitive,
-		parallelism:        cfg.Parallelism,
-		preImportHook:      cfg.PreImportHook,
-		postImportHook:     cfg.PostImportHook,
-		generateImportFile: cfg.GenerateImportBlock,
-		hclOnly:            cfg.HCLOnly,
-		tfclient:           cfg.TFClient,
+		logger:                    cfg.Logger,
+		subscriptionId:            cfg.SubscriptionId,
+		azureSDKCred:              cfg.AzureSDKCredential,
+		azureSDKClientOpt:         cfg.AzureSDKClientOption,
+		outdir:                    cfg.OutputDir,
+		outputFileNames:           outputFileNames,
+		outputFormatter:           newOutputFormatter(outputFormat),
+		fileLayout:                fileLayout,
+		moduleLayout:              moduleLayout{layout: modLayout, rules: moduleRules},
+		hoistVariables:            cfg.HoistVariables,
+		dataSourceForExternalRefs: cfg.DataSourceForExternalRefs,
+		generateDependencyGraph:   cfg.GenerateDependencyGraph,
+		generateOutputs:           cfg.GenerateOutputs,
+		consolidateForEach:        cfg.ConsolidateForEach,
+		outputAttributes:          cfg.OutputAttributes,
+		resourceClient:            resClient,
+		providerVersion:           cfg.ProviderVersion,
+		devProvider:               cfg.DevProvider,
+		backendType:               cfg.BackendType,
+		backendConfig:             cfg.BackendConfig,
+		providerConfig:            providerConfig,
+		providerName:              cfg.ProviderName,
+		configMode:                configMode,
+		maskSensitive:             cfg.MaskSensitive,
+		parallelism:               cfg.Parallelism,
+		preImportHook:             cfg.PreImportHook,
+		postImportHook:            cfg.PostImportHook,
+		generateImportFile:        cfg.GenerateImportBlock,
+		previousMapping:           previousMapping,
+		dependencyResolutions:     dependencyResolutions,
+		hclOnly:                   cfg.HCLOnly,
+		tfclient:                  cfg.TFClient,
 
 		moduleAddr: moduleAddr,
 		moduleDir:  moduleDir,
 
+		resume:     cfg.Resume,
+		checkpoint: newCheckpoint(cfg.OutputDir),
+
 		excludeAzureResources:     excludeAzureResources,
 		excludeTerraformResources: cfg.ExcludeTerraformResources,
 
@@ -393,6 +574,9 @@ func (meta *baseMeta) ParallelImport(ctx context.Context, items []*ImportItem) e
 	}
 	close(itemsCh)
 
+	// The items handled by each import directory, which are recorded to the checkpoint journal once merged.
+	workerItems := make([][]*ImportItem, meta.parallelism)
+
 	wp := workerpool.NewWorkPool(meta.parallelism)
 
 	wp.Run(func(i interface{}) error {
@@ -407,6 +591,9 @@ func (meta *baseMeta) ParallelImport(ctx context.Context, items []*ImportItem) e
 
 		// Don't merge state file if this import dir doesn't contain state file, which can because either this import dir imported nothing, or it encountered import error
 		if _, err := os.Stat(stateFile); os.IsNotExist(err) {
+			if err := meta.checkpoint.record(nil, workerItems[idx]); err != nil {
+				return fmt.Errorf("recording checkpoint: %v", err)
+			}
 			return nil
 		}
 		// Ensure the state file is removed after this round import, preparing for the next round.
@@ -419,6 +606,10 @@ func (meta *baseMeta) ParallelImport(ctx context.Context, items []*ImportItem) e
 		}
 		meta.baseState = newState
 
+		if err := meta.checkpoint.record(meta.baseState, workerItems[idx]); err != nil {
+			return fmt.Errorf("recording checkpoint: %v", err)
+		}
+
 		return nil
 	})
 
@@ -426,6 +617,16 @@ func (meta *baseMeta) ParallelImport(ctx context.Context, items []*ImportItem) e
 		i := i
 		wp.AddTask(func() (interface{}, error) {
 			for item := range itemsCh {
+				if item.Managed {
+					meta.Logger().Info("Skipping resource that is already managed by the state", "tf_id", item.TFResourceId, "tf_addr", item.TFAddr)
+					continue
+				}
+				if meta.checkpoint.isImported(item) {
+					meta.Logger().Info("Skipping resource that is already imported (checkpoint)", "tf_id", item.TFResourceId, "tf_addr", item.TFAddr)
+					item.ImportError = nil
+					item.Imported = true
+					continue
+				}
 				iitem := config.ImportItem{
 					AzureResourceID: item.AzureResourceID,
 					TFResourceId:    item.TFResourceId,
@@ -440,6 +641,7 @@ func (meta *baseMeta) ParallelImport(ctx context.Context, items []*ImportItem) e
 				if meta.postImportHook != nil {
 					meta.postImportHook(startTime, iitem)
 				}
+				workerItems[i] = append(workerItems[i], item)
 			}
 			return i, nil
 		})
@@ -501,52 +703,268 @@ func (meta baseMeta) PushState(ctx context.Context) error {
 }
 
 func (meta baseMeta) GetTerraformCfg(ctx context.Context, l ImportList) ([]byte, error) {
-	return meta.generateCfg(ctx, l, meta.lifecycleAddon, meta.addDependency)
+	// These are only applied by WriteTerraformCfg, which writes the child modules, the data sources and the variables along with the configuration.
+	switch {
+	case meta.moduleLayout.enabled():
+		return nil, fmt.Errorf("getting the TF configuration is not supported with the module layout, use WriteTerraformCfg instead")
+	case meta.dataSourceForExternalRefs:
+		return nil, fmt.Errorf("getting the TF configuration is not supported with the data sources for the external references, use WriteTerraformCfg instead")
+	case meta.hoistVariables:
+		return nil, fmt.Errorf("getting the TF configuration is not supported with the variable hoisting, use WriteTerraformCfg instead")
+	}
+	cfginfos, err := meta.generateCfg(ctx, l, meta.configTransformers()...)
+	if err != nil {
+		return nil, err
+	}
+	return meta.outputFormatter.Config(cfginfos)
+}
+
+// configTransformers returns the transformers of the generated configs.
+// The collectors are applied right after the dependencies are populated, before any consolidation.
+func (meta baseMeta) configTransformers(collectors ...TFConfigTransformer) []TFConfigTransformer {
+	cfgTrans := []TFConfigTransformer{meta.lifecycleAddon, meta.providerAddon, meta.addDependency}
+	cfgTrans = append(cfgTrans, collectors...)
+	if meta.consolidateForEach {
+		cfgTrans = append(cfgTrans, meta.consolidateToForEach)
+	}
+	return cfgTrans
 }
 
 func (meta baseMeta) WriteTerraformCfg(ctx context.Context, l ImportList) error {
-	b, err := meta.GetTerraformCfg(ctx, l)
+	var collectors []TFConfigTransformer
+	var depGraph *dependencyGraph
+	if meta.generateDependencyGraph {
+		depGraph = newDependencyGraph(meta.moduleLayout)
+		collectors = append(collectors, depGraph.Collect)
+	}
+	cfgTrans := meta.configTransformers(collectors...)
+	// The references across the child modules are wired after the references are finalized (e.g. by the consolidation).
+	var wiring *moduleWiring
+	if meta.moduleLayout.enabled() {
+		wiring = newModuleWiring(meta.moduleLayout)
+		cfgTrans = append(cfgTrans, wiring.Wire)
+	}
+	// The data sources are substituted before the variable hoisting, so that the substituted resource ids are not hoisted.
+	var substituter *dataSourceSubstituter
+	if meta.dataSourceForExternalRefs {
+		substituter = meta.newDataSourceSubstituter()
+		cfgTrans = append(cfgTrans, substituter.Substitute)
+	}
+	var hoister *variableHoister
+	if meta.hoistVariables {
+		module, diags := tfconfig.LoadModule(meta.moduleDir)
+		if diags.HasErrors() {
+			return fmt.Errorf("loading the module %s: %v", meta.moduleDir, diags.Err())
+		}
+		var reservedNames []string
+		for name := range module.Variables {
+			reservedNames = append(reservedNames, name)
+		}
+		hoister = newVariableHoister(reservedNames)
+		cfgTrans = append(cfgTrans, hoister.Hoist)
+	}
+
+	cfginfos, err := meta.generateCfg(ctx, l, cfgTrans...)
 	if err != nil {
 		return fmt.Errorf("genering terraform config: %v", err)
 	}
-	cfgFile := filepath.Join(meta.moduleDir, meta.outputFileNames.MainFileName)
-	if err := appendToFile(cfgFile, string(b)); err != nil {
-		return fmt.Errorf("generating main configuration file: %w", err)
+
+	// The import blocks are written before the config is generated, they need to be rewritten to the instance addresses of the for_each resources.
+	if meta.generateImportFile {
+		if kl, ok := forEachImportList(l, cfginfos); ok {
+			if err := meta.writeImportBlocks(ctx, kl); err != nil {
+				return err
+			}
+		}
+	}
+	// So do the moved blocks. Except when the state is managed by aztfexport, in which case the moved blocks to the instance addresses are already
+	// generated together with the for_each resources, which are chained with the moved blocks from the previous addresses.
+	if meta.previousMapping != nil && meta.hclOnly {
+		if kl, ok := forEachImportList(l, cfginfos); ok {
+			if err := meta.writeMovedBlocks(kl); err != nil {
+				return err
+			}
+		}
+	}
+
+	if depGraph != nil {
+		if err := meta.writeDependencyGraph(depGraph, cfginfos); err != nil {
+			return err
+		}
+	}
+
+	if len(meta.dependencyResolutions) != 0 {
+		if err := meta.writeDependencyResolutions(); err != nil {
+			return err
+		}
+	}
+
+	if hoister != nil && len(hoister.Variables()) != 0 {
+		b, err := meta.outputFormatter.File(hoister.VariablesFile())
+		if err != nil {
+			return fmt.Errorf("generating the variables: %v", err)
+		}
+		varFile := filepath.Join(meta.moduleDir, meta.outputFileNames.VariableFileName)
+		if err := meta.outputFormatter.AppendToFile(varFile, b); err != nil {
+			return fmt.Errorf("generating variable file: %w", err)
+		}
+	}
+
+	if substituter != nil && len(substituter.DataSources()) != 0 {
+		b, err := meta.outputFormatter.File(substituter.DataSourcesFile())
+		if err != nil {
+			return fmt.Errorf("generating the data sources: %v", err)
+		}
+		dataFile := filepath.Join(meta.moduleDir, meta.outputFileNames.DataSourceFileName)
+		if err := meta.outputFormatter.AppendToFile(dataFile, b); err != nil {
+			return fmt.Errorf("generating data source file: %w", err)
+		}
+	}
+
+	aliasItems := cfginfos.importItems()
+	if substituter != nil {
+		aliasItems = append(aliasItems, substituter.ImportItems()...)
+	}
+	if err := meta.writeProviderAliases(aliasItems); err != nil {
+		return err
+	}
+
+	fileKeys := meta.layoutFileKeys(l)
+	names, partitions := partitionConfigInfosByModule(meta.moduleLayout, cfginfos)
+	for _, name := range names {
+		dir := meta.moduleDir
+		if name != "" {
+			dir = filepath.Join(meta.moduleDir, childModulesDir, name)
+			if err := meta.initChildModule(dir); err != nil {
+				return err
+			}
+		}
+		if err := meta.writeModuleCfg(dir, partitions[name], fileKeys); err != nil {
+			return err
+		}
+		if name != "" && wiring != nil {
+			if err := meta.writeModuleWiring(dir, name, partitions[name], wiring); err != nil {
+				return err
+			}
+		}
+	}
+	return meta.writeModuleCalls(names, wiring)
+}
+
+// writeModuleCfg writes the configs (and the outputs of them) to the module directory, which are split into the files of the file keys.
+func (meta baseMeta) writeModuleCfg(dir string, cfginfos ConfigInfos, fileKeys layoutFileKeys) error {
+	if meta.generateOutputs {
+		f, err := outputsFile(cfginfos, meta.outputAttributes)
+		if err != nil {
+			return fmt.Errorf("generating the outputs: %v", err)
+		}
+		b, err := meta.outputFormatter.File(f)
+		if err != nil {
+			return fmt.Errorf("generating the outputs: %v", err)
+		}
+		outputFile := filepath.Join(dir, meta.outputFileNames.OutputFileName)
+		if err := meta.outputFormatter.AppendToFile(outputFile, b); err != nil {
+			return fmt.Errorf("generating output file: %w", err)
+		}
+	}
+
+	keys, partitions := partitionConfigInfos(fileKeys, cfginfos)
+	if len(keys) == 0 {
+		// Ensure the main configuration file exists even if there is no config generated.
+		keys = []string{""}
+	}
+	for _, key := range keys {
+		b, err := meta.outputFormatter.Config(partitions[key])
+		if err != nil {
+			return fmt.Errorf("genering terraform config: %v", err)
+		}
+		cfgFile := filepath.Join(dir, layoutFileName(key, meta.outputFileNames.MainFileName))
+		if err := meta.outputFormatter.AppendToFile(cfgFile, b); err != nil {
+			return fmt.Errorf("generating main configuration file: %w", err)
+		}
 	}
 	return nil
 }
 
-func (meta baseMeta) GetImportBlocks(_ context.Context, l ImportList) []byte {
-	f := hclwrite.NewFile()
-	body := f.Body()
-	for _, item := range l {
-		if item.Skip() {
-			continue
+// writeModuleWiring writes the variables and outputs of the child module for the references across the modules.
+// The outputs that are already generated for the configs are not duplicated.
+func (meta baseMeta) writeModuleWiring(dir, name string, cfginfos ConfigInfos, wiring *moduleWiring) error {
+	if vf := wiring.variablesFile(name); len(vf.Body().Blocks()) != 0 {
+		if err := meta.appendFile(vf, filepath.Join(dir, meta.outputFileNames.VariableFileName)); err != nil {
+			return fmt.Errorf("generating variable file: %w", err)
+		}
+	}
+
+	generated := map[string]bool{}
+	if meta.generateOutputs {
+		f, err := outputsFile(cfginfos, meta.outputAttributes)
+		if err != nil {
+			return fmt.Errorf("generating the outputs: %v", err)
+		}
+		for _, blk := range f.Body().Blocks() {
+			generated[blk.Labels()[0]] = true
+		}
+	}
+	of, err := wiring.outputsFile(name, generated)
+	if err != nil {
+		return fmt.Errorf("generating the outputs: %v", err)
+	}
+	if len(of.Body().Blocks()) != 0 {
+		if err := meta.appendFile(of, filepath.Join(dir, meta.outputFileNames.OutputFileName)); err != nil {
+			return fmt.Errorf("generating output file: %w", err)
 		}
+	}
+	return nil
+}
 
-		// The import block
-		blk := hclwrite.NewBlock("import", nil)
-		blk.Body().SetAttributeValue("id", cty.StringVal(item.TFResourceId))
-		blk.Body().SetAttributeTraversal("to", hcl.Traversal{hcl.TraverseRoot{Name: item.TFAddr.Type}, hcl.TraverseAttr{Name: item.TFAddr.Name}})
-		body.AppendBlock(blk)
+// appendFile renders the HCL file in the output format, and appends it to the file of the path.
+func (meta baseMeta) appendFile(f *hclwrite.File, path string) error {
+	b, err := meta.outputFormatter.File(f)
+	if err != nil {
+		return err
 	}
-	return f.Bytes()
+	return meta.outputFormatter.AppendToFile(path, b)
 }
 
-func (meta baseMeta) WriteResourceMapping(ctx context.Context, l ImportList) error {
-	m := resmap.ResourceMapping{}
-	for _, item := range l {
-		if item.Skip() {
-			continue
+func (meta baseMeta) writeImportBlocks(ctx context.Context, l ImportList) error {
+	keys, partitions := partitionImportList(meta.layoutFileKeys(l), l)
+	if len(keys) == 0 {
+		keys = []string{""}
+	}
+	for _, key := range keys {
+		b := meta.GetImportBlocks(ctx, partitions[key])
+		oImportFile := filepath.Join(meta.moduleDir, layoutImportFileName(key, meta.outputFileNames.ImportBlockFileName))
+		// #nosec G306
+		if err := os.WriteFile(oImportFile, b, 0644); err != nil {
+			return fmt.Errorf("writing the import block to %s: %v", oImportFile, err)
 		}
+	}
+	return nil
+}
 
-		// The JSON mapping record
-		m[item.AzureResourceID.String()] = resmap.ResourceMapEntity{
-			ResourceId:   item.TFResourceId,
-			ResourceType: item.TFAddr.Type,
-			ResourceName: item.TFAddr.Name,
+func (meta baseMeta) GetImportBlocks(_ context.Context, l ImportList) []byte {
+	return meta.outputFormatter.ImportBlocks(meta.importBlocks(l))
+}
+
+// importBlocks returns the import blocks of the non-skipped and unmanaged import items.
+// The import blocks target the resources in the child modules of the module layout by the module addresses, as they are only allowed in the root module.
+func (meta baseMeta) importBlocks(l ImportList) []importBlock {
+	var out []importBlock
+	for _, item := range l {
+		if item.Skip() || item.Managed {
+			continue
 		}
+		out = append(out, importBlock{
+			ID:     item.TFResourceId,
+			Module: meta.moduleLayout.moduleName(item),
+			TFAddr: item.TFAddr,
+		})
 	}
+	return out
+}
+
+func (meta baseMeta) WriteResourceMapping(ctx context.Context, l ImportList) error {
+	m := l.ResourceMapping()
 	b, err := json.MarshalIndent(m, "", "\t")
 	if err != nil {
 		return fmt.Errorf("JSON marshalling the resource mapping: %v", err)
@@ -558,11 +976,14 @@ func (meta baseMeta) WriteResourceMapping(ctx context.Context, l ImportList) err
 	}
 
 	if meta.generateImportFile {
-		b := meta.GetImportBlocks(ctx, l)
-		oImportFile := filepath.Join(meta.moduleDir, meta.outputFileNames.ImportBlockFileName)
-		// #nosec G306
-		if err := os.WriteFile(oImportFile, b, 0644); err != nil {
-			return fmt.Errorf("writing the import block to %s: %v", oImportFile, err)
+		if err := meta.writeImportBlocks(ctx, l); err != nil {
+			return err
+		}
+	}
+
+	if meta.previousMapping != nil {
+		if err := meta.writeMovedBlocks(l); err != nil {
+			return err
 		}
 	}
 
@@ -593,6 +1014,11 @@ func (meta baseMeta) WriteSkippedResources(ctx context.Context, l ImportList) er
 }
 
 func (meta baseMeta) CleanUpWorkspace(_ context.Context) error {
+	// The checkpoint is no longer needed once the export completes.
+	if err := meta.checkpoint.reset(); err != nil {
+		return fmt.Errorf("removing the checkpoint files: %v", err)
+	}
+
 	// For hcl only mode with using terraform binary, we will have to clean up the state and terraform cli/provider related files the output directory,
 	// except for the TF code, resource mapping file and ignore list file.
 	if meta.hclOnly && meta.tfclient == nil {
@@ -610,6 +1036,33 @@ func (meta baseMeta) CleanUpWorkspace(_ context.Context) error {
 	return nil
 }
 
+func (meta baseMeta) GetAmbiguousDependencies(ctx context.Context, l ImportList) ([]AmbiguousDependency, error) {
+	// The ambiguous dependencies only exist when multiple resources share the same TF resource id.
+	if !l.Imported().hasSharedTFResourceId() {
+		return nil, nil
+	}
+	// Only the reference dependencies are needed, rather than going through all the config transformers.
+	cfgs, err := meta.stateToConfig(ctx, l)
+	if err != nil {
+		return nil, fmt.Errorf("converting from state to configurations: %w", err)
+	}
+	if err := cfgs.PopulateReferenceDeps(); err != nil {
+		return nil, fmt.Errorf("populating reference dependencies: %v", err)
+	}
+	if err := cfgs.ResolveAmbiguousDeps(meta.dependencyResolutions); err != nil {
+		return nil, fmt.Errorf("resolving ambiguous dependencies: %v", err)
+	}
+	return cfgs.ambiguousDependencies(), nil
+}
+
+func (meta *baseMeta) SetDependencyResolutions(r DependencyResolutions) {
+	for id, m := range r {
+		for tfResourceId, chosen := range m {
+			meta.dependencyResolutions.Set(id, tfResourceId, chosen)
+		}
+	}
+}
+
 func (meta *baseMeta) SetPreImportHook(cb config.ImportCallback) {
 	meta.preImportHook = cb
 }
@@ -618,7 +1071,7 @@ func (meta *baseMeta) SetPostImportHook(cb config.ImportCallback) {
 	meta.postImportHook = cb
 }
 
-func (meta baseMeta) generateCfg(ctx context.Context, l ImportList, cfgTrans ...TFConfigTransformer) ([]byte, error) {
+func (meta baseMeta) generateCfg(ctx context.Context, l ImportList, cfgTrans ...TFConfigTransformer) (ConfigInfos, error) {
 	cfginfos, err := meta.stateToConfig(ctx, l)
 	if err != nil {
 		return nil, fmt.Errorf("converting from state to configurations: %w", err)
@@ -628,7 +1081,7 @@ func (meta baseMeta) generateCfg(ctx context.Context, l ImportList, cfgTrans ...
 		return nil, fmt.Errorf("Terraform HCL meta hook: %w", err)
 	}
 
-	return meta.generateConfig(cfginfos)
+	return cfginfos, nil
 }
 
 func (meta *baseMeta) useAzAPI() bool {
@@ -664,6 +1117,12 @@ func (meta *baseMeta) buildTerraformConfig(backendType string) string {
 }
 
 func (meta *baseMeta) buildProviderConfig() string {
+	return meta.buildAliasedProviderConfig("", "")
+}
+
+// buildAliasedProviderConfig builds the provider config with the alias, which targets the specified subscription instead.
+// The default provider config is built if the alias is empty.
+func (meta *baseMeta) buildAliasedProviderConfig(alias, subscriptionId string) string {
 	f := hclwrite.NewEmptyFile()
 
 	var body *hclwrite.Body
@@ -671,11 +1130,22 @@ func (meta *baseMeta) buildProviderConfig() string {
 		body = f.Body().AppendNewBlock("provider", []string{"azapi"}).Body()
 	} else {
 		body = f.Body().AppendNewBlock("provider", []string{"azurerm"}).Body()
+	}
+	if alias != "" {
+		body.SetAttributeValue("alias", cty.StringVal(alias))
+	}
+	if !meta.useAzAPI() {
 		body.AppendNewBlock("features", nil)
 	}
 	for k, v := range meta.providerConfig {
+		if alias != "" && k == "subscription_id" {
+			continue
+		}
 		body.SetAttributeValue(k, v)
 	}
+	if alias != "" {
+		body.SetAttributeValue("subscription_id", cty.StringVal(subscriptionId))
+	}
 	return string(f.Bytes())
 }
 
@@ -746,6 +1216,22 @@ func (meta *baseMeta) init_tf(ctx context.Context) error {
 	meta.baseState = []byte(baseState)
 	meta.originBaseState = []byte(baseState)
 
+	// Continue from the base state snapshot of the previous run if resuming, otherwise start over with a clean checkpoint.
+	if meta.resume {
+		meta.Logger().Info("Resume from the checkpoint")
+		state, err := meta.checkpoint.load()
+		if err != nil {
+			return fmt.Errorf("loading checkpoint: %v", err)
+		}
+		if state != nil {
+			meta.baseState = state
+		}
+	} else {
+		if err := meta.checkpoint.reset(); err != nil {
+			return fmt.Errorf("resetting checkpoint: %v", err)
+		}
+	}
+
 	return nil
 }
 
@@ -789,6 +1275,12 @@ func (meta *baseMeta) initImportDirs() error {
 	}
 	meta.importBaseDirs = importBaseDirs
 	meta.importModuleDirs = importModuleDirs
+	meta.importChildModules = make([]map[string]bool, meta.parallelism)
+	meta.importProviderAliases = make([]map[string]bool, meta.parallelism)
+	for i := 0; i < meta.parallelism; i++ {
+		meta.importChildModules[i] = map[string]bool{}
+		meta.importProviderAliases[i] = map[string]bool{}
+	}
 	return nil
 }
 
@@ -953,9 +1445,29 @@ func (meta *baseMeta) importItem_tf(ctx context.Context, item *ImportItem, impor
 	moduleDir := meta.importModuleDirs[importIdx]
 	tf := meta.importTFs[importIdx]
 
+	if name := meta.moduleLayout.moduleName(*item); name != "" {
+		dir, err := meta.importChildModuleDir(ctx, importIdx, name)
+		if err != nil {
+			err := fmt.Errorf("preparing module %s for %s: %w", name, item.TFAddr, err)
+			meta.Logger().Error("Failed to prepare the module", "error", err, "tf_addr", item.TFAddr)
+			item.ImportError = err
+			return
+		}
+		moduleDir = dir
+	}
+
 	// Construct the empty cfg file for importing
 	cfgFile := filepath.Join(moduleDir, "tmp.aztfexport.tf")
 	tpl := fmt.Sprintf(`resource "%s" "%s" {}`, item.TFAddr.Type, item.TFAddr.Name)
+	if alias, subscriptionId := meta.providerAlias(*item); alias != "" {
+		if err := meta.importProviderAlias(importIdx, alias, subscriptionId); err != nil {
+			err := fmt.Errorf("preparing provider %s for %s: %w", alias, item.TFAddr, err)
+			meta.Logger().Error("Failed to prepare the provider", "error", err, "tf_addr", item.TFAddr)
+			item.ImportError = err
+			return
+		}
+		tpl = fmt.Sprintf("resource %q %q {\n  provider = %s.%s\n}\n", item.TFAddr.Type, item.TFAddr.Name, meta.providerName, alias)
+	}
 	// #nosec G306
 	if err := os.WriteFile(cfgFile, []byte(tpl), 0644); err != nil {
 		err := fmt.Errorf("generating resource template file for %s: %w", item.TFAddr, err)
@@ -967,8 +1479,8 @@ func (meta *baseMeta) importItem_tf(ctx context.Context, item *ImportItem, impor
 
 	// Import resources
 	addr := item.TFAddr.String()
-	if meta.moduleAddr != "" {
-		addr = meta.moduleAddr + "." + addr
+	if moduleAddr := meta.resourceModuleAddr(*item); moduleAddr != "" {
+		addr = moduleAddr + "." + addr
 	}
 
 	meta.Logger().Info("Importing a resource", "tf_id", item.TFResourceId, "tf_addr", addr)
@@ -1118,8 +1630,8 @@ func (meta baseMeta) stateToConfig(ctx context.Context, list ImportList) (Config
 		var addrs []string
 		for _, item := range importedList {
 			addr := item.TFAddr.String()
-			if meta.moduleAddr != "" {
-				addr = meta.moduleAddr + "." + addr
+			if moduleAddr := meta.resourceModuleAddr(item); moduleAddr != "" {
+				addr = moduleAddr + "." + addr
 			}
 			addrs = append(addrs, addr)
 		}
@@ -1143,6 +1655,7 @@ func (meta baseMeta) stateToConfig(ctx context.Context, list ImportList) (Config
 			Dependencies: Dependencies{
 				ByIdRef:          make(map[string]Dependency),
 				ByIdRefAmbiguous: make(map[string][]Dependency),
+				ByNameRef:        make(map[string]Dependency),
 			},
 		})
 	}
@@ -1161,17 +1674,6 @@ func (meta baseMeta) terraformMetaHook(configs ConfigInfos, cfgTrans ...TFConfig
 	return configs, nil
 }
 
-func (meta baseMeta) generateConfig(cfgs ConfigInfos) ([]byte, error) {
-	buf := bytes.NewBuffer([]byte{})
-	for _, cfg := range cfgs {
-		if _, err := cfg.DumpHCL(buf); err != nil {
-			return nil, err
-		}
-		buf.Write([]byte("\n"))
-	}
-	return buf.Bytes(), nil
-}
-
 func (meta baseMeta) cleanupTerraformAdd(tpl string) string {
 	segs := strings.Split(tpl, "\n")
 	// Removing:
@@ -1208,6 +1710,9 @@ func (meta baseMeta) addDependency(configs ConfigInfos) (ConfigInfos, error) {
 		return nil, fmt.Errorf("populating reference dependencies: %v", err)
 	}
 	configs.PopulateRelationDeps()
+	if err := configs.ResolveAmbiguousDeps(meta.dependencyResolutions); err != nil {
+		return nil, fmt.Errorf("resolving ambiguous dependencies: %v", err)
+	}
 
 	if err := configs.ApplyDepsToHCL(); err != nil {
 		return nil, fmt.Errorf("applying dependencies to HCL blocks: %v", err)
@@ -1,10 +1,12 @@
 package meta
 
 import (
+	"context"
 	"regexp"
 	"testing"
 
 	"github.com/Azure/aztfexport/internal/tfaddr"
+	"github.com/Azure/aztfexport/pkg/config"
 	"github.com/magodo/armid"
 	"github.com/stretchr/testify/require"
 )
@@ -80,3 +82,14 @@ func TestExcludeImportList(t *testing.T) {
 		})
 	}
 }
+
+func TestGetTerraformCfg_Unsupported(t *testing.T) {
+	_, err := baseMeta{moduleLayout: moduleLayout{layout: config.ModuleLayoutResourceGroup}}.GetTerraformCfg(context.Background(), nil)
+	require.ErrorContains(t, err, "not supported with the module layout")
+
+	_, err = baseMeta{dataSourceForExternalRefs: true}.GetTerraformCfg(context.Background(), nil)
+	require.ErrorContains(t, err, "not supported with the data sources")
+
+	_, err = baseMeta{hoistVariables: true}.GetTerraformCfg(context.Background(), nil)
+	require.ErrorContains(t, err, "not supported with the variable hoisting")
+}
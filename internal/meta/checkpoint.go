@@ -0,0 +1,149 @@
+package meta
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const CheckpointFileName = "aztfexportCheckpoint.jsonl"
+const CheckpointStateFileName = "aztfexportCheckpoint.tfstate"
+
+// checkpointRecord is one line of the checkpoint journal, which records the import outcome of an import item.
+type checkpointRecord struct {
+	AzureResourceId string `json:"azure_resource_id"`
+	TFResourceId    string `json:"tf_resource_id"`
+	TFAddr          string `json:"tf_addr"`
+	Imported        bool   `json:"imported"`
+	Error           string `json:"error,omitempty"`
+}
+
+// checkpoint maintains the checkpoint journal and the state snapshot in the output directory, so that an interrupted
+// export can be resumed without re-importing the resources that have already been merged into the base state.
+type checkpoint struct {
+	journalFile string
+	stateFile   string
+
+	// imported is the set of import items that have been imported and merged into the state snapshot by a previous run.
+	// The key is the Azure resource id in uppercase.
+	imported map[string]checkpointRecord
+}
+
+func newCheckpoint(dir string) *checkpoint {
+	return &checkpoint{
+		journalFile: filepath.Join(dir, CheckpointFileName),
+		stateFile:   filepath.Join(dir, CheckpointStateFileName),
+		imported:    map[string]checkpointRecord{},
+	}
+}
+
+// load reloads the journal from a previous run, and returns the state snapshot that the journaled imported items are merged into.
+func (c *checkpoint) load() ([]byte, error) {
+	// #nosec G304
+	f, err := os.Open(c.journalFile)
+	if err != nil {
+		return nil, fmt.Errorf("opening the checkpoint journal: %v", err)
+	}
+	// #nosec G307
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(nil, 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		var record checkpointRecord
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			// The last line might be partially written in case the previous run is interrupted.
+			break
+		}
+		key := strings.ToUpper(record.AzureResourceId)
+		if record.Imported {
+			c.imported[key] = record
+		} else {
+			delete(c.imported, key)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("reading the checkpoint journal: %v", err)
+	}
+
+	// #nosec G304
+	state, err := os.ReadFile(c.stateFile)
+	if err != nil {
+		if os.IsNotExist(err) {
+			if len(c.imported) != 0 {
+				return nil, fmt.Errorf("the checkpoint state file %s doesn't exist", c.stateFile)
+			}
+			return nil, nil
+		}
+		return nil, fmt.Errorf("reading the checkpoint state file: %v", err)
+	}
+	return state, nil
+}
+
+// reset removes the checkpoint journal and the state snapshot, if any.
+func (c *checkpoint) reset() error {
+	for _, f := range []string{c.journalFile, c.stateFile} {
+		if err := os.Remove(f); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// isImported tells whether the import item has been imported by a previous run, as is recorded in the journal.
+func (c *checkpoint) isImported(item *ImportItem) bool {
+	record, ok := c.imported[strings.ToUpper(item.AzureResourceID.String())]
+	if !ok {
+		return false
+	}
+	return record.TFResourceId == item.TFResourceId && record.TFAddr == item.TFAddr.String()
+}
+
+// record persists the state snapshot (if not nil), then appends the import outcome of the items to the journal.
+// The snapshot is written ahead of the journal, so that the journal never claims an item imported that is not in the snapshot.
+func (c *checkpoint) record(state []byte, items []*ImportItem) error {
+	if state != nil {
+		tmpFile := c.stateFile + ".tmp"
+		if err := os.WriteFile(tmpFile, state, 0600); err != nil {
+			return fmt.Errorf("writing the checkpoint state file: %v", err)
+		}
+		if err := os.Rename(tmpFile, c.stateFile); err != nil {
+			return fmt.Errorf("renaming the checkpoint state file: %v", err)
+		}
+	}
+
+	var lines []string
+	for _, item := range items {
+		if item.Skip() {
+			continue
+		}
+		record := checkpointRecord{
+			AzureResourceId: item.AzureResourceID.String(),
+			TFResourceId:    item.TFResourceId,
+			TFAddr:          item.TFAddr.String(),
+			Imported:        item.Imported,
+		}
+		if item.ImportError != nil {
+			record.Error = item.ImportError.Error()
+		}
+		b, err := json.Marshal(record)
+		if err != nil {
+			return fmt.Errorf("marshalling the checkpoint record of %s: %v", item.AzureResourceID, err)
+		}
+		lines = append(lines, string(b))
+	}
+	if len(lines) == 0 {
+		return nil
+	}
+	if err := appendToFile(c.journalFile, strings.Join(lines, "\n")+"\n"); err != nil {
+		return fmt.Errorf("writing the checkpoint journal: %v", err)
+	}
+	return nil
+}
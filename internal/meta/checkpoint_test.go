@@ -0,0 +1,58 @@
+package meta
+
+import (
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/Azure/aztfexport/internal/tfaddr"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckpoint(t *testing.T) {
+	dir := t.TempDir()
+
+	items := []*ImportItem{
+		{
+			AzureResourceID: mustParseResourceId("/subscriptions/123/resourceGroups/rg1"),
+			TFResourceId:    "/subscriptions/123/resourceGroups/rg1",
+			TFAddr:          tfaddr.TFAddr{Type: "azurerm_resource_group", Name: "res-0"},
+			Imported:        true,
+		},
+		{
+			AzureResourceID: mustParseResourceId("/subscriptions/123/resourceGroups/rg1/providers/Microsoft.Network/virtualNetworks/vnet1"),
+			TFResourceId:    "/subscriptions/123/resourceGroups/rg1/providers/Microsoft.Network/virtualNetworks/vnet1",
+			TFAddr:          tfaddr.TFAddr{Type: "azurerm_virtual_network", Name: "res-1"},
+			ImportError:     fmt.Errorf("boom"),
+		},
+		{
+			AzureResourceID: mustParseResourceId("/subscriptions/123/resourceGroups/rg1/providers/Microsoft.Foo/foos/foo1"),
+			TFResourceId:    "/subscriptions/123/resourceGroups/rg1/providers/Microsoft.Foo/foos/foo1",
+		},
+	}
+
+	c := newCheckpoint(dir)
+	require.NoError(t, c.record([]byte("state"), items))
+
+	// Simulate a partially written line by an interrupted run
+	require.NoError(t, appendToFile(c.journalFile, `{"azure_resource_id":`))
+
+	c = newCheckpoint(dir)
+	state, err := c.load()
+	require.NoError(t, err)
+	require.Equal(t, "state", string(state))
+	require.True(t, c.isImported(items[0]))
+	require.False(t, c.isImported(items[1]))
+	require.False(t, c.isImported(items[2]))
+
+	// The item is renamed since the previous run
+	renamed := *items[0]
+	renamed.TFAddr.Name = "res-2"
+	require.False(t, c.isImported(&renamed))
+
+	require.NoError(t, c.reset())
+	_, err = os.Stat(c.journalFile)
+	require.True(t, os.IsNotExist(err))
+	_, err = os.Stat(c.stateFile)
+	require.True(t, os.IsNotExist(err))
+}
@@ -22,10 +22,14 @@ type ConfigInfo struct {
 	Dependencies Dependencies
 
 	HCL *hclwrite.File
+
+	// ForEach is non-empty when the config is a "for_each" resource consolidated from multiple homogeneous resources.
+	// It holds the import items of these resources, whose TFAddr are the instance addresses.
+	ForEach []ImportItem
 }
 
 type Dependencies struct {
-	// Dependencies inferred by scanning for resource id values
+	// Dependencies inferred by scanning for resource id values, which are matched case insensitively, either as the whole string or embedded in a longer string.
 	// The key is TFResourceId.
 	ByIdRef map[string]Dependency
 
@@ -35,9 +39,14 @@ type Dependencies struct {
 	ByIdRefAmbiguous map[string][]Dependency
 
 	// Dependencies inferred by resource group name reference.
-	// NOTE: This holds since the azurerm/azapi provider is guaranteed to work for a single subscription.
+	// NOTE: The resource group names are only unique within a subscription, the referenced resource group is ensured to be the parent of the resource.
 	ByRgNameRef *Dependency
 
+	// Dependencies inferred by resource name reference, i.e. the top level attributes named as "<type>_name" (e.g. `storage_account_name`), other than `resource_group_name`.
+	// NOTE: The referenced resource is ensured to be of a TF resource type ending with "_<type>", and to be either an ancestor of the resource, or within the same resource group.
+	// The key is the attribute name.
+	ByNameRef map[string]Dependency
+
 	// Dependencies inferred via Azure resource id parent lookup.
 	// At most one such dependency can exist.
 	ByRelation *Dependency
@@ -49,56 +58,82 @@ type Dependency struct {
 	TFAddr          tfaddr.TFAddr
 }
 
+// importItems returns the import items of the configs.
+func (cfgs ConfigInfos) importItems() []ImportItem {
+	out := make([]ImportItem, 0, len(cfgs))
+	for _, cfg := range cfgs {
+		out = append(out, cfg.ImportItem)
+	}
+	return out
+}
+
 func (cfg ConfigInfo) DumpHCL(w io.Writer) (int, error) {
 	out := hclwrite.Format(cfg.HCL.Bytes())
 	return w.Write(out)
 }
 
 func (cfg *ConfigInfo) applyRefDepsToHCL() {
-	var applyF func(*hclwrite.Body, map[string]Dependency, *Dependency)
-	applyF = func(body *hclwrite.Body, idDeps map[string]Dependency, rgDep *Dependency) {
-		// Apply the rg name reference
-		if rgDep != nil {
-			if _, ok := body.Attributes()["resource_group_name"]; ok {
-				body.SetAttributeTraversal("resource_group_name", hcl.Traversal{
-					hcl.TraverseRoot{Name: rgDep.TFAddr.Type},
-					hcl.TraverseAttr{Name: rgDep.TFAddr.Name},
-					hcl.TraverseAttr{Name: "name"},
-				})
-			}
+	body := cfg.HCL.Body().Blocks()[0].Body()
+
+	// Apply the rg name reference
+	if rgDep := cfg.Dependencies.ByRgNameRef; rgDep != nil {
+		if _, ok := body.Attributes()["resource_group_name"]; ok {
+			body.SetAttributeTraversal("resource_group_name", addrAttrTraversal(rgDep.TFAddr, "name"))
 		}
+	}
 
-		// Apply the id reference
-		if len(idDeps) != 0 {
-			for name, attr := range body.Attributes() {
-				tokens := attr.Expr().BuildTokens(nil)
-				newTokens := hclwrite.Tokens{}
-				toApply := false
-				for i := 0; i < len(tokens); i++ {
-					refDep, refDepExists := idDeps[string(tokens[i].Bytes)]
-					// Parsing process guaranteed QuotedLit is surrounded by Opening and Closing quote
-					if tokens[i].Type == hclsyntax.TokenQuotedLit && refDepExists {
-						newTokens[len(newTokens)-1] = &hclwrite.Token{
-							Type:         hclsyntax.TokenIdent,
-							Bytes:        fmt.Appendf(nil, "%s.id", refDep.TFAddr),
-							SpacesBefore: tokens[i-1].SpacesBefore,
-						}
-						toApply = true
-						i += 1 // Skip the next token as it was already processed
-					} else {
-						newTokens = append(newTokens, tokens[i])
-					}
-				}
-				if toApply {
-					body.SetAttributeRaw(name, newTokens)
-				}
-				for _, nestedBlock := range body.Blocks() {
-					applyF(nestedBlock.Body(), idDeps, nil)
-				}
-			}
+	// Apply the resource name references
+	for name, dep := range cfg.Dependencies.ByNameRef {
+		if _, ok := body.Attributes()[name]; ok {
+			body.SetAttributeTraversal(name, addrAttrTraversal(dep.TFAddr, "name"))
 		}
 	}
-	applyF(cfg.HCL.Body().Blocks()[0].Body(), cfg.Dependencies.ByIdRef, cfg.Dependencies.ByRgNameRef)
+
+	// Apply the id references
+	idDeps := cfg.Dependencies.ByIdRef
+	if len(idDeps) == 0 {
+		return
+	}
+	// key: upper cased TFResourceId
+	upperIdDeps := map[string]Dependency{}
+	var embeddableIds []string
+	for id, dep := range idDeps {
+		upperIdDeps[strings.ToUpper(id)] = dep
+		if strings.HasPrefix(id, "/") {
+			embeddableIds = append(embeddableIds, id)
+		}
+	}
+	replaceQuotedLits(body, func(lit string) hclwrite.Tokens {
+		if dep, ok := upperIdDeps[strings.ToUpper(lit)]; ok {
+			return hclwrite.TokensForTraversal(addrAttrTraversal(dep.TFAddr, "id"))
+		}
+		matches := embeddedIds(lit, embeddableIds)
+		if len(matches) == 0 {
+			return nil
+		}
+		// Build the template, with the ids interpolated by the references.
+		tokens := hclwrite.Tokens{{Type: hclsyntax.TokenOQuote, Bytes: []byte(`"`)}}
+		appendLit := func(s string) {
+			if s != "" {
+				tokens = append(tokens, &hclwrite.Token{Type: hclsyntax.TokenQuotedLit, Bytes: []byte(s)})
+			}
+		}
+		var pos int
+		for _, m := range matches {
+			appendLit(lit[pos:m.start])
+			tokens = append(tokens, &hclwrite.Token{Type: hclsyntax.TokenTemplateInterp, Bytes: []byte("${")})
+			tokens = append(tokens, hclwrite.TokensForTraversal(addrAttrTraversal(upperIdDeps[strings.ToUpper(m.id)].TFAddr, "id"))...)
+			tokens = append(tokens, &hclwrite.Token{Type: hclsyntax.TokenTemplateSeqEnd, Bytes: []byte("}")})
+			pos = m.end
+		}
+		appendLit(lit[pos:])
+		return append(tokens, &hclwrite.Token{Type: hclsyntax.TokenCQuote, Bytes: []byte(`"`)})
+	})
+}
+
+// addrAttrTraversal returns the traversal of the attribute of the resource address, e.g. "azurerm_resource_group.res-0.name".
+func addrAttrTraversal(addr tfaddr.TFAddr, attr string) hcl.Traversal {
+	return append(addrTraversal(addr), hcl.TraverseAttr{Name: attr})
 }
 
 func (cfg *ConfigInfo) applyExplicitDepsToHCL() error {
@@ -114,6 +149,9 @@ func (cfg *ConfigInfo) applyExplicitDepsToHCL() error {
 		if dep := cfg.Dependencies.ByRgNameRef; dep != nil {
 			appliedDepIds = append(appliedDepIds, dep.AzureResourceId)
 		}
+		for _, dep := range cfg.Dependencies.ByNameRef {
+			appliedDepIds = append(appliedDepIds, dep.AzureResourceId)
+		}
 		var covered bool
 		for _, id := range appliedDepIds {
 			if isParentOf(relationDep.AzureResourceId, id) {
@@ -140,16 +178,10 @@ func (cfg *ConfigInfo) applyExplicitDepsToHCL() error {
 		src += relationDep.TFAddr.String() + ",\n"
 	}
 	if len(ambiguousDeps) > 0 {
-		ambiguousDepsComments := make([]string, 0, len(ambiguousDeps))
-		for _, deps := range ambiguousDeps {
-			tfAddrs := make([]string, 0, len(deps))
-			for _, dep := range deps {
-				tfAddrs = append(tfAddrs, dep.TFAddr.String())
-			}
-			sort.Strings(tfAddrs)
-			ambiguousDepsComments = append(ambiguousDepsComments, fmt.Sprintf("# One of %s (can't auto-resolve as their ids are identical)", strings.Join(tfAddrs, ",")))
+		var ambiguousDepsComments []string
+		for _, comment := range cfg.Dependencies.ambiguousDepsComments() {
+			ambiguousDepsComments = append(ambiguousDepsComments, "# "+comment)
 		}
-		sort.Strings(ambiguousDepsComments)
 		src += strings.Join(ambiguousDepsComments, "\n") + "\n"
 	}
 	src += "]\n"
@@ -162,6 +194,21 @@ func (cfg *ConfigInfo) applyExplicitDepsToHCL() error {
 	return nil
 }
 
+// ambiguousDepsComments returns the sorted comments that describe the ambiguous dependencies.
+func (deps Dependencies) ambiguousDepsComments() []string {
+	comments := make([]string, 0, len(deps.ByIdRefAmbiguous))
+	for _, deps := range deps.ByIdRefAmbiguous {
+		tfAddrs := make([]string, 0, len(deps))
+		for _, dep := range deps {
+			tfAddrs = append(tfAddrs, dep.TFAddr.String())
+		}
+		sort.Strings(tfAddrs)
+		comments = append(comments, fmt.Sprintf("One of %s (can't auto-resolve as their ids are identical)", strings.Join(tfAddrs, ",")))
+	}
+	sort.Strings(comments)
+	return comments
+}
+
 // Look at the Azure resource id and determine if parent dependency exist.
 // For example, /subscriptions/123/resourceGroups/rg1/providers/Microsoft.Foo/foos/foo1
 // has a parent /subscriptions/123/resourceGroups/rg1, which is the resource group.
@@ -198,32 +245,63 @@ func (cfgs ConfigInfos) PopulateRelationDeps() {
 	}
 }
 
-// Scan the HCL files for references to other resources. There are two references will be detected:
-//  1. Reference by (TF) resource id. This can be detected any where in the expression.
+// Scan the HCL files for references to other resources. There are three references will be detected:
+//  1. Reference by (TF) resource id. This can be detected any where in the expression, e.g. inside lists and maps. The id is matched case insensitively,
+//     either as the whole string, or embedded in a longer string (e.g. "<vnet id>/subnets/subnet1"), in which case the longest id wins.
 //     Especially, a single TF resource id can map to multiple resources, in which case the dependencies is regarded as ambiguous.
 //  2. Reference by resoruce group name. This only applies to the top level attribute named `resource_group_name`.
+//  3. Reference by resource name. This only applies to the other top level attributes named as "<type>_name" (e.g. `storage_account_name`).
 func (cfgs ConfigInfos) PopulateReferenceDeps() error {
-	// key: TFResourceId
+	// key: upper cased TFResourceId
 	allResMap := map[string][]*ConfigInfo{}
-	// key: resource group name
-	allRgMap := map[string]*ConfigInfo{}
+	// The TF resource ids that are Azure resource ids, which can be embedded in the longer strings.
+	var embeddableIds []string
+	// key: upper cased resource group name
+	// A resource group name can map to multiple resource groups in different subscriptions.
+	allRgMap := map[string][]*ConfigInfo{}
+	// key: upper cased resource name
+	allNameMap := map[string][]*ConfigInfo{}
 	for _, cfg := range cfgs {
-		allResMap[cfg.TFResourceId] = append(allResMap[cfg.TFResourceId], &cfg)
+		key := strings.ToUpper(cfg.TFResourceId)
+		if _, ok := allResMap[key]; !ok && strings.HasPrefix(cfg.TFResourceId, "/") {
+			embeddableIds = append(embeddableIds, cfg.TFResourceId)
+		}
+		allResMap[key] = append(allResMap[key], &cfg)
 		if id, ok := cfg.AzureResourceID.(*armid.ResourceGroup); ok && len(id.AttrTypes) == 0 {
-			allRgMap[id.Name] = &cfg
+			key := strings.ToUpper(id.Name)
+			allRgMap[key] = append(allRgMap[key], &cfg)
+		}
+		if names := cfg.AzureResourceID.Names(); len(names) != 0 {
+			key := strings.ToUpper(names[len(names)-1])
+			allNameMap[key] = append(allNameMap[key], &cfg)
 		}
 	}
 	for i, cfg := range cfgs {
+		if cfg.Dependencies.ByIdRef == nil {
+			cfg.Dependencies.ByIdRef = map[string]Dependency{}
+		}
+		if cfg.Dependencies.ByIdRefAmbiguous == nil {
+			cfg.Dependencies.ByIdRefAmbiguous = map[string][]Dependency{}
+		}
+		if cfg.Dependencies.ByNameRef == nil {
+			cfg.Dependencies.ByNameRef = map[string]Dependency{}
+		}
+
 		file, err := hclsyntax.ParseConfig(cfg.HCL.Bytes(), "main.tf", hcl.InitialPos)
 		if err != nil {
 			return fmt.Errorf("parsing hcl for %s: %v", cfg.AzureResourceID, err)
 		}
-		// Scan for the top level resource group name reference
-		if attr, ok := file.Body.(*hclsyntax.Body).Blocks[0].Body.Attributes["resource_group_name"]; ok {
-			if tplExpr, ok := attr.Expr.(*hclsyntax.TemplateExpr); ok && tplExpr.IsStringLiteral() {
-				val, _ := tplExpr.Value(nil)
-				rgName := val.AsString()
-				if rgCfg, ok := allRgMap[rgName]; ok {
+		body := file.Body.(*hclsyntax.Body).Blocks[0].Body
+		for name, attr := range body.Attributes {
+			tplExpr, ok := attr.Expr.(*hclsyntax.TemplateExpr)
+			if !ok || !tplExpr.IsStringLiteral() {
+				continue
+			}
+			val, _ := tplExpr.Value(nil)
+
+			// Scan for the top level resource group name reference
+			if name == "resource_group_name" {
+				for _, rgCfg := range allRgMap[strings.ToUpper(val.AsString())] {
 					// Ensure the referenced resource group is really the parent resource group of the current resource.
 					// This is to avoid the case that the referenced resource group is from another subscription.
 					// Since the resource group name is equal, we only need to further check its subscription id.
@@ -233,35 +311,46 @@ func (cfgs ConfigInfos) PopulateReferenceDeps() error {
 							TFResourceId:    rgCfg.ImportItem.TFResourceId,
 							TFAddr:          rgCfg.ImportItem.TFAddr,
 						}
+						break
 					}
 				}
+				continue
 			}
-		}
 
-		// Scan for resource id reference
-		hclsyntax.VisitAll(file.Body.(*hclsyntax.Body), func(node hclsyntax.Node) hcl.Diagnostics {
-			expr, ok := node.(*hclsyntax.LiteralValueExpr)
-			if !ok {
-				return nil
-			}
-			val := expr.Val
-			if !expr.Val.IsKnown() || !val.Type().Equals(cty.String) {
-				return nil
+			// Scan for the top level resource name reference
+			if strings.HasSuffix(name, "_name") {
+				typeSuffix := "_" + strings.TrimSuffix(name, "_name")
+				var depCfgs []*ConfigInfo
+				for _, depCfg := range allNameMap[strings.ToUpper(val.AsString())] {
+					if !strings.HasSuffix(depCfg.TFAddr.Type, typeSuffix) {
+						continue
+					}
+					// Ignore the self dependency and the dependency on the descendant resources (which will cause circular dependency)
+					if isParentOf(cfg.AzureResourceID.String(), depCfg.AzureResourceID.String()) {
+						continue
+					}
+					if !isParentOf(depCfg.AzureResourceID.String(), cfg.AzureResourceID.String()) && !sameResourceGroup(depCfg.AzureResourceID, cfg.AzureResourceID) {
+						continue
+					}
+					depCfgs = append(depCfgs, depCfg)
+				}
+				if len(depCfgs) == 1 {
+					cfg.Dependencies.ByNameRef[name] = Dependency{
+						TFResourceId:    depCfgs[0].TFResourceId,
+						AzureResourceId: depCfgs[0].AzureResourceID.String(),
+						TFAddr:          depCfgs[0].TFAddr,
+					}
+				}
 			}
-			maybeTFId := val.AsString()
+		}
 
-			// Try to look up this string attribute from the TF id map. If there is a match, we regard it as a valid TF resource id.
-			// This is safe to match case sensitively given the TF id are consistent across the provider. Otherwise, it is a provider bug.
-			dependingConfigsRaw, ok := allResMap[maybeTFId]
-			if !ok {
-				return nil
-			}
-			depTFResId := maybeTFId
+		addIdRef := func(dependingConfigsRaw []*ConfigInfo) {
+			depTFResId := dependingConfigsRaw[0].TFResourceId
 
 			var dependingConfigs []*ConfigInfo
 			for _, depCfg := range dependingConfigsRaw[:] {
 				// Ignore the self dependency
-				if cfg.AzureResourceID.String() == depCfg.AzureResourceID.String() {
+				if strings.EqualFold(cfg.AzureResourceID.String(), depCfg.AzureResourceID.String()) {
 					continue
 				}
 				// Ignore the dependency on the child resource (which will cause circular dependency)
@@ -288,7 +377,29 @@ func (cfgs ConfigInfos) PopulateReferenceDeps() error {
 				}
 				cfg.Dependencies.ByIdRefAmbiguous[depTFResId] = deps
 			}
+		}
+
+		// Scan for resource id reference
+		hclsyntax.VisitAll(file.Body.(*hclsyntax.Body), func(node hclsyntax.Node) hcl.Diagnostics {
+			expr, ok := node.(*hclsyntax.LiteralValueExpr)
+			if !ok {
+				return nil
+			}
+			val := expr.Val
+			if !expr.Val.IsKnown() || !val.Type().Equals(cty.String) {
+				return nil
+			}
+			maybeTFId := val.AsString()
 
+			// Try to look up this string attribute from the TF id map. If there is a match, we regard it as a valid TF resource id.
+			// The TF ids are matched case insensitively, as the ids referenced by the other resources (e.g. returned by the API) might be in a different casing.
+			if dependingConfigsRaw, ok := allResMap[strings.ToUpper(maybeTFId)]; ok {
+				addIdRef(dependingConfigsRaw)
+				return nil
+			}
+			for _, m := range embeddedIds(maybeTFId, embeddableIds) {
+				addIdRef(allResMap[strings.ToUpper(m.id)])
+			}
 			return nil
 		})
 		cfgs[i] = cfg
@@ -307,6 +418,94 @@ func (configs ConfigInfos) ApplyDepsToHCL() error {
 	return nil
 }
 
+// sameResourceGroup tells whether both Azure resource ids are within the same resource group.
+func sameResourceGroup(id1, id2 armid.ResourceId) bool {
+	rg1, ok := id1.RootScope().(*armid.ResourceGroup)
+	if !ok {
+		return false
+	}
+	rg2, ok := id2.RootScope().(*armid.ResourceGroup)
+	if !ok {
+		return false
+	}
+	return strings.EqualFold(rg1.String(), rg2.String())
+}
+
+// idMatch is an id embedded in a string, at s[start:end].
+type idMatch struct {
+	start, end int
+	id         string
+}
+
+// embeddedIds returns the non-overlapping ids (starting with "/") that are embedded in the string, which are matched case insensitively, where the longest id wins at each position.
+// The id must be followed by either the end of the string, or a character that can't be part of a name (e.g. "/"), to avoid matching "rg1" in "rg10".
+func embeddedIds(s string, ids []string) []idMatch {
+	if len(ids) == 0 {
+		return nil
+	}
+	isNameChar := func(c byte) bool {
+		return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '-' || c == '_' || c == '.'
+	}
+	us := strings.ToUpper(s)
+	var out []idMatch
+	for i := 0; i < len(us); {
+		if us[i] != '/' {
+			i++
+			continue
+		}
+		var best string
+		for _, id := range ids {
+			end := i + len(id)
+			if len(id) <= len(best) || end > len(us) || us[i:end] != strings.ToUpper(id) {
+				continue
+			}
+			if end < len(us) && isNameChar(us[end]) {
+				continue
+			}
+			best = id
+		}
+		if best == "" {
+			i++
+			continue
+		}
+		out = append(out, idMatch{start: i, end: i + len(best), id: best})
+		i += len(best)
+	}
+	return out
+}
+
+// replaceQuotedLits walks through the attributes in the body and its nested blocks, and replaces the quoted string literals (other than the object keys)
+// by the tokens, in case the function returns non-nil tokens for the literal.
+func replaceQuotedLits(body *hclwrite.Body, f func(lit string) hclwrite.Tokens) {
+	for name, attr := range body.Attributes() {
+		tokens := attr.Expr().BuildTokens(nil)
+		newTokens := make(hclwrite.Tokens, 0, len(tokens))
+		toApply := false
+		for i := 0; i < len(tokens); i++ {
+			if i+2 < len(tokens) &&
+				tokens[i].Type == hclsyntax.TokenOQuote &&
+				tokens[i+1].Type == hclsyntax.TokenQuotedLit &&
+				tokens[i+2].Type == hclsyntax.TokenCQuote &&
+				!(i+3 < len(tokens) && (tokens[i+3].Type == hclsyntax.TokenEqual || tokens[i+3].Type == hclsyntax.TokenColon)) {
+				if replacement := f(string(tokens[i+1].Bytes)); replacement != nil {
+					replacement[0].SpacesBefore = tokens[i].SpacesBefore
+					newTokens = append(newTokens, replacement...)
+					toApply = true
+					i += 2
+					continue
+				}
+			}
+			newTokens = append(newTokens, tokens[i])
+		}
+		if toApply {
+			body.SetAttributeRaw(name, newTokens)
+		}
+	}
+	for _, blk := range body.Blocks() {
+		replaceQuotedLits(blk.Body(), f)
+	}
+}
+
 // isParentOf is a utility to tell whether the "pid" is a top level of the "id".
 // Given both "pid" and "id" are Azure resource ids.
 func isParentOf(pid, id string) bool {
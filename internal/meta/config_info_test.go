@@ -270,6 +270,64 @@ resource "azurerm_foo_resource" "res-1" {
 				"/subscriptions/123/resourceGroups/rg1":                                  {},
 			},
 		},
+		{
+			name: "res-2 reference res-0 by resource group name, with the same named resource group in another subscription",
+			inputConfigs: []ConfigInfo{
+				newConfigInfo(
+					"/subscriptions/456/resourceGroups/rg1",
+					"/subscriptions/456/resourceGroups/rg1",
+					"azurerm_resource_group.res-0",
+					`
+resource "azurerm_resource_group" "res-0" {
+  name     = "rg1"
+  location = "West Europe"
+}
+`,
+					nil,
+				),
+				newConfigInfo(
+					"/subscriptions/123/resourceGroups/rg1",
+					"/subscriptions/123/resourceGroups/rg1",
+					"azurerm_resource_group.res-1",
+					`
+resource "azurerm_resource_group" "res-1" {
+  name     = "rg1"
+  location = "West Europe"
+}
+`,
+					nil,
+				),
+				newConfigInfo(
+					"/subscriptions/456/resourceGroups/rg1/providers/Microsoft.Foo/foo/foo1",
+					"/subscriptions/456/resourceGroups/rg1/providers/Microsoft.Foo/foo/foo1",
+					"azurerm_foo_resource.res-2",
+					`
+resource "azurerm_foo_resource" "res-2" {
+  name                = "foo1"
+  resource_group_name = "rg1"
+}
+`,
+					nil,
+				),
+			},
+			expectedRgNameReferenceDeps: map[string]*Dependency{
+				"/subscriptions/456/resourceGroups/rg1/providers/Microsoft.Foo/foo/foo1": {
+					TFAddr:          mustParseTFAddr("azurerm_resource_group.res-0"),
+					AzureResourceId: "/subscriptions/456/resourceGroups/rg1",
+					TFResourceId:    "/subscriptions/456/resourceGroups/rg1",
+				},
+			},
+			expectedIdReferenceDeps: map[string]map[string]Dependency{
+				"/subscriptions/456/resourceGroups/rg1/providers/Microsoft.Foo/foo/foo1": {},
+				"/subscriptions/456/resourceGroups/rg1":                                  {},
+				"/subscriptions/123/resourceGroups/rg1":                                  {},
+			},
+			expectedIdReferenceAmbiguousDeps: map[string]map[string][]Dependency{
+				"/subscriptions/456/resourceGroups/rg1/providers/Microsoft.Foo/foo/foo1": {},
+				"/subscriptions/456/resourceGroups/rg1":                                  {},
+				"/subscriptions/123/resourceGroups/rg1":                                  {},
+			},
+		},
 	}
 
 	for _, testCase := range testCases {
@@ -744,3 +802,112 @@ func mustParseTFAddr(s string) tfaddr.TFAddr {
 	}
 	return *tfAddr
 }
+
+func TestConfigInfos_PopulateAndApplyLooseReferenceDeps(t *testing.T) {
+	rgId := "/subscriptions/123/resourceGroups/rg1"
+	saId := rgId + "/providers/Microsoft.Storage/storageAccounts/sa1"
+	vnetId := rgId + "/providers/Microsoft.Network/virtualNetworks/vnet1"
+	subnetId := vnetId + "/subnets/subnet1"
+	cfgs := ConfigInfos{
+		newConfigInfo(rgId, rgId, "azurerm_resource_group.res-0", `resource "azurerm_resource_group" "res-0" {
+  name = "rg1"
+}
+`, nil),
+		newConfigInfo(saId, saId, "azurerm_storage_account.res-1", `resource "azurerm_storage_account" "res-1" {
+  name                = "sa1"
+  resource_group_name = "rg1"
+}
+`, nil),
+		newConfigInfo(saId+"/blobServices/default/containers/c1", saId+"/blobServices/default/containers/c1", "azurerm_storage_container.res-2", `resource "azurerm_storage_container" "res-2" {
+  name                 = "c1"
+  storage_account_name = "sa1"
+}
+`, nil),
+		newConfigInfo(vnetId, vnetId, "azurerm_virtual_network.res-3", `resource "azurerm_virtual_network" "res-3" {
+  name                = "vnet1"
+  resource_group_name = "rg1"
+}
+`, nil),
+		newConfigInfo(subnetId, subnetId, "azurerm_subnet.res-4", `resource "azurerm_subnet" "res-4" {
+  name                 = "subnet1"
+  resource_group_name  = "rg1"
+  virtual_network_name = "vnet1"
+}
+`, nil),
+		newConfigInfo(rgId+"/providers/Microsoft.Foo/foos/foo1", rgId+"/providers/Microsoft.Foo/foos/foo1", "azurerm_foo.res-5", `resource "azurerm_foo" "res-5" {
+  name                 = "foo1"
+  storage_account_name = "sa1"
+  server_name          = "sa1"
+  subnet_id            = "/SUBSCRIPTIONS/123/resourceGroups/rg1/providers/Microsoft.Network/virtualNetworks/vnet1/subnets/SUBNET1"
+  scope                = "/subscriptions/123/resourceGroups/rg1/providers/Microsoft.Network/virtualNetworks/vnet1/subnets/subnet2"
+  rg_ids               = ["/subscriptions/123/resourceGroups/rg10", "/subscriptions/123/resourceGroups/rg1"]
+  tags = {
+    "/subscriptions/123/resourceGroups/rg1" = "x/subscriptions/123/resourceGroups/rg1/providers/Microsoft.Storage/storageAccounts/sa1;"
+  }
+}
+`, nil),
+	}
+
+	assert.NoError(t, cfgs.PopulateReferenceDeps())
+	assert.NoError(t, cfgs.ApplyDepsToHCL())
+
+	assert.Equal(t, map[string]Dependency{
+		"storage_account_name": {TFResourceId: saId, AzureResourceId: saId, TFAddr: mustParseTFAddr("azurerm_storage_account.res-1")},
+	}, cfgs[2].Dependencies.ByNameRef)
+	assert.Equal(t, `resource "azurerm_storage_container" "res-2" {
+  name                 = "c1"
+  storage_account_name = azurerm_storage_account.res-1.name
+}
+`, string(cfgs[2].HCL.Bytes()))
+	assert.Equal(t, `resource "azurerm_subnet" "res-4" {
+  name                 = "subnet1"
+  resource_group_name  = azurerm_resource_group.res-0.name
+  virtual_network_name = azurerm_virtual_network.res-3.name
+}
+`, string(cfgs[4].HCL.Bytes()))
+	assert.Equal(t, `resource "azurerm_foo" "res-5" {
+  name                 = "foo1"
+  storage_account_name = azurerm_storage_account.res-1.name
+  server_name          = "sa1"
+  subnet_id            = azurerm_subnet.res-4.id
+  scope                = "${azurerm_virtual_network.res-3.id}/subnets/subnet2"
+  rg_ids               = ["/subscriptions/123/resourceGroups/rg10", azurerm_resource_group.res-0.id]
+  tags = {
+    "/subscriptions/123/resourceGroups/rg1" = "x${azurerm_storage_account.res-1.id};"
+  }
+}
+`, string(cfgs[5].HCL.Bytes()))
+}
+
+func TestEmbeddedIds(t *testing.T) {
+	ids := []string{"/a/b", "/a/b/c/d", "/x"}
+	assert.Equal(t, []idMatch{
+		{start: 0, end: 8, id: "/a/b/c/d"},
+		{start: 9, end: 13, id: "/a/b"},
+		{start: 21, end: 23, id: "/x"},
+	}, embeddedIds("/A/B/C/D,/a/b/e/a/bc-/x", ids))
+	assert.Empty(t, embeddedIds("/a/bc", ids))
+	assert.Empty(t, embeddedIds("/a/b", nil))
+}
+
+func TestConfigInfos_PopulateReferenceDeps_CaseInsensitive(t *testing.T) {
+	rgId := "/subscriptions/123/resourceGroups/rg1"
+	fooId := rgId + "/providers/Microsoft.Foo/foos/foo1"
+	cfgs := ConfigInfos{
+		newConfigInfo(rgId, rgId, "azurerm_resource_group.res-0", `resource "azurerm_resource_group" "res-0" {
+  name = "rg1"
+}
+`, nil),
+		newConfigInfo(fooId, fooId, "azurerm_foo.res-1", `resource "azurerm_foo" "res-1" {
+  name                = "foo1"
+  resource_group_name = "RG1"
+  self_id             = "/subscriptions/123/resourceGroups/rg1/providers/Microsoft.Foo/FOOS/FOO1"
+}
+`, nil),
+	}
+	assert.NoError(t, cfgs.PopulateReferenceDeps())
+	assert.Equal(t, &Dependency{TFResourceId: rgId, AzureResourceId: rgId, TFAddr: mustParseTFAddr("azurerm_resource_group.res-0")}, cfgs[1].Dependencies.ByRgNameRef)
+	// The reference to itself in a different casing is ignored
+	assert.Empty(t, cfgs[1].Dependencies.ByIdRef)
+	assert.Empty(t, cfgs[1].Dependencies.ByIdRefAmbiguous)
+}
@@ -0,0 +1,238 @@
+package meta
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/Azure/aztfexport/internal/resourceset"
+	"github.com/Azure/aztfexport/internal/tfaddr"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/magodo/armid"
+	"github.com/magodo/aztft/aztft"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// externalDataSourceNameArgs maps the TF resource types to the arguments of their (same named) data sources, which are set by the names of the resource id in order.
+// These data sources are looked up by the names, together with the "resource_group_name" argument, except for the resource group itself.
+var externalDataSourceNameArgs = map[string][]string{
+	"azurerm_resource_group":             {"name"},
+	"azurerm_application_insights":       {"name"},
+	"azurerm_application_security_group": {"name"},
+	"azurerm_container_registry":         {"name"},
+	"azurerm_cosmosdb_account":           {"name"},
+	"azurerm_dns_zone":                   {"name"},
+	"azurerm_eventhub_namespace":         {"name"},
+	"azurerm_firewall":                   {"name"},
+	"azurerm_key_vault":                  {"name"},
+	"azurerm_kubernetes_cluster":         {"name"},
+	"azurerm_log_analytics_workspace":    {"name"},
+	"azurerm_monitor_action_group":       {"name"},
+	"azurerm_mssql_server":               {"name"},
+	"azurerm_nat_gateway":                {"name"},
+	"azurerm_network_interface":          {"name"},
+	"azurerm_network_security_group":     {"name"},
+	"azurerm_private_dns_zone":           {"name"},
+	"azurerm_public_ip":                  {"name"},
+	"azurerm_route_table":                {"name"},
+	"azurerm_service_plan":               {"name"},
+	"azurerm_servicebus_namespace":       {"name"},
+	"azurerm_storage_account":            {"name"},
+	"azurerm_subnet":                     {"virtual_network_name", "name"},
+	"azurerm_user_assigned_identity":     {"name"},
+	"azurerm_virtual_hub":                {"name"},
+	"azurerm_virtual_network":            {"name"},
+}
+
+type dataSourceArg struct {
+	Name  string
+	Value string
+}
+
+// externalDataSource is a data source of a resource that is referenced by the exported resources, but is out of the export scope.
+type externalDataSource struct {
+	AzureResourceID armid.ResourceId
+	// The address of the data source, without the "data." prefix.
+	TFAddr tfaddr.TFAddr
+	Args   []dataSourceArg
+	// The alias of the provider config, in case the resource belongs to another subscription.
+	ProviderAlias string
+}
+
+// dataSourceSubstituter substitutes the references to the out of scope resources (i.e. the resource id literals that don't match any exported resource)
+// by the data sources of these resources, which are referenced by the configs instead.
+// Only the resources whose data sources can be looked up by the names (see externalDataSourceNameArgs) are substituted.
+type dataSourceSubstituter struct {
+	providerName string
+	// queryType returns the TF resource type of the resource id, or false if it can't be determined.
+	queryType func(id string) (string, bool)
+	// providerAlias returns the alias of the provider config for the resource.
+	providerAlias func(item ImportItem) (alias, subscriptionId string)
+
+	names *nameExpander
+	// The data sources (nil for the unsupported ids) keyed by the upper cased resource id.
+	dataSources map[string]*externalDataSource
+}
+
+func (meta baseMeta) newDataSourceSubstituter() *dataSourceSubstituter {
+	return &dataSourceSubstituter{
+		providerName: meta.providerName,
+		queryType: func(id string) (string, bool) {
+			types, exact, err := aztft.QueryType(id, &aztft.APIOption{
+				Cred:         meta.azureSDKCred,
+				ClientOption: meta.azureSDKClientOpt,
+			})
+			if err != nil || !exact || len(types) != 1 {
+				return "", false
+			}
+			return types[0].TFType, true
+		},
+		providerAlias: meta.providerAlias,
+		names:         newNameExpander(""),
+		dataSources:   map[string]*externalDataSource{},
+	}
+}
+
+// Substitute is a TFConfigTransformer.
+func (s *dataSourceSubstituter) Substitute(configs ConfigInfos) (ConfigInfos, error) {
+	if s.providerName != "azurerm" {
+		return configs, nil
+	}
+	// The in scope resource ids are keyed by the upper cased forms, including the ones consolidated into the for_each resources.
+	inScope := map[string]bool{}
+	for _, cfg := range configs {
+		inScope[strings.ToUpper(cfg.TFResourceId)] = true
+		for _, item := range cfg.ForEach {
+			inScope[strings.ToUpper(item.TFResourceId)] = true
+		}
+	}
+	for _, cfg := range configs {
+		// Walk through all the blocks, including the locals block of the for_each resource, which holds the differing attributes.
+		replaceQuotedLitValues(cfg.HCL.Body(), func(lit string) hcl.Traversal {
+			if inScope[strings.ToUpper(lit)] {
+				return nil
+			}
+			ds := s.dataSource(lit)
+			if ds == nil {
+				return nil
+			}
+			return hcl.Traversal{
+				hcl.TraverseRoot{Name: "data"},
+				hcl.TraverseAttr{Name: ds.TFAddr.Type},
+				hcl.TraverseAttr{Name: ds.TFAddr.Name},
+				hcl.TraverseAttr{Name: "id"},
+			}
+		})
+	}
+	return configs, nil
+}
+
+// dataSource returns the data source of the resource id, or nil if the id is not a resource id, or its data source is not supported.
+func (s *dataSourceSubstituter) dataSource(id string) *externalDataSource {
+	if !strings.HasPrefix(strings.ToLower(id), "/subscriptions/") {
+		return nil
+	}
+	key := strings.ToUpper(id)
+	if ds, ok := s.dataSources[key]; ok {
+		return ds
+	}
+	s.dataSources[key] = nil
+
+	azureId, err := armid.ParseResourceId(id)
+	if err != nil {
+		return nil
+	}
+	tfType, ok := s.queryType(id)
+	if !ok {
+		return nil
+	}
+	nameArgs, ok := externalDataSourceNameArgs[tfType]
+	if !ok {
+		return nil
+	}
+
+	var args []dataSourceArg
+	switch azureId := azureId.(type) {
+	case *armid.ResourceGroup:
+		args = append(args, dataSourceArg{Name: nameArgs[0], Value: azureId.Name})
+	case *armid.ScopedResourceId:
+		rg, ok := azureId.ParentScope().(*armid.ResourceGroup)
+		if !ok || len(azureId.Names()) != len(nameArgs) {
+			return nil
+		}
+		for i, name := range azureId.Names() {
+			args = append(args, dataSourceArg{Name: nameArgs[i], Value: name})
+		}
+		args = append(args, dataSourceArg{Name: "resource_group_name", Value: rg.Name})
+	default:
+		return nil
+	}
+
+	ds := &externalDataSource{
+		AzureResourceID: azureId,
+		TFAddr: tfaddr.TFAddr{
+			Type: tfType,
+			Name: s.names.Expand(resourceset.TFResource{AzureId: azureId, TFType: tfType}),
+		},
+		Args: args,
+	}
+	ds.ProviderAlias, _ = s.providerAlias(ImportItem{AzureResourceID: azureId})
+	s.dataSources[key] = ds
+	return ds
+}
+
+// DataSources returns the substituted data sources, sorted by the addresses.
+func (s *dataSourceSubstituter) DataSources() []externalDataSource {
+	var out []externalDataSource
+	for _, ds := range s.dataSources {
+		if ds != nil {
+			out = append(out, *ds)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].TFAddr.String() < out[j].TFAddr.String()
+	})
+	return out
+}
+
+// ImportItems returns the (pseudo) import items of the substituted data sources, which are only meant for determining the provider aliases.
+func (s *dataSourceSubstituter) ImportItems() []ImportItem {
+	var out []ImportItem
+	for _, ds := range s.DataSources() {
+		out = append(out, ImportItem{AzureResourceID: ds.AzureResourceID})
+	}
+	return out
+}
+
+// DataSourcesFile returns the HCL file of the data blocks of the substituted data sources.
+func (s *dataSourceSubstituter) DataSourcesFile() *hclwrite.File {
+	f := hclwrite.NewFile()
+	body := f.Body()
+	for i, ds := range s.DataSources() {
+		if i != 0 {
+			body.AppendNewline()
+		}
+		blk := body.AppendNewBlock("data", []string{ds.TFAddr.Type, ds.TFAddr.Name})
+		for _, arg := range ds.Args {
+			blk.Body().SetAttributeValue(arg.Name, cty.StringVal(arg.Value))
+		}
+		if ds.ProviderAlias != "" {
+			blk.Body().SetAttributeTraversal("provider", hcl.Traversal{
+				hcl.TraverseRoot{Name: s.providerName},
+				hcl.TraverseAttr{Name: ds.ProviderAlias},
+			})
+		}
+	}
+	return f
+}
+
+// replaceQuotedLitValues walks through the attributes in the body and its nested blocks, and replaces the quoted string literals
+// that are the whole values (e.g. the elements of a list) by the traversals, in case the function returns a non-nil traversal.
+func replaceQuotedLitValues(body *hclwrite.Body, f func(lit string) hcl.Traversal) {
+	replaceQuotedLits(body, func(lit string) hclwrite.Tokens {
+		if traversal := f(lit); traversal != nil {
+			return hclwrite.TokensForTraversal(traversal)
+		}
+		return nil
+	})
+}
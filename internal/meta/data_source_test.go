@@ -0,0 +1,104 @@
+package meta
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDataSourceSubstituter(t *testing.T) {
+	meta := baseMeta{
+		subscriptionId: "123",
+		providerName:   "azurerm",
+	}
+	s := meta.newDataSourceSubstituter()
+	s.queryType = func(id string) (string, bool) {
+		switch {
+		case strings.Contains(id, "/subnets/"):
+			return "azurerm_subnet", true
+		case strings.Contains(id, "/workspaces/"):
+			return "azurerm_log_analytics_workspace", true
+		case strings.Contains(id, "/virtualMachines/"):
+			return "azurerm_linux_virtual_machine", true
+		}
+		return "", false
+	}
+
+	cfgs := ConfigInfos{
+		newConfigInfo(
+			"/subscriptions/123/resourceGroups/rg1/providers/Microsoft.Network/networkInterfaces/nic1",
+			"/subscriptions/123/resourceGroups/rg1/providers/Microsoft.Network/networkInterfaces/nic1",
+			"azurerm_network_interface.nic1",
+			`resource "azurerm_network_interface" "nic1" {
+  name = "nic1"
+  ip_configuration {
+    subnet_id = "/subscriptions/123/resourceGroups/hub/providers/Microsoft.Network/virtualNetworks/hub-vnet/subnets/default"
+  }
+  vm_id = "/subscriptions/123/resourceGroups/rg1/providers/Microsoft.Compute/virtualMachines/vm1"
+  workspace_ids = ["/subscriptions/456/resourceGroups/logs/providers/Microsoft.OperationalInsights/workspaces/ws1", "/subscriptions/123/resourceGroups/rg1/providers/Microsoft.Network/virtualNetworks/vnet1/subnets/internal"]
+  subnet_ids    = ["/SUBSCRIPTIONS/123/resourceGroups/rg1/providers/Microsoft.Network/virtualNetworks/vnet1/subnets/INTERNAL", "/subscriptions/123/resourceGroups/rg1/providers/Microsoft.Network/virtualNetworks/vnet1/subnets/consolidated"]
+}
+`, nil),
+		newConfigInfo(
+			"/subscriptions/123/resourceGroups/rg1/providers/Microsoft.Network/virtualNetworks/vnet1/subnets/internal",
+			"/subscriptions/123/resourceGroups/rg1/providers/Microsoft.Network/virtualNetworks/vnet1/subnets/internal",
+			"azurerm_subnet.internal",
+			`resource "azurerm_subnet" "internal" {
+  name = "internal"
+}
+`, nil),
+		newConfigInfo(
+			"/subscriptions/123/resourceGroups/rg1/providers/Microsoft.Network/networkInterfaces/nic2",
+			"/subscriptions/123/resourceGroups/rg1/providers/Microsoft.Network/networkInterfaces/nic2",
+			"azurerm_network_interface.nic2",
+			`resource "azurerm_network_interface" "nic2" {
+  for_each  = local.nic2
+  name      = each.key
+  subnet_id = each.value.subnet_id
+}
+
+locals {
+  nic2 = {
+    "nic2" = {
+      subnet_id = "/subscriptions/123/resourceGroups/hub/providers/Microsoft.Network/virtualNetworks/hub-vnet/subnets/default"
+    }
+  }
+}
+`, nil),
+	}
+	cfgs[2].ForEach = []ImportItem{
+		{TFResourceId: "/subscriptions/123/resourceGroups/rg1/providers/Microsoft.Network/virtualNetworks/vnet1/subnets/consolidated"},
+	}
+
+	cfgs, err := s.Substitute(cfgs)
+	require.NoError(t, err)
+	// The in scope resource id (i.e. the subnet "internal") and the unsupported resource id (i.e. the virtual machine) are kept as is.
+	require.Equal(t, `resource "azurerm_network_interface" "nic1" {
+  name = "nic1"
+  ip_configuration {
+    subnet_id = data.azurerm_subnet.hub_vnet_default_8291c0b0.id
+  }
+  vm_id         = "/subscriptions/123/resourceGroups/rg1/providers/Microsoft.Compute/virtualMachines/vm1"
+  workspace_ids = [data.azurerm_log_analytics_workspace.ws1_54ddde3d.id, "/subscriptions/123/resourceGroups/rg1/providers/Microsoft.Network/virtualNetworks/vnet1/subnets/internal"]
+  subnet_ids    = ["/SUBSCRIPTIONS/123/resourceGroups/rg1/providers/Microsoft.Network/virtualNetworks/vnet1/subnets/INTERNAL", "/subscriptions/123/resourceGroups/rg1/providers/Microsoft.Network/virtualNetworks/vnet1/subnets/consolidated"]
+}
+`, string(hclwrite.Format(cfgs[0].HCL.Bytes())))
+	// The out of scope resource ids in the locals block of the for_each resource are substituted as well.
+	require.Contains(t, string(hclwrite.Format(cfgs[2].HCL.Bytes())), `subnet_id = data.azurerm_subnet.hub_vnet_default_8291c0b0.id`)
+
+	require.Len(t, s.ImportItems(), 2)
+	require.Equal(t, `data "azurerm_log_analytics_workspace" "ws1_54ddde3d" {
+  name                = "ws1"
+  resource_group_name = "logs"
+  provider            = azurerm.sub-456
+}
+
+data "azurerm_subnet" "hub_vnet_default_8291c0b0" {
+  virtual_network_name = "hub-vnet"
+  name                 = "default"
+  resource_group_name  = "hub"
+}
+`, string(hclwrite.Format(s.DataSourcesFile().Bytes())))
+}
@@ -0,0 +1,186 @@
+package meta
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+
+	"github.com/Azure/aztfexport/internal/tfaddr"
+)
+
+// The kinds of the edges of the dependency graph, one for each kind of the inferred dependencies.
+const (
+	dependencyKindIdRef     = "id_reference"
+	dependencyKindRgNameRef = "rg_name_reference"
+	dependencyKindNameRef   = "name_reference"
+	dependencyKindRelation  = "relation"
+	dependencyKindAmbiguous = "ambiguous"
+)
+
+type dependencyGraphNode struct {
+	// The address of the resource, prefixed by the module address for the module layout.
+	Address         string `json:"address"`
+	AzureResourceId string `json:"azure_resource_id"`
+	TFResourceId    string `json:"tf_resource_id"`
+}
+
+type dependencyGraphEdge struct {
+	// The addresses of the depending and the depended resources.
+	From string `json:"from"`
+	To   string `json:"to"`
+	Kind string `json:"kind"`
+}
+
+type dependencyGraphOutput struct {
+	Nodes []dependencyGraphNode `json:"nodes"`
+	Edges []dependencyGraphEdge `json:"edges"`
+}
+
+type dependencyGraphRawEdge struct {
+	from, to string
+	kind     string
+}
+
+// dependencyGraph collects the dependencies of the configs, right after they are populated.
+// The nodes and edges are keyed by the Azure resource ids, as the TF addresses can still change afterwards (e.g. by the for_each consolidation).
+type dependencyGraph struct {
+	moduleLayout moduleLayout
+	nodes        map[string]ImportItem
+	edges        map[dependencyGraphRawEdge]bool
+}
+
+func newDependencyGraph(layout moduleLayout) *dependencyGraph {
+	return &dependencyGraph{
+		moduleLayout: layout,
+		nodes:        map[string]ImportItem{},
+		edges:        map[dependencyGraphRawEdge]bool{},
+	}
+}
+
+// Collect is a TFConfigTransformer, which doesn't change the configs.
+func (g *dependencyGraph) Collect(configs ConfigInfos) (ConfigInfos, error) {
+	for _, cfg := range configs {
+		from := cfg.AzureResourceID.String()
+		g.nodes[from] = cfg.ImportItem
+		add := func(dep Dependency, kind string) {
+			g.edges[dependencyGraphRawEdge{from: from, to: dep.AzureResourceId, kind: kind}] = true
+		}
+		for _, dep := range cfg.Dependencies.ByIdRef {
+			add(dep, dependencyKindIdRef)
+		}
+		for _, deps := range cfg.Dependencies.ByIdRefAmbiguous {
+			for _, dep := range deps {
+				add(dep, dependencyKindAmbiguous)
+			}
+		}
+		for _, dep := range cfg.Dependencies.ByNameRef {
+			add(dep, dependencyKindNameRef)
+		}
+		if dep := cfg.Dependencies.ByRgNameRef; dep != nil {
+			add(*dep, dependencyKindRgNameRef)
+		}
+		if dep := cfg.Dependencies.ByRelation; dep != nil {
+			add(*dep, dependencyKindRelation)
+		}
+	}
+	return configs, nil
+}
+
+// Output returns the nodes and edges, sorted by the addresses, given the final configs to resolve the TF addresses of the resources.
+func (g *dependencyGraph) Output(cfgs ConfigInfos) dependencyGraphOutput {
+	addrs := map[string]tfaddr.TFAddr{}
+	for _, cfg := range cfgs {
+		if len(cfg.ForEach) == 0 {
+			addrs[cfg.AzureResourceID.String()] = cfg.TFAddr
+			continue
+		}
+		for _, item := range cfg.ForEach {
+			addrs[item.AzureResourceID.String()] = item.TFAddr
+		}
+	}
+
+	nodeAddrs := map[string]string{}
+	out := dependencyGraphOutput{
+		Nodes: []dependencyGraphNode{},
+		Edges: []dependencyGraphEdge{},
+	}
+	for id, item := range g.nodes {
+		addr, ok := addrs[id]
+		if !ok {
+			addr = item.TFAddr
+		}
+		nodeAddrs[id] = moduleResourceAddr(g.moduleLayout.moduleName(item), addr)
+		out.Nodes = append(out.Nodes, dependencyGraphNode{
+			Address:         nodeAddrs[id],
+			AzureResourceId: id,
+			TFResourceId:    item.TFResourceId,
+		})
+	}
+	for edge := range g.edges {
+		from, to := nodeAddrs[edge.from], nodeAddrs[edge.to]
+		if from == "" || to == "" {
+			continue
+		}
+		out.Edges = append(out.Edges, dependencyGraphEdge{From: from, To: to, Kind: edge.kind})
+	}
+	sort.Slice(out.Nodes, func(i, j int) bool {
+		return out.Nodes[i].Address < out.Nodes[j].Address
+	})
+	sort.Slice(out.Edges, func(i, j int) bool {
+		ei, ej := out.Edges[i], out.Edges[j]
+		if ei.From != ej.From {
+			return ei.From < ej.From
+		}
+		if ei.To != ej.To {
+			return ei.To < ej.To
+		}
+		return ei.Kind < ej.Kind
+	})
+	return out
+}
+
+// DOT renders the graph in the Graphviz DOT language. The edges of the ambiguous dependencies are dashed.
+func (out dependencyGraphOutput) DOT() []byte {
+	var buf bytes.Buffer
+	buf.WriteString("digraph dependencies {\n")
+	buf.WriteString("\trankdir=LR;\n")
+	buf.WriteString("\tnode [shape=box];\n")
+	for _, node := range out.Nodes {
+		fmt.Fprintf(&buf, "\t%s [tooltip=%s];\n", strconv.Quote(node.Address), strconv.Quote(node.AzureResourceId))
+	}
+	for _, edge := range out.Edges {
+		attrs := "label=" + strconv.Quote(edge.Kind)
+		if edge.Kind == dependencyKindAmbiguous {
+			attrs += ", style=dashed"
+		}
+		fmt.Fprintf(&buf, "\t%s -> %s [%s];\n", strconv.Quote(edge.From), strconv.Quote(edge.To), attrs)
+	}
+	buf.WriteString("}\n")
+	return buf.Bytes()
+}
+
+// writeDependencyGraph writes the dependency graph in both DOT and JSON to the output directory, next to the resource mapping file.
+func (meta baseMeta) writeDependencyGraph(g *dependencyGraph, cfgs ConfigInfos) error {
+	out := g.Output(cfgs)
+
+	b, err := json.MarshalIndent(out, "", "\t")
+	if err != nil {
+		return fmt.Errorf("JSON marshalling the dependency graph: %v", err)
+	}
+	jsonFile := filepath.Join(meta.outdir, DependencyGraphJSONFileName)
+	// #nosec G306
+	if err := os.WriteFile(jsonFile, b, 0644); err != nil {
+		return fmt.Errorf("writing the dependency graph to %s: %v", jsonFile, err)
+	}
+
+	dotFile := filepath.Join(meta.outdir, DependencyGraphDOTFileName)
+	// #nosec G306
+	if err := os.WriteFile(dotFile, out.DOT(), 0644); err != nil {
+		return fmt.Errorf("writing the dependency graph to %s: %v", dotFile, err)
+	}
+	return nil
+}
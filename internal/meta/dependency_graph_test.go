@@ -0,0 +1,102 @@
+package meta
+
+import (
+	"testing"
+
+	"github.com/Azure/aztfexport/pkg/config"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDependencyGraph(t *testing.T) {
+	rgId := "/subscriptions/123/resourceGroups/rg1"
+	vnetId := rgId + "/providers/Microsoft.Network/virtualNetworks/vnet1"
+	subnetId := vnetId + "/subnets/subnet1"
+	nic1Id := rgId + "/providers/Microsoft.Network/networkInterfaces/nic1"
+	nic2Id := rgId + "/providers/Microsoft.Network/networkInterfaces/nic2"
+	dep := func(id, addr string) Dependency {
+		return Dependency{TFResourceId: id, AzureResourceId: id, TFAddr: mustParseTFAddr(addr)}
+	}
+
+	cfgs := ConfigInfos{
+		newConfigInfo(rgId, rgId, "azurerm_resource_group.res-0", `resource "azurerm_resource_group" "res-0" {}`, nil),
+		newConfigInfo(vnetId, vnetId, "azurerm_virtual_network.res-1", `resource "azurerm_virtual_network" "res-1" {}`, &Dependencies{
+			ByRgNameRef: ptr(dep(rgId, "azurerm_resource_group.res-0")),
+		}),
+		newConfigInfo(subnetId, subnetId, "azurerm_subnet.res-2", `resource "azurerm_subnet" "res-2" {}`, &Dependencies{
+			ByRelation: ptr(dep(vnetId, "azurerm_virtual_network.res-1")),
+		}),
+		newConfigInfo(nic1Id, nic1Id, "azurerm_network_interface.res-3", `resource "azurerm_network_interface" "res-3" {}`, &Dependencies{
+			ByIdRef: map[string]Dependency{
+				subnetId: dep(subnetId, "azurerm_subnet.res-2"),
+			},
+		}),
+		newConfigInfo(nic2Id, nic2Id, "azurerm_network_interface.res-4", `resource "azurerm_network_interface" "res-4" {}`, &Dependencies{
+			ByIdRefAmbiguous: map[string][]Dependency{
+				"foo": {
+					dep(vnetId, "azurerm_virtual_network.res-1"),
+					dep(subnetId, "azurerm_subnet.res-2"),
+				},
+			},
+		}),
+	}
+
+	g := newDependencyGraph(moduleLayout{layout: config.ModuleLayoutNone})
+	_, err := g.Collect(cfgs)
+	require.NoError(t, err)
+
+	// The subnet is consolidated into a for_each resource afterwards.
+	final := append(ConfigInfos{}, cfgs...)
+	final[2].TFAddr = mustParseTFAddr("azurerm_subnet.res-2")
+	final[2].ForEach = []ImportItem{final[2].ImportItem}
+	final[2].ForEach[0].TFAddr = mustParseTFAddr(`azurerm_subnet.res-2["subnet1"]`)
+
+	out := g.Output(final)
+	require.Equal(t, []dependencyGraphNode{
+		{Address: "azurerm_network_interface.res-3", AzureResourceId: nic1Id, TFResourceId: nic1Id},
+		{Address: "azurerm_network_interface.res-4", AzureResourceId: nic2Id, TFResourceId: nic2Id},
+		{Address: "azurerm_resource_group.res-0", AzureResourceId: rgId, TFResourceId: rgId},
+		{Address: `azurerm_subnet.res-2["subnet1"]`, AzureResourceId: subnetId, TFResourceId: subnetId},
+		{Address: "azurerm_virtual_network.res-1", AzureResourceId: vnetId, TFResourceId: vnetId},
+	}, out.Nodes)
+	require.Equal(t, []dependencyGraphEdge{
+		{From: "azurerm_network_interface.res-3", To: `azurerm_subnet.res-2["subnet1"]`, Kind: dependencyKindIdRef},
+		{From: "azurerm_network_interface.res-4", To: `azurerm_subnet.res-2["subnet1"]`, Kind: dependencyKindAmbiguous},
+		{From: "azurerm_network_interface.res-4", To: "azurerm_virtual_network.res-1", Kind: dependencyKindAmbiguous},
+		{From: `azurerm_subnet.res-2["subnet1"]`, To: "azurerm_virtual_network.res-1", Kind: dependencyKindRelation},
+		{From: "azurerm_virtual_network.res-1", To: "azurerm_resource_group.res-0", Kind: dependencyKindRgNameRef},
+	}, out.Edges)
+
+	require.Equal(t, `digraph dependencies {
+	rankdir=LR;
+	node [shape=box];
+	"azurerm_network_interface.res-3" [tooltip="`+nic1Id+`"];
+	"azurerm_network_interface.res-4" [tooltip="`+nic2Id+`"];
+	"azurerm_resource_group.res-0" [tooltip="`+rgId+`"];
+	"azurerm_subnet.res-2[\"subnet1\"]" [tooltip="`+subnetId+`"];
+	"azurerm_virtual_network.res-1" [tooltip="`+vnetId+`"];
+	"azurerm_network_interface.res-3" -> "azurerm_subnet.res-2[\"subnet1\"]" [label="id_reference"];
+	"azurerm_network_interface.res-4" -> "azurerm_subnet.res-2[\"subnet1\"]" [label="ambiguous", style=dashed];
+	"azurerm_network_interface.res-4" -> "azurerm_virtual_network.res-1" [label="ambiguous", style=dashed];
+	"azurerm_subnet.res-2[\"subnet1\"]" -> "azurerm_virtual_network.res-1" [label="relation"];
+	"azurerm_virtual_network.res-1" -> "azurerm_resource_group.res-0" [label="rg_name_reference"];
+}
+`, string(out.DOT()))
+}
+
+func TestDependencyGraphModuleLayout(t *testing.T) {
+	rgId := "/subscriptions/123/resourceGroups/rg1"
+	vnetId := rgId + "/providers/Microsoft.Network/virtualNetworks/vnet1"
+	cfgs := ConfigInfos{
+		newConfigInfo(rgId, rgId, "azurerm_resource_group.res-0", `resource "azurerm_resource_group" "res-0" {}`, nil),
+		newConfigInfo(vnetId, vnetId, "azurerm_virtual_network.res-1", `resource "azurerm_virtual_network" "res-1" {}`, &Dependencies{
+			ByRgNameRef: &Dependency{TFResourceId: rgId, AzureResourceId: rgId, TFAddr: mustParseTFAddr("azurerm_resource_group.res-0")},
+		}),
+	}
+	g := newDependencyGraph(moduleLayout{layout: config.ModuleLayoutResourceType})
+	_, err := g.Collect(cfgs)
+	require.NoError(t, err)
+	out := g.Output(cfgs)
+	require.Equal(t, []dependencyGraphEdge{
+		{From: "module.azurerm_virtual_network.azurerm_virtual_network.res-1", To: "module.azurerm_resource_group.azurerm_resource_group.res-0", Kind: dependencyKindRgNameRef},
+	}, out.Edges)
+}
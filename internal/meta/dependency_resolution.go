@@ -0,0 +1,155 @@
+package meta
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/Azure/aztfexport/internal/tfaddr"
+)
+
+// DependencyResolutions resolves the ambiguous dependencies, i.e. the references to the TF resource ids that map to multiple exported resources.
+// The outer key is the Azure resource id of the referencing resource, the inner key is the referenced TF resource id,
+// and the value is the Azure resource id of the chosen resource, e.g.:
+//
+//	{
+//	  "/subscriptions/123/resourceGroups/rg1/providers/Microsoft.Network/networkInterfaces/nic1": {
+//	    "/subscriptions/123/resourceGroups/rg1/providers/Microsoft.Foo/foos/foo1": "/subscriptions/123/resourceGroups/rg1/providers/Microsoft.Foo/foos/foo1/bars/default"
+//	  }
+//	}
+//
+// The Azure resource ids are matched case insensitively.
+type DependencyResolutions map[string]map[string]string
+
+// AmbiguousDependency is a reference of an exported resource to a TF resource id, which maps to multiple exported resources.
+type AmbiguousDependency struct {
+	// The Azure resource id and the TF address of the referencing resource.
+	AzureResourceId string
+	TFAddr          tfaddr.TFAddr
+	// The referenced TF resource id.
+	TFResourceId string
+	// The resources that the TF resource id maps to, sorted by the TF addresses.
+	Candidates []Dependency
+}
+
+// ParseDependencyResolutionFile parses the dependency resolution file, in the JSON format of DependencyResolutions.
+func ParseDependencyResolutionFile(p string) (DependencyResolutions, error) {
+	// #nosec G304
+	b, err := os.ReadFile(p)
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %v", p, err)
+	}
+	var r DependencyResolutions
+	if err := json.Unmarshal(b, &r); err != nil {
+		return nil, fmt.Errorf("unmarshalling %s: %v", p, err)
+	}
+	return r, nil
+}
+
+// Set records the resource (by the Azure resource id) chosen for the reference of the resource to the TF resource id, which overrides the existing one.
+func (r DependencyResolutions) Set(azureResourceId, tfResourceId, chosenAzureResourceId string) {
+	for id := range r {
+		if strings.EqualFold(id, azureResourceId) {
+			azureResourceId = id
+			break
+		}
+	}
+	if r[azureResourceId] == nil {
+		r[azureResourceId] = map[string]string{}
+	}
+	for id := range r[azureResourceId] {
+		if strings.EqualFold(id, tfResourceId) {
+			tfResourceId = id
+			break
+		}
+	}
+	r[azureResourceId][tfResourceId] = chosenAzureResourceId
+}
+
+func (r DependencyResolutions) lookup(azureResourceId, tfResourceId string) (string, bool) {
+	for id, m := range r {
+		if !strings.EqualFold(id, azureResourceId) {
+			continue
+		}
+		for tfId, chosen := range m {
+			if strings.EqualFold(tfId, tfResourceId) {
+				return chosen, true
+			}
+		}
+		return "", false
+	}
+	return "", false
+}
+
+// ResolveAmbiguousDeps turns the resolved ambiguous dependencies into the id reference dependencies.
+// It returns an error in case the chosen resource is not one of the candidates.
+func (cfgs ConfigInfos) ResolveAmbiguousDeps(r DependencyResolutions) error {
+	if len(r) == 0 {
+		return nil
+	}
+	for i, cfg := range cfgs {
+		for tfResourceId, deps := range cfg.Dependencies.ByIdRefAmbiguous {
+			chosen, ok := r.lookup(cfg.AzureResourceID.String(), tfResourceId)
+			if !ok {
+				continue
+			}
+			var resolved *Dependency
+			for i := range deps {
+				if strings.EqualFold(deps[i].AzureResourceId, chosen) {
+					resolved = &deps[i]
+					break
+				}
+			}
+			if resolved == nil {
+				return fmt.Errorf("the resolution %s of the reference of %s to %s is not one of the candidates", chosen, cfg.AzureResourceID, tfResourceId)
+			}
+			cfg.Dependencies.ByIdRef[tfResourceId] = *resolved
+			delete(cfg.Dependencies.ByIdRefAmbiguous, tfResourceId)
+		}
+		cfgs[i] = cfg
+	}
+	return nil
+}
+
+// ambiguousDependencies returns the ambiguous dependencies of the configs, sorted by the referencing resources and the referenced TF resource ids.
+func (cfgs ConfigInfos) ambiguousDependencies() []AmbiguousDependency {
+	var out []AmbiguousDependency
+	for _, cfg := range cfgs {
+		for tfResourceId, deps := range cfg.Dependencies.ByIdRefAmbiguous {
+			candidates := append([]Dependency{}, deps...)
+			sort.Slice(candidates, func(i, j int) bool {
+				return candidates[i].TFAddr.String() < candidates[j].TFAddr.String()
+			})
+			out = append(out, AmbiguousDependency{
+				AzureResourceId: cfg.AzureResourceID.String(),
+				TFAddr:          cfg.TFAddr,
+				TFResourceId:    tfResourceId,
+				Candidates:      candidates,
+			})
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].TFAddr.String() != out[j].TFAddr.String() {
+			return out[i].TFAddr.String() < out[j].TFAddr.String()
+		}
+		return out[i].TFResourceId < out[j].TFResourceId
+	})
+	return out
+}
+
+// writeDependencyResolutions writes the dependency resolutions to the output directory, so that they can be reused by the later exports.
+func (meta baseMeta) writeDependencyResolutions() error {
+	b, err := json.MarshalIndent(meta.dependencyResolutions, "", "\t")
+	if err != nil {
+		return fmt.Errorf("JSON marshalling the dependency resolutions: %v", err)
+	}
+	output := filepath.Join(meta.outdir, DependencyResolutionFileName)
+	// #nosec G306
+	if err := os.WriteFile(output, b, 0644); err != nil {
+		return fmt.Errorf("writing the dependency resolutions to %s: %v", output, err)
+	}
+	return nil
+}
@@ -0,0 +1,99 @@
+package meta
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolveAmbiguousDeps(t *testing.T) {
+	fooId := "/subscriptions/123/resourceGroups/rg1/providers/Microsoft.Foo/foos/foo1"
+	barId := fooId + "/bars/default"
+	nicId := "/subscriptions/123/resourceGroups/rg1/providers/Microsoft.Network/networkInterfaces/nic1"
+	newCfgs := func() ConfigInfos {
+		return ConfigInfos{
+			newConfigInfo(nicId, nicId, "azurerm_network_interface.res-0", `resource "azurerm_network_interface" "res-0" {
+  foo_id = "`+fooId+`"
+}
+`, &Dependencies{
+				ByIdRef: map[string]Dependency{},
+				ByIdRefAmbiguous: map[string][]Dependency{
+					fooId: {
+						{TFResourceId: fooId, AzureResourceId: fooId, TFAddr: mustParseTFAddr("azurerm_foo.res-1")},
+						{TFResourceId: fooId, AzureResourceId: barId, TFAddr: mustParseTFAddr("azurerm_bar.res-2")},
+					},
+				},
+			}),
+		}
+	}
+
+	cfgs := newCfgs()
+	require.Equal(t, []AmbiguousDependency{
+		{
+			AzureResourceId: nicId,
+			TFAddr:          mustParseTFAddr("azurerm_network_interface.res-0"),
+			TFResourceId:    fooId,
+			Candidates: []Dependency{
+				{TFResourceId: fooId, AzureResourceId: barId, TFAddr: mustParseTFAddr("azurerm_bar.res-2")},
+				{TFResourceId: fooId, AzureResourceId: fooId, TFAddr: mustParseTFAddr("azurerm_foo.res-1")},
+			},
+		},
+	}, cfgs.ambiguousDependencies())
+
+	// Unresolved
+	require.NoError(t, cfgs.ResolveAmbiguousDeps(DependencyResolutions{}))
+	require.Len(t, cfgs[0].Dependencies.ByIdRefAmbiguous, 1)
+
+	// Resolved, with the ids matched case insensitively
+	r := DependencyResolutions{}
+	r.Set(nicId, fooId, barId)
+	r.Set(nicId, "/unrelated", fooId)
+	r.Set(strings.ToUpper(nicId), strings.ToLower(fooId), strings.ToUpper(barId))
+	require.Len(t, r, 1)
+	require.Len(t, r[nicId], 2)
+	require.NoError(t, cfgs.ResolveAmbiguousDeps(r))
+	require.Empty(t, cfgs[0].Dependencies.ByIdRefAmbiguous)
+	require.Equal(t, map[string]Dependency{
+		fooId: {TFResourceId: fooId, AzureResourceId: barId, TFAddr: mustParseTFAddr("azurerm_bar.res-2")},
+	}, cfgs[0].Dependencies.ByIdRef)
+	require.NoError(t, cfgs.ApplyDepsToHCL())
+	require.Equal(t, `resource "azurerm_network_interface" "res-0" {
+  foo_id = azurerm_bar.res-2.id
+}
+`, string(cfgs[0].HCL.Bytes()))
+
+	// Resolved by the resolution file written with a different id casing
+	cfgs = newCfgs()
+	require.NoError(t, cfgs.ResolveAmbiguousDeps(DependencyResolutions{strings.ToLower(nicId): {strings.ToUpper(fooId): barId}}))
+	require.Empty(t, cfgs[0].Dependencies.ByIdRefAmbiguous)
+
+	// Resolved to a non-candidate
+	cfgs = newCfgs()
+	require.Error(t, cfgs.ResolveAmbiguousDeps(DependencyResolutions{nicId: {fooId: nicId}}))
+}
+
+func TestParseDependencyResolutionFile(t *testing.T) {
+	p := filepath.Join(t.TempDir(), "resolution.json")
+	require.NoError(t, os.WriteFile(p, []byte(`{"/subscriptions/123/resourceGroups/rg1": {"/foo": "/bar"}}`), 0644))
+	r, err := ParseDependencyResolutionFile(p)
+	require.NoError(t, err)
+	require.Equal(t, DependencyResolutions{"/subscriptions/123/resourceGroups/rg1": {"/foo": "/bar"}}, r)
+
+	require.NoError(t, os.WriteFile(p, []byte(`["/foo"]`), 0644))
+	_, err = ParseDependencyResolutionFile(p)
+	require.Error(t, err)
+}
+
+func TestImportListHasSharedTFResourceId(t *testing.T) {
+	l := ImportList{
+		{TFResourceId: "/subscriptions/123/resourceGroups/rg1/providers/Microsoft.Foo/foos/foo1"},
+		{TFResourceId: "/subscriptions/123/resourceGroups/rg1/providers/Microsoft.Foo/foos/foo2"},
+	}
+	require.False(t, l.hasSharedTFResourceId())
+
+	l = append(l, ImportItem{TFResourceId: "/SUBSCRIPTIONS/123/resourceGroups/rg1/providers/Microsoft.Foo/foos/foo1"})
+	require.True(t, l.hasSharedTFResourceId())
+}
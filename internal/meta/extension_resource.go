@@ -1,16 +1,27 @@
 package meta
 
 import (
+	"context"
+	"fmt"
+	"sort"
 	"strings"
 
+	"github.com/magodo/armid"
 	"github.com/magodo/azlist/azlist"
 )
 
 // Supported extension resource types
 const (
-	ExtensionResourceTypeRoleAssignments    = "role-assignments"
-	ExtensionResourceTypeLocks              = "locks"
-	ExtensionResourceTypeDiagnosticSettings = "diagnostics-settings"
+	ExtensionResourceTypeRoleAssignments            = "role-assignments"
+	ExtensionResourceTypeLocks                      = "locks"
+	ExtensionResourceTypeDiagnosticSettings         = "diagnostics-settings"
+	ExtensionResourceTypePolicyAssignments          = "policy-assignments"
+	ExtensionResourceTypeSecurityPricings           = "security-pricings"
+	ExtensionResourceTypeAdvancedThreatProtection   = "advanced-threat-protection"
+	ExtensionResourceTypeTags                       = "tags"
+	ExtensionResourceTypePrivateEndpointConnections = "private-endpoint-connections"
+	ExtensionResourceTypeAutoscaleSettings          = "autoscale-settings"
+	ExtensionResourceTypeActionGroups               = "action-groups"
 )
 
 // SupportedExtensionResourceTypes is the list of supported extension resource types.
@@ -18,6 +29,13 @@ var SupportedExtensionResourceTypes = []string{
 	ExtensionResourceTypeRoleAssignments,
 	ExtensionResourceTypeLocks,
 	ExtensionResourceTypeDiagnosticSettings,
+	ExtensionResourceTypePolicyAssignments,
+	ExtensionResourceTypeSecurityPricings,
+	ExtensionResourceTypeAdvancedThreatProtection,
+	ExtensionResourceTypeTags,
+	ExtensionResourceTypePrivateEndpointConnections,
+	ExtensionResourceTypeAutoscaleSettings,
+	ExtensionResourceTypeActionGroups,
 }
 
 type extBuilder struct {
@@ -53,38 +71,188 @@ func (b extBuilder) Build() []azlist.ExtensionResource {
 			})
 		case ExtensionResourceTypeLocks:
 			el = append(el, azlist.ExtensionResource{
-				Type: "Microsoft.Authorization/locks",
+				Type:   "Microsoft.Authorization/locks",
+				Filter: scopeFilter("Microsoft.Authorization/locks"),
+			})
+		case ExtensionResourceTypeDiagnosticSettings:
+			el = append(el, azlist.ExtensionResource{
+				Type:   "Microsoft.Insights/diagnosticSettings",
+				Filter: scopeFilter("Microsoft.Insights/diagnosticSettings"),
+			})
+		case ExtensionResourceTypePolicyAssignments:
+			el = append(el, azlist.ExtensionResource{
+				Type:   "Microsoft.Authorization/policyAssignments",
+				Filter: scopeFilter("Microsoft.Authorization/policyAssignments"),
+			})
+		case ExtensionResourceTypeSecurityPricings:
+			el = append(el, azlist.ExtensionResource{
+				Type:   "Microsoft.Security/pricings",
+				Filter: scopeFilter("Microsoft.Security/pricings"),
+			})
+		case ExtensionResourceTypeAdvancedThreatProtection:
+			el = append(el, azlist.ExtensionResource{
+				Type:   "Microsoft.Security/advancedThreatProtectionSettings",
+				Filter: scopeFilter("Microsoft.Security/advancedThreatProtectionSettings"),
+			})
+		case ExtensionResourceTypeTags:
+			filter := scopeFilter("Microsoft.Resources/tags")
+			el = append(el, azlist.ExtensionResource{
+				Type: "Microsoft.Resources/tags",
 				Filter: func(res, extensionRes map[string]any) bool {
-					idRaw, ok := res["id"]
-					if !ok {
+					if !filter(res, extensionRes) {
 						return false
 					}
-					id := idRaw.(string)
+					// The tags resource always exists for a resource, only keep it if there is any tag.
+					props, _ := extensionRes["properties"].(map[string]any)
+					tags, _ := props["tags"].(map[string]any)
+					return len(tags) != 0
+				},
+			})
+		}
+	}
 
-					lockIdRaw, ok := extensionRes["id"]
-					if !ok {
-						return false
-					}
-					lockId := lockIdRaw.(string)
+	return el
+}
 
-					// A management lock's id is in form of "<scope>/providers/Microsoft.Authorization/locks/<name>".
-					// Derive the scope that the lock applies to and only keep the lock if that scope is
-					// one of the exported resources (i.e. matches the parent resource being listed).
-					idx := strings.Index(strings.ToLower(lockId), "/providers/microsoft.authorization/locks/")
-					if idx == -1 {
-						return false
+// scopeFilter returns a filter that only keeps the extension resources of the resource type that apply to the listed resource.
+// The extension resource's id is in form of "<scope>/providers/<resource type>/<name>", where the scope is derived from.
+// This excludes the extension resources that are inherited from the parent scopes, e.g. the ones returned when listing at a child resource.
+func scopeFilter(resourceType string) azlist.ResourceFilter {
+	return func(res, extensionRes map[string]any) bool {
+		idRaw, ok := res["id"]
+		if !ok {
+			return false
+		}
+		id := idRaw.(string)
+
+		extIdRaw, ok := extensionRes["id"]
+		if !ok {
+			return false
+		}
+		extId := extIdRaw.(string)
+
+		idx := strings.Index(strings.ToLower(extId), "/providers/"+strings.ToLower(resourceType)+"/")
+		if idx == -1 {
+			return false
+		}
+		scope := extId[:idx]
+
+		return strings.EqualFold(id, scope)
+	}
+}
+
+// AppendLinked appends the resources linked to the listed resources, which are not extension resources in ARM's sense and thus can't be listed by azlist.
+// These include the private endpoint connections and the action groups referenced by the listed resources, and the autoscale settings targeting the listed resources.
+// The listed resources are returned first, followed by the linked resources that are not in the list yet.
+func (b extBuilder) AppendLinked(ctx context.Context, opt azlist.Option, rl []azlist.AzureResource) ([]azlist.AzureResource, error) {
+	var linked []azlist.AzureResource
+	for _, ext := range b.includeExtensions {
+		switch ext {
+		case ExtensionResourceTypePrivateEndpointConnections:
+			for _, res := range rl {
+				props, _ := res.Properties["properties"].(map[string]any)
+				conns, _ := props["privateEndpointConnections"].([]any)
+				for _, conn := range conns {
+					conn, _ := conn.(map[string]any)
+					v, _ := conn["id"].(string)
+					id, err := armid.ParseResourceId(v)
+					if err != nil {
+						continue
 					}
-					scope := lockId[:idx]
+					linked = append(linked, azlist.AzureResource{Id: id, Properties: conn})
+				}
+			}
+		case ExtensionResourceTypeActionGroups:
+			// The action groups are only kept if they are in the resource groups of the listed resources, i.e. within the export scope.
+			// The out of scope ones are left as references, which can be substituted by the data sources (i.e. via "--data-source-for-external-refs").
+			scopes := map[string]bool{}
+			for _, res := range rl {
+				if k, ok := resourceGroupKey(res.Id); ok {
+					scopes[k] = true
+				}
+			}
+			for _, res := range rl {
+				for _, v := range actionGroupIds(res.Properties["properties"]) {
+					id, err := armid.ParseResourceId(v)
+					if err != nil {
+						continue
+					}
+					if k, ok := resourceGroupKey(id); !ok || !scopes[k] {
+						continue
+					}
+					linked = append(linked, azlist.AzureResource{Id: id})
+				}
+			}
+		case ExtensionResourceTypeAutoscaleSettings:
+			targets := map[string]bool{}
+			for _, res := range rl {
+				targets[strings.ToUpper(res.Id.String())] = true
+			}
+			opt.Recursive = false
+			opt.IncludeResourceGroup = false
+			opt.ExtensionResourceTypes = nil
+			opt.ARGTable = ""
+			opt.ARGAuthorizationScopeFilter = ""
+			lister, err := azlist.NewLister(opt)
+			if err != nil {
+				return nil, fmt.Errorf("building azlister for listing autoscale settings: %v", err)
+			}
+			result, err := lister.ListByQuery(ctx, `type =~ "microsoft.insights/autoscalesettings"`)
+			if err != nil {
+				return nil, fmt.Errorf("listing autoscale settings: %w", err)
+			}
+			for _, res := range result.Resources {
+				props, _ := res.Properties["properties"].(map[string]any)
+				target, _ := props["targetResourceUri"].(string)
+				if targets[strings.ToUpper(target)] {
+					linked = append(linked, res)
+				}
+			}
+		}
+	}
 
-					return strings.EqualFold(id, scope)
-				},
-			})
-		case ExtensionResourceTypeDiagnosticSettings:
-			el = append(el, azlist.ExtensionResource{
-				Type: "Microsoft.Insights/diagnosticSettings",
-			})
+	out := rl
+	set := map[string]bool{}
+	for _, res := range rl {
+		set[strings.ToUpper(res.Id.String())] = true
+	}
+	for _, res := range linked {
+		k := strings.ToUpper(res.Id.String())
+		if set[k] {
+			continue
 		}
+		set[k] = true
+		out = append(out, res)
 	}
+	return out, nil
+}
 
-	return el
+// resourceGroupKey returns the upper cased id of the resource group that the resource belongs to, or false if it doesn't belong to any.
+func resourceGroupKey(id armid.ResourceId) (string, bool) {
+	rg, ok := id.RootScope().(*armid.ResourceGroup)
+	if !ok {
+		return "", false
+	}
+	return strings.ToUpper(fmt.Sprintf("/subscriptions/%s/resourceGroups/%s", rg.SubscriptionId, rg.Name)), true
+}
+
+// actionGroupIds returns the action group ids referenced in the resource properties, e.g. by the metric alerts and the activity log alerts.
+func actionGroupIds(v any) []string {
+	var ids []string
+	switch v := v.(type) {
+	case map[string]any:
+		for _, vv := range v {
+			ids = append(ids, actionGroupIds(vv)...)
+		}
+		sort.Strings(ids)
+	case []any:
+		for _, vv := range v {
+			ids = append(ids, actionGroupIds(vv)...)
+		}
+	case string:
+		if strings.Contains(strings.ToLower(v), "/providers/microsoft.insights/actiongroups/") {
+			ids = append(ids, v)
+		}
+	}
+	return ids
 }
@@ -0,0 +1,89 @@
+package meta
+
+import (
+	"context"
+	"testing"
+
+	"github.com/magodo/azlist/azlist"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScopeFilter(t *testing.T) {
+	filter := scopeFilter("Microsoft.Insights/diagnosticSettings")
+	res := map[string]any{"id": "/subscriptions/123/resourceGroups/rg1/providers/Microsoft.KeyVault/vaults/kv1"}
+
+	require.True(t, filter(res, map[string]any{"id": "/subscriptions/123/resourceGroups/rg1/providers/Microsoft.KeyVault/vaults/kv1/providers/microsoft.insights/diagnosticSettings/diag1"}))
+	// Inherited from the parent scope
+	require.False(t, filter(res, map[string]any{"id": "/subscriptions/123/resourceGroups/rg1/providers/Microsoft.Insights/diagnosticSettings/diag1"}))
+	// Different resource type
+	require.False(t, filter(res, map[string]any{"id": "/subscriptions/123/resourceGroups/rg1/providers/Microsoft.KeyVault/vaults/kv1/providers/Microsoft.Authorization/locks/lock1"}))
+	require.False(t, filter(res, map[string]any{}))
+}
+
+func TestExtBuilder_TagsFilter(t *testing.T) {
+	el := extBuilder{includeExtensions: []string{ExtensionResourceTypeTags}}.Build()
+	require.Len(t, el, 1)
+	res := map[string]any{"id": "/subscriptions/123/resourceGroups/rg1"}
+	id := "/subscriptions/123/resourceGroups/rg1/providers/Microsoft.Resources/tags/default"
+	require.True(t, el[0].Filter(res, map[string]any{"id": id, "properties": map[string]any{"tags": map[string]any{"env": "prod"}}}))
+	require.False(t, el[0].Filter(res, map[string]any{"id": id, "properties": map[string]any{"tags": map[string]any{}}}))
+}
+
+func TestExtBuilder_AppendLinked(t *testing.T) {
+	rl := []azlist.AzureResource{
+		{
+			Id: mustParseID(t, "/subscriptions/123/resourceGroups/rg1/providers/Microsoft.KeyVault/vaults/kv1"),
+			Properties: map[string]any{
+				"properties": map[string]any{
+					"privateEndpointConnections": []any{
+						map[string]any{"id": "/subscriptions/123/resourceGroups/rg1/providers/Microsoft.KeyVault/vaults/kv1/privateEndpointConnections/pec1"},
+					},
+				},
+			},
+		},
+		{
+			Id: mustParseID(t, "/subscriptions/123/resourceGroups/rg1/providers/Microsoft.Insights/metricAlerts/alert1"),
+			Properties: map[string]any{
+				"properties": map[string]any{
+					"actions": []any{
+						map[string]any{"actionGroupId": "/subscriptions/123/resourceGroups/rg1/providers/microsoft.insights/actionGroups/ag1"},
+						// Out of the export scope
+						map[string]any{"actionGroupId": "/subscriptions/123/resourceGroups/rg2/providers/microsoft.insights/actionGroups/ag2"},
+					},
+				},
+			},
+		},
+		{
+			Id: mustParseID(t, "/subscriptions/123/resourceGroups/rg1/providers/Microsoft.Insights/activityLogAlerts/alert2"),
+			Properties: map[string]any{
+				"properties": map[string]any{
+					"actions": map[string]any{
+						"actionGroups": []any{
+							// Duplicate of the one referenced by alert1
+							map[string]any{"actionGroupId": "/SUBSCRIPTIONS/123/resourceGroups/RG1/providers/microsoft.insights/actionGroups/AG1"},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	// No linked resource is included by default
+	out, err := extBuilder{}.AppendLinked(context.Background(), azlist.Option{}, rl)
+	require.NoError(t, err)
+	require.Equal(t, rl, out)
+
+	out, err = extBuilder{includeExtensions: []string{ExtensionResourceTypePrivateEndpointConnections, ExtensionResourceTypeActionGroups}}.AppendLinked(context.Background(), azlist.Option{}, rl)
+	require.NoError(t, err)
+	var ids []string
+	for _, res := range out {
+		ids = append(ids, res.Id.String())
+	}
+	require.Equal(t, []string{
+		"/subscriptions/123/resourceGroups/rg1/providers/Microsoft.KeyVault/vaults/kv1",
+		"/subscriptions/123/resourceGroups/rg1/providers/Microsoft.Insights/metricAlerts/alert1",
+		"/subscriptions/123/resourceGroups/rg1/providers/Microsoft.Insights/activityLogAlerts/alert2",
+		"/subscriptions/123/resourceGroups/rg1/providers/Microsoft.KeyVault/vaults/kv1/privateEndpointConnections/pec1",
+		"/subscriptions/123/resourceGroups/rg1/providers/microsoft.insights/actionGroups/ag1",
+	}, ids)
+}
@@ -0,0 +1,152 @@
+package meta
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/Azure/aztfexport/pkg/config"
+	"github.com/magodo/armid"
+)
+
+// layoutFileKeyInvalidChars are the characters that are replaced in the file keys. The dots are also replaced, so that a config file (e.g. "network.tf")
+// never collides with the import block file of another key (e.g. "network.import.tf" of the key "network").
+var layoutFileKeyInvalidChars = regexp.MustCompile(`[^a-z0-9_-]`)
+
+// layoutFileKey returns the raw key (in lower case) of the file that the import item belongs to in the file layout.
+// An empty key means the item belongs to the default file, e.g. "main.tf".
+func layoutFileKey(layout config.FileLayout, item ImportItem) string {
+	var key string
+	switch layout {
+	case config.FileLayoutResourceType:
+		key = item.TFAddr.Type
+	case config.FileLayoutProvider:
+		if item.AzureResourceID != nil {
+			key = strings.TrimPrefix(strings.ToLower(item.AzureResourceID.Provider()), "microsoft.")
+		}
+	case config.FileLayoutResourceGroup:
+		// The resources that are not within a resource group (e.g. subscription level resources) belong to the default file.
+		if item.AzureResourceID != nil {
+			if rg, ok := item.AzureResourceID.RootScope().(*armid.ResourceGroup); ok {
+				key = rg.Name
+			}
+		}
+	}
+	return strings.ToLower(key)
+}
+
+// layoutFileKeys maps the raw keys of the file layout to the file keys, which are sanitized to name the files.
+type layoutFileKeys struct {
+	layout config.FileLayout
+	keys   map[string]string
+}
+
+// newLayoutFileKeys resolves the file keys of the import items, so that each raw key has its own file.
+// The raw keys that collide after the sanitization (e.g. "rg.1" and "rg(1)"), or whose config files collide with the reserved files (e.g. "main" with "main.tf"),
+// are suffixed by "_2", "_3", etc. in the order of the raw keys.
+func newLayoutFileKeys(layout config.FileLayout, l ImportList, mainFileName string, reservedFileNames []string) layoutFileKeys {
+	var rawKeys []string
+	seen := map[string]bool{}
+	for _, item := range l {
+		if key := layoutFileKey(layout, item); key != "" && !seen[key] {
+			seen[key] = true
+			rawKeys = append(rawKeys, key)
+		}
+	}
+	sort.Strings(rawKeys)
+
+	taken := map[string]bool{mainFileName: true}
+	for _, name := range reservedFileNames {
+		taken[name] = true
+	}
+	keys := map[string]string{}
+	for _, rawKey := range rawKeys {
+		base := layoutFileKeyInvalidChars.ReplaceAllString(rawKey, "_")
+		key := base
+		for i := 2; taken[layoutFileName(key, mainFileName)]; i++ {
+			key = fmt.Sprintf("%s_%d", base, i)
+		}
+		taken[layoutFileName(key, mainFileName)] = true
+		keys[rawKey] = key
+	}
+	return layoutFileKeys{layout: layout, keys: keys}
+}
+
+// layoutFileKeys resolves the file keys of the import items, whose config files don't collide with the other generated files.
+func (meta baseMeta) layoutFileKeys(l ImportList) layoutFileKeys {
+	names := meta.outputFileNames
+	return newLayoutFileKeys(meta.fileLayout, l, names.MainFileName, []string{
+		names.TerraformFileName,
+		names.ProviderFileName,
+		names.ImportBlockFileName,
+		names.MovedBlockFileName,
+		names.DataSourceFileName,
+		names.VariableFileName,
+		names.OutputFileName,
+		names.ModuleFileName,
+	})
+}
+
+// key returns the file key of the import item.
+func (k layoutFileKeys) key(item ImportItem) string {
+	rawKey := layoutFileKey(k.layout, item)
+	if key, ok := k.keys[rawKey]; ok {
+		return key
+	}
+	return layoutFileKeyInvalidChars.ReplaceAllString(rawKey, "_")
+}
+
+// layoutFileName returns the name of the config file identified by the key, which takes the extension of the main file name.
+// E.g. the key "network" of the main file name "main.tf" is "network.tf".
+func layoutFileName(key, mainFileName string) string {
+	if key == "" {
+		return mainFileName
+	}
+	ext := filepath.Ext(mainFileName)
+	if strings.HasSuffix(mainFileName, ".tf.json") {
+		ext = ".tf.json"
+	}
+	return key + ext
+}
+
+// layoutImportFileName returns the name of the import block file identified by the key, which is prefixed to the import block file name,
+// so that it is distinguished from the config file of the same key. E.g. the key "network" of the import block file name "import.tf" is "network.import.tf".
+func layoutImportFileName(key, importFileName string) string {
+	if key == "" {
+		return importFileName
+	}
+	return key + "." + importFileName
+}
+
+// partitionConfigInfos partitions the config infos by the file keys of the layout, with the order of the config infos in each partition kept.
+func partitionConfigInfos(fileKeys layoutFileKeys, cfgs ConfigInfos) (keys []string, partitions map[string]ConfigInfos) {
+	partitions = map[string]ConfigInfos{}
+	for _, cfg := range cfgs {
+		key := fileKeys.key(cfg.ImportItem)
+		if _, ok := partitions[key]; !ok {
+			keys = append(keys, key)
+		}
+		partitions[key] = append(partitions[key], cfg)
+	}
+	sort.Strings(keys)
+	return keys, partitions
+}
+
+// partitionImportList partitions the import list by the file keys of the layout, with the order of the items in each partition kept.
+func partitionImportList(fileKeys layoutFileKeys, l ImportList) (keys []string, partitions map[string]ImportList) {
+	partitions = map[string]ImportList{}
+	for _, item := range l {
+		if item.Skip() || item.Managed {
+			continue
+		}
+		key := fileKeys.key(item)
+		if _, ok := partitions[key]; !ok {
+			keys = append(keys, key)
+		}
+		partitions[key] = append(partitions[key], item)
+	}
+	sort.Strings(keys)
+	return keys, partitions
+}
@@ -0,0 +1,107 @@
+package meta
+
+import (
+	"testing"
+
+	"github.com/Azure/aztfexport/internal/tfaddr"
+	"github.com/Azure/aztfexport/pkg/config"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPartitionImportList(t *testing.T) {
+	l := ImportList{
+		{
+			AzureResourceID: mustParseResourceId("/subscriptions/123/resourceGroups/rg1"),
+			TFAddr:          tfaddr.TFAddr{Type: "azurerm_resource_group", Name: "res-0"},
+		},
+		{
+			AzureResourceID: mustParseResourceId("/subscriptions/123/resourceGroups/rg1/providers/Microsoft.Network/virtualNetworks/vnet1"),
+			TFAddr:          tfaddr.TFAddr{Type: "azurerm_virtual_network", Name: "res-1"},
+		},
+		{
+			AzureResourceID: mustParseResourceId("/subscriptions/123/resourceGroups/My.RG(2)/providers/Microsoft.Network/virtualNetworks/vnet2"),
+			TFAddr:          tfaddr.TFAddr{Type: "azurerm_virtual_network", Name: "res-2"},
+		},
+		{
+			AzureResourceID: mustParseResourceId("/subscriptions/123/providers/Microsoft.Authorization/policyDefinitions/def1"),
+			TFAddr:          tfaddr.TFAddr{Type: "azurerm_policy_definition", Name: "res-3"},
+		},
+		{
+			AzureResourceID: mustParseResourceId("/subscriptions/123/resourceGroups/rg1/providers/Microsoft.Foo/foos/foo1"),
+		},
+	}
+
+	cases := []struct {
+		layout config.FileLayout
+		expect map[string][]string
+	}{
+		{
+			layout: config.FileLayoutSingle,
+			expect: map[string][]string{
+				"": {"res-0", "res-1", "res-2", "res-3"},
+			},
+		},
+		{
+			layout: config.FileLayoutResourceType,
+			expect: map[string][]string{
+				"azurerm_resource_group":    {"res-0"},
+				"azurerm_virtual_network":   {"res-1", "res-2"},
+				"azurerm_policy_definition": {"res-3"},
+			},
+		},
+		{
+			layout: config.FileLayoutProvider,
+			expect: map[string][]string{
+				"resources":     {"res-0"},
+				"network":       {"res-1", "res-2"},
+				"authorization": {"res-3"},
+			},
+		},
+		{
+			layout: config.FileLayoutResourceGroup,
+			expect: map[string][]string{
+				"rg1":      {"res-0", "res-1"},
+				"my_rg_2_": {"res-2"},
+				"":         {"res-3"},
+			},
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(string(tt.layout), func(t *testing.T) {
+			keys, partitions := partitionImportList(newLayoutFileKeys(tt.layout, l, "main.tf", nil), l)
+			require.Len(t, keys, len(tt.expect))
+			actual := map[string][]string{}
+			for _, key := range keys {
+				for _, item := range partitions[key] {
+					actual[key] = append(actual[key], item.TFAddr.Name)
+				}
+			}
+			require.Equal(t, tt.expect, actual)
+		})
+	}
+}
+
+func TestLayoutFileKeys(t *testing.T) {
+	var l ImportList
+	for _, rg := range []string{"rg_1", "RG.1", "rg(1)", "main", "Import", "rg2"} {
+		l = append(l, ImportItem{AzureResourceID: mustParseResourceId("/subscriptions/123/resourceGroups/" + rg)})
+	}
+	l = append(l, ImportItem{AzureResourceID: mustParseResourceId("/subscriptions/123/resourceGroups/rg2/providers/Microsoft.Network/virtualNetworks/vnet1")})
+	l = append(l, ImportItem{AzureResourceID: mustParseResourceId("/subscriptions/123/providers/Microsoft.Authorization/policyDefinitions/def1")})
+
+	fileKeys := newLayoutFileKeys(config.FileLayoutResourceGroup, l, "main.tf", []string{"import.tf", "terraform.tf"})
+	var keys []string
+	for _, item := range l {
+		keys = append(keys, fileKeys.key(item))
+	}
+	require.Equal(t, []string{"rg_1_2", "rg_1", "rg_1_", "main_2", "import_2", "rg2", "rg2", ""}, keys)
+}
+
+func TestLayoutFileName(t *testing.T) {
+	require.Equal(t, "main.tf", layoutFileName("", "main.tf"))
+	require.Equal(t, "network.tf", layoutFileName("network", "main.tf"))
+	require.Equal(t, "network.tf.json", layoutFileName("network", "main.aztfexport.tf.json"))
+	require.Equal(t, "import.tf", layoutImportFileName("", "import.tf"))
+	require.Equal(t, "rg1.import.aztfexport.tf.json", layoutImportFileName("rg1", "import.aztfexport.tf.json"))
+}
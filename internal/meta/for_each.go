@@ -0,0 +1,427 @@
+package meta
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/Azure/aztfexport/internal/tfaddr"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/zclconf/go-cty/cty"
+	ctyjson "github.com/zclconf/go-cty/cty/json"
+)
+
+const (
+	// forEachMinGroupSize is the minimum number of homogeneous resources to be consolidated into one for_each resource.
+	forEachMinGroupSize = 3
+	// forEachMaxDiffAttrs is the maximum number of top level attributes that the homogeneous resources can differ in.
+	forEachMaxDiffAttrs = 3
+)
+
+// forEachCandidate is a parsed config that is possible to be consolidated.
+type forEachCandidate struct {
+	idx int
+	// The signature of the config, which is the same for the homogeneous configs.
+	signature string
+	// The literal values of the top level attributes.
+	literals map[string]cty.Value
+}
+
+// consolidateToForEach groups the configs of the same TF resource type, whose HCL only differs in a few top level literal attributes,
+// into a single resource with "for_each" iterating over a generated local map, which holds the differing attributes keyed by the instance key.
+// The references to the grouped resources in all the configs are updated to the instance addresses.
+// In case the state is managed by aztfexport (i.e. not HCL only), "moved" blocks are generated to move the imported resources to the instance addresses.
+func (meta baseMeta) consolidateToForEach(configs ConfigInfos) (ConfigInfos, error) {
+	var groups [][]forEachCandidate
+	groupIdx := map[string]int{}
+	for i, cfg := range configs {
+		c, ok, err := newForEachCandidate(i, cfg)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			continue
+		}
+		// The resources of different modules can't be consolidated together.
+		key := meta.moduleLayout.moduleName(cfg.ImportItem) + "\n" + c.signature
+		idx, ok := groupIdx[key]
+		if !ok {
+			idx = len(groups)
+			groupIdx[key] = idx
+			groups = append(groups, nil)
+		}
+		groups[idx] = append(groups[idx], c)
+	}
+
+	type consolidation struct {
+		members    []forEachCandidate
+		diffAttrs  []string
+		memberKeys []string
+	}
+	var consolidations []consolidation
+	addrMap := map[string]tfaddr.TFAddr{}
+	for _, group := range groups {
+		if len(group) < forEachMinGroupSize {
+			continue
+		}
+		diffAttrs := forEachDiffAttrs(group)
+		if len(diffAttrs) == 0 || len(diffAttrs) > forEachMaxDiffAttrs {
+			continue
+		}
+		keys := forEachKeys(configs, group)
+		leaderAddr := configs[group[0].idx].TFAddr
+		for i, c := range group {
+			addrMap[configs[c.idx].TFAddr.String()] = tfaddr.TFAddr{Type: leaderAddr.Type, Name: leaderAddr.Name, Key: keys[i]}
+		}
+		consolidations = append(consolidations, consolidation{members: group, diffAttrs: diffAttrs, memberKeys: keys})
+	}
+	if len(consolidations) == 0 {
+		return configs, nil
+	}
+
+	// Update the references to the consolidated resources.
+	for i, cfg := range configs {
+		f, err := rewriteResourceReferences(cfg.HCL, addrMap)
+		if err != nil {
+			return nil, fmt.Errorf("updating the references of %s: %v", cfg.TFAddr, err)
+		}
+		cfg.HCL = f
+		cfg.Dependencies = updateDependencyAddrs(cfg.Dependencies, addrMap)
+		configs[i] = cfg
+	}
+
+	toRemove := map[int]bool{}
+	for _, cons := range consolidations {
+		leader := configs[cons.members[0].idx]
+		var (
+			items []ImportItem
+			addrs []tfaddr.TFAddr
+		)
+		for i, c := range cons.members {
+			item := configs[c.idx].ImportItem
+			addrs = append(addrs, item.TFAddr)
+			item.TFAddr = addrMap[item.TFAddr.String()]
+			items = append(items, item)
+			if i != 0 {
+				toRemove[c.idx] = true
+			}
+		}
+
+		var literals []map[string]cty.Value
+		for _, c := range cons.members {
+			literals = append(literals, c.literals)
+		}
+		f, err := meta.buildForEachHCL(leader, cons.diffAttrs, addrs, cons.memberKeys, literals)
+		if err != nil {
+			return nil, fmt.Errorf("consolidating %s: %v", leader.TFAddr, err)
+		}
+		leader.HCL = f
+		leader.ForEach = items
+		configs[cons.members[0].idx] = leader
+	}
+
+	var out ConfigInfos
+	for i, cfg := range configs {
+		if toRemove[i] {
+			continue
+		}
+		out = append(out, cfg)
+	}
+	return out, nil
+}
+
+func newForEachCandidate(idx int, cfg ConfigInfo) (forEachCandidate, bool, error) {
+	src := cfg.HCL.Bytes()
+	file, diags := hclsyntax.ParseConfig(src, "main.tf", hcl.InitialPos)
+	if diags.HasErrors() {
+		return forEachCandidate{}, false, fmt.Errorf("parsing hcl for %s: %v", cfg.AzureResourceID, diags.Error())
+	}
+	blocks := file.Body.(*hclsyntax.Body).Blocks
+	if len(blocks) != 1 || cfg.TFAddr.Key != "" {
+		return forEachCandidate{}, false, nil
+	}
+	body := blocks[0].Body
+	if _, ok := body.Attributes["for_each"]; ok {
+		return forEachCandidate{}, false, nil
+	}
+	if _, ok := body.Attributes["count"]; ok {
+		return forEachCandidate{}, false, nil
+	}
+
+	c := forEachCandidate{idx: idx, literals: map[string]cty.Value{}}
+	var sigs []string
+	for name, attr := range body.Attributes {
+		val, diags := attr.Expr.Value(nil)
+		if !diags.HasErrors() && val.IsWhollyKnown() {
+			c.literals[name] = val
+			sigs = append(sigs, name+"=<literal>")
+			continue
+		}
+		sigs = append(sigs, name+"="+string(attr.Expr.Range().SliceBytes(src)))
+	}
+	sort.Strings(sigs)
+	for _, blk := range body.Blocks {
+		sigs = append(sigs, string(hclwrite.Format(blk.Range().SliceBytes(src))))
+	}
+	c.signature = cfg.TFAddr.Type + "\n" + strings.Join(sigs, "\n")
+	return c, true, nil
+}
+
+// forEachDiffAttrs returns the sorted literal attributes whose values differ among the group.
+func forEachDiffAttrs(group []forEachCandidate) []string {
+	var out []string
+	for name, val := range group[0].literals {
+		b, _ := ctyjson.Marshal(val, val.Type())
+		for _, c := range group[1:] {
+			ov := c.literals[name]
+			ob, _ := ctyjson.Marshal(ov, ov.Type())
+			if string(b) != string(ob) || !val.Type().Equals(ov.Type()) {
+				out = append(out, name)
+				break
+			}
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+// forEachKeys returns the instance keys of the group members. The value of the "name" attribute is used if it is unique among the group,
+// otherwise, the TF resource name is used.
+func forEachKeys(configs ConfigInfos, group []forEachCandidate) []string {
+	keys := make([]string, len(group))
+	seen := map[string]bool{}
+	useName := true
+	for i, c := range group {
+		v, ok := c.literals["name"]
+		if !ok || v.IsNull() || !v.Type().Equals(cty.String) || v.AsString() == "" || seen[v.AsString()] {
+			useName = false
+			break
+		}
+		seen[v.AsString()] = true
+		keys[i] = v.AsString()
+	}
+	if useName {
+		return keys
+	}
+	for i, c := range group {
+		keys[i] = configs[c.idx].TFAddr.Name
+	}
+	return keys
+}
+
+// buildForEachHCL builds the HCL of the for_each resource, based on the leader config, together with the locals block holding the differing attributes
+// and the moved blocks from the original addresses of the members.
+func (meta baseMeta) buildForEachHCL(leader ConfigInfo, diffAttrs []string, addrs []tfaddr.TFAddr, keys []string, literals []map[string]cty.Value) (*hclwrite.File, error) {
+	src := leader.HCL.Bytes()
+	file, diags := hclsyntax.ParseConfig(src, "main.tf", hcl.InitialPos)
+	if diags.HasErrors() {
+		return nil, diags
+	}
+	synBody := file.Body.(*hclsyntax.Body).Blocks[0].Body
+	wBody := leader.HCL.Body().Blocks()[0].Body()
+
+	isDiff := map[string]bool{}
+	for _, attr := range diffAttrs {
+		isDiff[attr] = true
+	}
+
+	localName := leader.TFAddr.Type + "_" + leader.TFAddr.Name
+
+	f := hclwrite.NewFile()
+	body := f.Body()
+
+	// The resource block
+	blk := body.AppendNewBlock("resource", []string{leader.TFAddr.Type, leader.TFAddr.Name})
+	blk.Body().SetAttributeTraversal("for_each", hcl.Traversal{
+		hcl.TraverseRoot{Name: "local"},
+		hcl.TraverseAttr{Name: localName},
+	})
+	var attrNames []string
+	for name := range synBody.Attributes {
+		attrNames = append(attrNames, name)
+	}
+	sort.Slice(attrNames, func(i, j int) bool {
+		return synBody.Attributes[attrNames[i]].SrcRange.Start.Byte < synBody.Attributes[attrNames[j]].SrcRange.Start.Byte
+	})
+	for _, name := range attrNames {
+		if isDiff[name] {
+			blk.Body().SetAttributeTraversal(name, hcl.Traversal{
+				hcl.TraverseRoot{Name: "each"},
+				hcl.TraverseAttr{Name: "value"},
+				hcl.TraverseAttr{Name: name},
+			})
+			continue
+		}
+		blk.Body().SetAttributeRaw(name, wBody.GetAttribute(name).Expr().BuildTokens(nil))
+	}
+	for _, nb := range wBody.Blocks() {
+		blk.Body().AppendNewline()
+		blk.Body().AppendUnstructuredTokens(nb.BuildTokens(nil))
+	}
+
+	// The locals block
+	var elems []hclwrite.ObjectAttrTokens
+	for i, key := range keys {
+		var attrs []hclwrite.ObjectAttrTokens
+		for _, name := range diffAttrs {
+			attrs = append(attrs, hclwrite.ObjectAttrTokens{
+				Name:  hclwrite.TokensForIdentifier(name),
+				Value: hclwrite.TokensForValue(literals[i][name]),
+			})
+		}
+		elems = append(elems, hclwrite.ObjectAttrTokens{
+			Name:  hclwrite.TokensForValue(cty.StringVal(key)),
+			Value: hclwrite.TokensForObject(attrs),
+		})
+	}
+	body.AppendNewline()
+	localsBlk := body.AppendNewBlock("locals", nil)
+	localsBlk.Body().SetAttributeRaw(localName, hclwrite.TokensForObject(elems))
+
+	// The moved blocks
+	if !meta.hclOnly {
+		for i, key := range keys {
+			body.AppendNewline()
+			movedBlk := body.AppendNewBlock("moved", nil)
+			movedBlk.Body().SetAttributeTraversal("from", addrTraversal(addrs[i]))
+			movedBlk.Body().SetAttributeTraversal("to", addrTraversal(tfaddr.TFAddr{Type: leader.TFAddr.Type, Name: leader.TFAddr.Name, Key: key}))
+		}
+	}
+
+	return f, nil
+}
+
+// addrTraversal returns the traversal of the resource address.
+func addrTraversal(addr tfaddr.TFAddr) hcl.Traversal {
+	traversal := hcl.Traversal{
+		hcl.TraverseRoot{Name: addr.Type},
+		hcl.TraverseAttr{Name: addr.Name},
+	}
+	if addr.Key != "" {
+		traversal = append(traversal, hcl.TraverseIndex{Key: cty.StringVal(addr.Key)})
+	}
+	return traversal
+}
+
+// rewriteResourceReferences rewrites the references to the resources (e.g. "azurerm_subnet.res-1.id") in the HCL file,
+// by replacing the resource addresses (e.g. "azurerm_subnet.res-1") as is mapped.
+func rewriteResourceReferences(f *hclwrite.File, addrMap map[string]tfaddr.TFAddr) (*hclwrite.File, error) {
+	src := f.Bytes()
+	file, diags := hclsyntax.ParseConfig(src, "main.tf", hcl.InitialPos)
+	if diags.HasErrors() {
+		return nil, diags
+	}
+
+	type edit struct {
+		start, end int
+		text       string
+	}
+	// The instances can't be depended on individually, the "depends_on" references the whole resource instead.
+	var dependsOnRange hcl.Range
+	if blocks := file.Body.(*hclsyntax.Body).Blocks; len(blocks) != 0 {
+		if attr, ok := blocks[0].Body.Attributes["depends_on"]; ok {
+			dependsOnRange = attr.Expr.Range()
+		}
+	}
+
+	var edits []edit
+	hclsyntax.VisitAll(file.Body.(*hclsyntax.Body), func(node hclsyntax.Node) hcl.Diagnostics {
+		expr, ok := node.(*hclsyntax.ScopeTraversalExpr)
+		if !ok || len(expr.Traversal) < 2 {
+			return nil
+		}
+		attr, ok := expr.Traversal[1].(hcl.TraverseAttr)
+		if !ok {
+			return nil
+		}
+		addr, ok := addrMap[expr.Traversal.RootName()+"."+attr.Name]
+		if !ok {
+			return nil
+		}
+		if dependsOnRange.ContainsOffset(expr.SrcRange.Start.Byte) {
+			addr = addr.ResourceAddr()
+		}
+		edits = append(edits, edit{
+			start: expr.Traversal[0].SourceRange().Start.Byte,
+			end:   attr.SrcRange.End.Byte,
+			text:  addr.String(),
+		})
+		return nil
+	})
+	if len(edits) == 0 {
+		return f, nil
+	}
+
+	sort.Slice(edits, func(i, j int) bool {
+		return edits[i].start > edits[j].start
+	})
+	for _, e := range edits {
+		src = append(src[:e.start:e.start], append([]byte(e.text), src[e.end:]...)...)
+	}
+	nf, diags := hclwrite.ParseConfig(src, "", hcl.InitialPos)
+	if diags.HasErrors() {
+		return nil, diags
+	}
+	return nf, nil
+}
+
+// updateDependencyAddrs returns the dependencies with the TF addresses updated as is mapped.
+func updateDependencyAddrs(deps Dependencies, addrMap map[string]tfaddr.TFAddr) Dependencies {
+	update := func(dep Dependency) Dependency {
+		if addr, ok := addrMap[dep.TFAddr.String()]; ok {
+			dep.TFAddr = addr
+		}
+		return dep
+	}
+	out := Dependencies{
+		ByIdRef:          map[string]Dependency{},
+		ByIdRefAmbiguous: map[string][]Dependency{},
+		ByNameRef:        map[string]Dependency{},
+	}
+	for k, dep := range deps.ByIdRef {
+		out.ByIdRef[k] = update(dep)
+	}
+	for k, ambDeps := range deps.ByIdRefAmbiguous {
+		var l []Dependency
+		for _, dep := range ambDeps {
+			l = append(l, update(dep))
+		}
+		out.ByIdRefAmbiguous[k] = l
+	}
+	for k, dep := range deps.ByNameRef {
+		out.ByNameRef[k] = update(dep)
+	}
+	if deps.ByRgNameRef != nil {
+		dep := update(*deps.ByRgNameRef)
+		out.ByRgNameRef = &dep
+	}
+	if deps.ByRelation != nil {
+		dep := update(*deps.ByRelation)
+		out.ByRelation = &dep
+	}
+	return out
+}
+
+// forEachImportList returns a copy of the import list, with the TF addresses of the items consolidated into the for_each resources
+// updated to the instance addresses. It returns false if there is no for_each resource.
+func forEachImportList(l ImportList, cfgs ConfigInfos) (ImportList, bool) {
+	addrs := map[string]tfaddr.TFAddr{}
+	for _, cfg := range cfgs {
+		for _, item := range cfg.ForEach {
+			addrs[item.AzureResourceID.String()] = item.TFAddr
+		}
+	}
+	if len(addrs) == 0 {
+		return nil, false
+	}
+	out := make(ImportList, len(l))
+	for i, item := range l {
+		if addr, ok := addrs[item.AzureResourceID.String()]; ok {
+			item.TFAddr = addr
+		}
+		out[i] = item
+	}
+	return out, true
+}
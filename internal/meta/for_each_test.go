@@ -0,0 +1,170 @@
+package meta
+
+import (
+	"testing"
+
+	"github.com/Azure/aztfexport/internal/tfaddr"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConsolidateToForEach(t *testing.T) {
+	newSubnet := func(name, addr, prefix string) ConfigInfo {
+		id := "/subscriptions/123/resourceGroups/rg1/providers/Microsoft.Network/virtualNetworks/vnet1/subnets/" + name
+		return newConfigInfo(id, id, addr, `resource "azurerm_subnet" "`+mustParseTFAddr(addr).Name+`" {
+  name                 = "`+name+`"
+  resource_group_name  = "rg1"
+  virtual_network_name = azurerm_virtual_network.res-0.name
+  address_prefixes     = ["`+prefix+`"]
+  depends_on = [
+    azurerm_virtual_network.res-0,
+  ]
+}
+`, nil)
+	}
+	cfgs := ConfigInfos{
+		newConfigInfo(
+			"/subscriptions/123/resourceGroups/rg1/providers/Microsoft.Network/virtualNetworks/vnet1",
+			"/subscriptions/123/resourceGroups/rg1/providers/Microsoft.Network/virtualNetworks/vnet1",
+			"azurerm_virtual_network.res-0",
+			`resource "azurerm_virtual_network" "res-0" {
+  name = "vnet1"
+}
+`,
+			nil,
+		),
+		newSubnet("subnet1", "azurerm_subnet.res-1", "10.0.1.0/24"),
+		newSubnet("subnet2", "azurerm_subnet.res-2", "10.0.2.0/24"),
+		newSubnet("subnet3", "azurerm_subnet.res-3", "10.0.3.0/24"),
+		newConfigInfo(
+			"/subscriptions/123/resourceGroups/rg1/providers/Microsoft.Network/networkInterfaces/nic1",
+			"/subscriptions/123/resourceGroups/rg1/providers/Microsoft.Network/networkInterfaces/nic1",
+			"azurerm_network_interface.res-4",
+			`resource "azurerm_network_interface" "res-4" {
+  name = "nic1"
+  ip_configuration {
+    subnet_id = azurerm_subnet.res-2.id
+  }
+  depends_on = [
+    azurerm_subnet.res-2,
+  ]
+}
+`,
+			&Dependencies{
+				ByIdRef: map[string]Dependency{
+					"subnet2": {TFAddr: mustParseTFAddr("azurerm_subnet.res-2")},
+				},
+			},
+		),
+	}
+
+	cfgs, err := baseMeta{}.consolidateToForEach(cfgs)
+	require.NoError(t, err)
+	require.Len(t, cfgs, 3)
+
+	require.Equal(t, `resource "azurerm_subnet" "res-1" {
+  for_each             = local.azurerm_subnet_res-1
+  name                 = each.value.name
+  resource_group_name  = "rg1"
+  virtual_network_name = azurerm_virtual_network.res-0.name
+  address_prefixes     = each.value.address_prefixes
+  depends_on = [
+    azurerm_virtual_network.res-0,
+  ]
+}
+
+locals {
+  azurerm_subnet_res-1 = {
+    "subnet1" = {
+      address_prefixes = ["10.0.1.0/24"]
+      name             = "subnet1"
+    }
+    "subnet2" = {
+      address_prefixes = ["10.0.2.0/24"]
+      name             = "subnet2"
+    }
+    "subnet3" = {
+      address_prefixes = ["10.0.3.0/24"]
+      name             = "subnet3"
+    }
+  }
+}
+
+moved {
+  from = azurerm_subnet.res-1
+  to   = azurerm_subnet.res-1["subnet1"]
+}
+
+moved {
+  from = azurerm_subnet.res-2
+  to   = azurerm_subnet.res-1["subnet2"]
+}
+
+moved {
+  from = azurerm_subnet.res-3
+  to   = azurerm_subnet.res-1["subnet3"]
+}
+`, string(hclwrite.Format(cfgs[1].HCL.Bytes())))
+	require.Equal(t, []tfaddr.TFAddr{
+		{Type: "azurerm_subnet", Name: "res-1", Key: "subnet1"},
+		{Type: "azurerm_subnet", Name: "res-1", Key: "subnet2"},
+		{Type: "azurerm_subnet", Name: "res-1", Key: "subnet3"},
+	}, []tfaddr.TFAddr{cfgs[1].ForEach[0].TFAddr, cfgs[1].ForEach[1].TFAddr, cfgs[1].ForEach[2].TFAddr})
+
+	require.Equal(t, `resource "azurerm_network_interface" "res-4" {
+  name = "nic1"
+  ip_configuration {
+    subnet_id = azurerm_subnet.res-1["subnet2"].id
+  }
+  depends_on = [
+    azurerm_subnet.res-1,
+  ]
+}
+`, string(hclwrite.Format(cfgs[2].HCL.Bytes())))
+	require.Equal(t, tfaddr.TFAddr{Type: "azurerm_subnet", Name: "res-1", Key: "subnet2"}, cfgs[2].Dependencies.ByIdRef["subnet2"].TFAddr)
+
+	l, ok := forEachImportList(ImportList{cfgs[0].ImportItem, {AzureResourceID: cfgs[1].ForEach[2].AzureResourceID, TFResourceId: cfgs[1].ForEach[2].TFResourceId, TFAddr: mustParseTFAddr("azurerm_subnet.res-3")}}, cfgs)
+	require.True(t, ok)
+	require.Equal(t, `azurerm_subnet.res-1["subnet3"]`, l[1].TFAddr.String())
+	require.Equal(t, `import {
+  id = "/subscriptions/123/resourceGroups/rg1/providers/Microsoft.Network/virtualNetworks/vnet1/subnets/subnet3"
+  to = azurerm_subnet.res-1["subnet3"]
+}
+`, string(hclwrite.Format(hclFormatter{}.ImportBlocks(baseMeta{}.importBlocks(l[1:])))))
+
+	f, err := outputsFile(cfgs[1:2], nil)
+	require.NoError(t, err)
+	require.Equal(t, `output "azurerm_subnet_res-1_id" {
+  value = { for k, v in azurerm_subnet.res-1 : k => v.id }
+}
+`, string(hclwrite.Format(f.Bytes())))
+
+	b, err := jsonFormatter{}.Config(cfgs[1:2])
+	require.NoError(t, err)
+	require.JSONEq(t, `{
+  "resource": {
+    "azurerm_subnet": {
+      "res-1": {
+        "for_each": "${local.azurerm_subnet_res-1}",
+        "name": "${each.value.name}",
+        "resource_group_name": "rg1",
+        "virtual_network_name": "${azurerm_virtual_network.res-0.name}",
+        "address_prefixes": "${each.value.address_prefixes}",
+        "depends_on": ["azurerm_virtual_network.res-0"]
+      }
+    }
+  },
+  "locals": {
+    "azurerm_subnet_res-1": {
+      "subnet1": {"address_prefixes": ["10.0.1.0/24"], "name": "subnet1"},
+      "subnet2": {"address_prefixes": ["10.0.2.0/24"], "name": "subnet2"},
+      "subnet3": {"address_prefixes": ["10.0.3.0/24"], "name": "subnet3"}
+    }
+  },
+  "moved": [
+    {"from": "azurerm_subnet.res-1", "to": "azurerm_subnet.res-1[\"subnet1\"]"},
+    {"from": "azurerm_subnet.res-2", "to": "azurerm_subnet.res-1[\"subnet2\"]"},
+    {"from": "azurerm_subnet.res-3", "to": "azurerm_subnet.res-1[\"subnet3\"]"}
+  ]
+}`, string(b))
+}
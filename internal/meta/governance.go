@@ -0,0 +1,75 @@
+package meta
+
+import (
+	"strings"
+
+	"github.com/Azure/aztfexport/internal/resourceset"
+)
+
+// argQuery is an ARG query of the resources in the table, which are matched by the predicate.
+type argQuery struct {
+	table     string
+	predicate string
+}
+
+// subscriptionGovernanceQueries are the ARG queries for the governance objects defined at the subscription scope, or at the resource group scope of the subscription.
+// The built-in policy (set) definitions and role definitions are excluded, as they are not managed by the user.
+var subscriptionGovernanceQueries = []argQuery{
+	{
+		table:     "PolicyResources",
+		predicate: `type in~ ("microsoft.authorization/policydefinitions", "microsoft.authorization/policysetdefinitions") and properties.policyType =~ "Custom" and id startswith "/subscriptions/"`,
+	},
+	{
+		// The policy assignments and exemptions inherited from the management groups are excluded.
+		table:     "PolicyResources",
+		predicate: `type in~ ("microsoft.authorization/policyassignments", "microsoft.authorization/policyexemptions") and id startswith "/subscriptions/"`,
+	},
+	{
+		table:     "AuthorizationResources",
+		predicate: `type =~ "microsoft.authorization/roledefinitions" and properties.type =~ "CustomRole"`,
+	},
+	{
+		// The role assignments at the resource scope are exported as the extension resources (i.e. via "--include-extension").
+		table:     "AuthorizationResources",
+		predicate: `type =~ "microsoft.authorization/roleassignments" and tostring(properties.scope) matches regex @"(?i)^/subscriptions/[^/]+(/resourcegroups/[^/]+)?$"`,
+	},
+}
+
+// managementGroupGovernanceQueries are the ARG queries for the governance objects defined at the management group scopes.
+// These queries are expected to run against the management group with the "AtScopeAndBelow" authorization scope filter,
+// so that the objects of its descendant management groups are included, while the ones of the subscriptions are excluded by the predicates.
+var managementGroupGovernanceQueries = []argQuery{
+	{
+		table:     "PolicyResources",
+		predicate: `type in~ ("microsoft.authorization/policydefinitions", "microsoft.authorization/policysetdefinitions") and properties.policyType =~ "Custom" and id startswith "/providers/Microsoft.Management/managementGroups/"`,
+	},
+	{
+		table:     "PolicyResources",
+		predicate: `type in~ ("microsoft.authorization/policyassignments", "microsoft.authorization/policyexemptions") and id startswith "/providers/Microsoft.Management/managementGroups/"`,
+	},
+	{
+		// The custom role definitions that are assignable at any of the management groups, while the ones only assignable at the subscriptions are queried per subscription.
+		table:     "AuthorizationResources",
+		predicate: `type =~ "microsoft.authorization/roledefinitions" and properties.type =~ "CustomRole" and tostring(properties.assignableScopes) contains "/providers/Microsoft.Management/managementGroups/"`,
+	},
+	{
+		table:     "AuthorizationResources",
+		predicate: `type =~ "microsoft.authorization/roleassignments" and tostring(properties.scope) startswith "/providers/Microsoft.Management/managementGroups/"`,
+	},
+}
+
+// dedupResources removes the resources whose ids (case insensitively) appear earlier in the list.
+// The governance objects can also be listed as the extension resources of the exported resources (e.g. the role assignments of a resource group).
+func dedupResources(rl []resourceset.AzureResource) []resourceset.AzureResource {
+	var out []resourceset.AzureResource
+	set := map[string]bool{}
+	for _, res := range rl {
+		k := strings.ToUpper(res.Id.String())
+		if set[k] {
+			continue
+		}
+		set[k] = true
+		out = append(out, res)
+	}
+	return out
+}
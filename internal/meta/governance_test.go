@@ -0,0 +1,18 @@
+package meta
+
+import (
+	"testing"
+
+	"github.com/Azure/aztfexport/internal/resourceset"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDedupResources(t *testing.T) {
+	rl := []resourceset.AzureResource{
+		{Id: mustParseID(t, "/subscriptions/123/resourceGroups/rg1/providers/Microsoft.Authorization/roleAssignments/ra1")},
+		{Id: mustParseID(t, "/subscriptions/123/providers/Microsoft.Authorization/policyAssignments/pa1")},
+		{Id: mustParseID(t, "/subscriptions/123/resourcegroups/RG1/providers/Microsoft.Authorization/roleAssignments/ra1")},
+		{Id: mustParseID(t, "/subscriptions/123/resourceGroups/rg1")},
+	}
+	require.Equal(t, []resourceset.AzureResource{rl[0], rl[1], rl[3]}, dedupResources(rl))
+}
@@ -1,6 +1,9 @@
 package meta
 
 import (
+	"strings"
+
+	"github.com/Azure/aztfexport/internal/resmap"
 	"github.com/Azure/aztfexport/internal/tfaddr"
 	"github.com/magodo/armid"
 	"github.com/zclconf/go-cty/cty"
@@ -18,6 +21,9 @@ type ImportItem struct {
 	// Whether this azure resource has been successfully imported
 	Imported bool
 
+	// Whether this azure resource is already managed by the existing state, which won't be imported again
+	Managed bool
+
 	// Whether this azure resource failed to validate into terraform (tbh, this should reside in UI layer only)
 	ValidateError error
 
@@ -74,6 +80,19 @@ func (l ImportList) ImportErrored() ImportList {
 	return out
 }
 
+// ResourceMapping returns the resource mapping of the non-skipped items.
+func (l ImportList) ResourceMapping() resmap.ResourceMapping {
+	m := resmap.ResourceMapping{}
+	for _, item := range l.NonSkipped() {
+		m[item.AzureResourceID.String()] = resmap.ResourceMapEntity{
+			ResourceId:   item.TFResourceId,
+			ResourceType: item.TFAddr.Type,
+			ResourceName: item.TFAddr.Name,
+		}
+	}
+	return m
+}
+
 func (l ImportList) Imported() ImportList {
 	var out ImportList
 	for _, item := range l {
@@ -83,3 +102,16 @@ func (l ImportList) Imported() ImportList {
 	}
 	return out
 }
+
+// hasSharedTFResourceId returns whether any TF resource id (case insensitively) is shared by multiple items.
+func (l ImportList) hasSharedTFResourceId() bool {
+	set := map[string]bool{}
+	for _, item := range l {
+		k := strings.ToUpper(item.TFResourceId)
+		if set[k] {
+			return true
+		}
+		set[k] = true
+	}
+	return false
+}
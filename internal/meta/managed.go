@@ -0,0 +1,130 @@
+package meta
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/Azure/aztfexport/internal/tfaddr"
+)
+
+// rawState is the subset of the Terraform state file (format version 4) that is needed to tell the resources managed by it.
+type rawState struct {
+	Resources []rawStateResource `json:"resources"`
+}
+
+type rawStateResource struct {
+	Module    string                     `json:"module"`
+	Mode      string                     `json:"mode"`
+	Type      string                     `json:"type"`
+	Name      string                     `json:"name"`
+	Instances []rawStateResourceInstance `json:"instances"`
+}
+
+type rawStateResourceInstance struct {
+	IndexKey   any            `json:"index_key"`
+	Attributes map[string]any `json:"attributes"`
+}
+
+// managedResource is a TF resource instance managed by the state.
+type managedResource struct {
+	// The module address of the resource, e.g. "module.rg1". This is empty for the root module.
+	ModuleAddr string
+	// The TF address of the resource. This is empty for the indexed resource instance, which can't be addressed by an import item.
+	TFAddr tfaddr.TFAddr
+}
+
+// managedResources returns the TF resources managed by the state, keyed by the TF resource id in uppercase.
+func managedResources(state []byte) (map[string]managedResource, error) {
+	out := map[string]managedResource{}
+	if len(state) == 0 {
+		return out, nil
+	}
+
+	var st rawState
+	if err := json.Unmarshal(state, &st); err != nil {
+		return nil, fmt.Errorf("unmarshalling the state: %v", err)
+	}
+	for _, res := range st.Resources {
+		if res.Mode != "managed" {
+			continue
+		}
+		for _, ins := range res.Instances {
+			id, ok := ins.Attributes["id"].(string)
+			if !ok || id == "" {
+				continue
+			}
+			mres := managedResource{ModuleAddr: res.Module}
+			if ins.IndexKey == nil {
+				mres.TFAddr = tfaddr.TFAddr{Type: res.Type, Name: res.Name}
+			}
+			out[strings.ToUpper(id)] = mres
+		}
+	}
+	return out, nil
+}
+
+// markManagedImportList marks the import items that are already managed by the base state of the workspace, matched by the TF resource id.
+// The managed items take the TF address from the state if it is in the same module, while the other items are renamed if their addresses conflict with the existing ones.
+func (meta baseMeta) markManagedImportList(l ImportList) (ImportList, error) {
+	// The base state might have been replaced by the checkpoint snapshot of a previous run when resuming,
+	// whose items are imported by this export. Hence only the state pulled from the workspace is regarded.
+	managed, err := managedResources(meta.originBaseState)
+	if err != nil {
+		return nil, fmt.Errorf("reading the managed resources from the base state: %v", err)
+	}
+	if len(managed) == 0 {
+		return l, nil
+	}
+
+	// The addresses are qualified by the module addresses, as the items can belong to different modules of the module layout.
+	qualifiedAddr := func(moduleAddr string, addr tfaddr.TFAddr) string {
+		return moduleAddr + "|" + addr.String()
+	}
+
+	existingAddrs := map[string]bool{}
+	for _, mres := range managed {
+		if mres.TFAddr.Type != "" {
+			existingAddrs[qualifiedAddr(mres.ModuleAddr, mres.TFAddr)] = true
+		}
+	}
+
+	usedAddrs := map[string]bool{}
+	for addr := range existingAddrs {
+		usedAddrs[addr] = true
+	}
+
+	for i, item := range l {
+		moduleAddr := meta.resourceModuleAddr(item)
+		mres, ok := managed[strings.ToUpper(item.TFResourceId)]
+		if !ok {
+			usedAddrs[qualifiedAddr(moduleAddr, item.TFAddr)] = true
+			continue
+		}
+		meta.Logger().Info("Resource is already managed by the state", "tf_id", item.TFResourceId, "module", mres.ModuleAddr, "tf_addr", mres.TFAddr)
+		item.Managed = true
+		if mres.ModuleAddr == moduleAddr && mres.TFAddr.Type != "" {
+			item.TFAddr = mres.TFAddr
+			item.TFAddrCache = mres.TFAddr
+		}
+		l[i] = item
+	}
+
+	for i, item := range l {
+		moduleAddr := meta.resourceModuleAddr(item)
+		if item.Managed || item.Skip() || !existingAddrs[qualifiedAddr(moduleAddr, item.TFAddr)] {
+			continue
+		}
+		addr := item.TFAddr
+		for n := 2; usedAddrs[qualifiedAddr(moduleAddr, addr)]; n++ {
+			addr.Name = fmt.Sprintf("%s_%d", item.TFAddr.Name, n)
+		}
+		meta.Logger().Info("Rename resource as its address conflicts with an existing one", "tf_id", item.TFResourceId, "tf_addr", item.TFAddr, "new_tf_addr", addr)
+		usedAddrs[qualifiedAddr(moduleAddr, addr)] = true
+		item.TFAddr = addr
+		item.TFAddrCache = addr
+		l[i] = item
+	}
+
+	return l, nil
+}
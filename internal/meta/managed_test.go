@@ -0,0 +1,137 @@
+package meta
+
+import (
+	"log/slog"
+	"testing"
+
+	"github.com/Azure/aztfexport/internal/tfaddr"
+	"github.com/Azure/aztfexport/pkg/config"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMarkManagedImportList(t *testing.T) {
+	state := `{
+  "version": 4,
+  "resources": [
+    {
+      "mode": "managed",
+      "type": "azurerm_resource_group",
+      "name": "rg",
+      "instances": [{"attributes": {"id": "/subscriptions/123/resourceGroups/RG1"}}]
+    },
+    {
+      "mode": "managed",
+      "type": "azurerm_virtual_network",
+      "name": "res-1",
+      "instances": [{"attributes": {"id": "/subscriptions/123/resourceGroups/rg1/providers/Microsoft.Network/virtualNetworks/vnet2"}}]
+    },
+    {
+      "mode": "data",
+      "type": "azurerm_virtual_network",
+      "name": "vnet",
+      "instances": [{"attributes": {"id": "/subscriptions/123/resourceGroups/rg1/providers/Microsoft.Network/virtualNetworks/vnet3"}}]
+    }
+  ]
+}`
+
+	l := ImportList{
+		{
+			AzureResourceID: mustParseResourceId("/subscriptions/123/resourceGroups/rg1"),
+			TFResourceId:    "/subscriptions/123/resourceGroups/rg1",
+			TFAddr:          tfaddr.TFAddr{Type: "azurerm_resource_group", Name: "res-0"},
+		},
+		{
+			AzureResourceID: mustParseResourceId("/subscriptions/123/resourceGroups/rg1/providers/Microsoft.Network/virtualNetworks/vnet1"),
+			TFResourceId:    "/subscriptions/123/resourceGroups/rg1/providers/Microsoft.Network/virtualNetworks/vnet1",
+			TFAddr:          tfaddr.TFAddr{Type: "azurerm_virtual_network", Name: "res-1"},
+		},
+		{
+			AzureResourceID: mustParseResourceId("/subscriptions/123/resourceGroups/rg1/providers/Microsoft.Network/virtualNetworks/vnet3"),
+			TFResourceId:    "/subscriptions/123/resourceGroups/rg1/providers/Microsoft.Network/virtualNetworks/vnet3",
+			TFAddr:          tfaddr.TFAddr{Type: "azurerm_virtual_network", Name: "res-1_2"},
+		},
+	}
+
+	meta := baseMeta{
+		logger:          slog.New(slog.DiscardHandler),
+		originBaseState: []byte(state),
+	}
+	l, err := meta.markManagedImportList(l)
+	require.NoError(t, err)
+
+	require.True(t, l[0].Managed)
+	require.Equal(t, tfaddr.TFAddr{Type: "azurerm_resource_group", Name: "rg"}, l[0].TFAddr)
+
+	require.False(t, l[1].Managed)
+	require.Equal(t, tfaddr.TFAddr{Type: "azurerm_virtual_network", Name: "res-1_3"}, l[1].TFAddr)
+	require.Equal(t, l[1].TFAddr, l[1].TFAddrCache)
+
+	require.False(t, l[2].Managed)
+	require.Equal(t, tfaddr.TFAddr{Type: "azurerm_virtual_network", Name: "res-1_2"}, l[2].TFAddr)
+
+	// No-op for an empty state
+	meta.originBaseState = nil
+	nl := ImportList{{TFResourceId: "foo", TFAddr: tfaddr.TFAddr{Type: "azurerm_resource_group", Name: "res-0"}}}
+	nl, err = meta.markManagedImportList(nl)
+	require.NoError(t, err)
+	require.False(t, nl[0].Managed)
+}
+
+func TestMarkManagedImportList_ModuleLayout(t *testing.T) {
+	state := `{
+  "version": 4,
+  "resources": [
+    {
+      "module": "module.rg1",
+      "mode": "managed",
+      "type": "azurerm_resource_group",
+      "name": "rg",
+      "instances": [{"attributes": {"id": "/subscriptions/123/resourceGroups/rg1"}}]
+    },
+    {
+      "module": "module.rg1",
+      "mode": "managed",
+      "type": "azurerm_virtual_network",
+      "name": "res-1",
+      "instances": [{"attributes": {"id": "/subscriptions/123/resourceGroups/rg1/providers/Microsoft.Network/virtualNetworks/vnet2"}}]
+    }
+  ]
+}`
+
+	l := ImportList{
+		{
+			AzureResourceID: mustParseResourceId("/subscriptions/123/resourceGroups/rg1"),
+			TFResourceId:    "/subscriptions/123/resourceGroups/rg1",
+			TFAddr:          tfaddr.TFAddr{Type: "azurerm_resource_group", Name: "res-0"},
+		},
+		{
+			AzureResourceID: mustParseResourceId("/subscriptions/123/resourceGroups/rg1/providers/Microsoft.Network/virtualNetworks/vnet1"),
+			TFResourceId:    "/subscriptions/123/resourceGroups/rg1/providers/Microsoft.Network/virtualNetworks/vnet1",
+			TFAddr:          tfaddr.TFAddr{Type: "azurerm_virtual_network", Name: "res-1"},
+		},
+		{
+			AzureResourceID: mustParseResourceId("/subscriptions/123/resourceGroups/rg2/providers/Microsoft.Network/virtualNetworks/vnet3"),
+			TFResourceId:    "/subscriptions/123/resourceGroups/rg2/providers/Microsoft.Network/virtualNetworks/vnet3",
+			TFAddr:          tfaddr.TFAddr{Type: "azurerm_virtual_network", Name: "res-1"},
+		},
+	}
+
+	meta := baseMeta{
+		logger:          slog.New(slog.DiscardHandler),
+		originBaseState: []byte(state),
+		moduleLayout:    moduleLayout{layout: config.ModuleLayoutResourceGroup},
+	}
+	l, err := meta.markManagedImportList(l)
+	require.NoError(t, err)
+
+	require.True(t, l[0].Managed)
+	require.Equal(t, tfaddr.TFAddr{Type: "azurerm_resource_group", Name: "rg"}, l[0].TFAddr)
+
+	// Conflicts with the existing address in the same module
+	require.False(t, l[1].Managed)
+	require.Equal(t, tfaddr.TFAddr{Type: "azurerm_virtual_network", Name: "res-1_2"}, l[1].TFAddr)
+
+	// No conflict as it belongs to another module
+	require.False(t, l[2].Managed)
+	require.Equal(t, tfaddr.TFAddr{Type: "azurerm_virtual_network", Name: "res-1"}, l[2].TFAddr)
+}
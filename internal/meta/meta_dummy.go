@@ -108,6 +108,13 @@ func (m MetaGroupDummy) WriteSkippedResources(_ context.Context, l ImportList) e
 	return nil
 }
 
+func (m *MetaGroupDummy) GetAmbiguousDependencies(_ context.Context, l ImportList) ([]AmbiguousDependency, error) {
+	return nil, nil
+}
+
+func (m *MetaGroupDummy) SetDependencyResolutions(r DependencyResolutions) {
+}
+
 func (m MetaGroupDummy) CleanUpWorkspace(_ context.Context) error {
 	time.Sleep(500 * time.Millisecond)
 	return nil
@@ -0,0 +1,185 @@
+package meta
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/Azure/aztfexport/internal/client"
+	"github.com/Azure/aztfexport/internal/resourceset"
+	"github.com/Azure/aztfexport/pkg/config"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/resourcegraph/armresourcegraph"
+	"github.com/magodo/armid"
+	"github.com/magodo/azlist/azlist"
+)
+
+type MetaKQL struct {
+	MetaQuery
+	argQuery string
+}
+
+func NewMetaKQL(cfg config.Config) (*MetaKQL, error) {
+	cfg.Logger.Info("New KQL meta")
+	metaQuery, err := NewMetaQuery(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return &MetaKQL{
+		MetaQuery: *metaQuery,
+		argQuery:  cfg.ARGQuery,
+	}, nil
+}
+
+// ReadKQLQuery returns the KQL query, which is read from the file if the query is a path to a ".kql" file.
+func ReadKQLQuery(query string) (string, error) {
+	if !strings.EqualFold(filepath.Ext(query), ".kql") {
+		return query, nil
+	}
+	// #nosec G304
+	b, err := os.ReadFile(query)
+	if err != nil {
+		return "", fmt.Errorf("reading KQL file %s: %v", query, err)
+	}
+	return string(b), nil
+}
+
+func (meta MetaKQL) ScopeName() string {
+	msg := strings.Join(strings.Fields(meta.argQuery), " ")
+	if meta.recursiveQuery {
+		msg += " (recursive)"
+	}
+	return msg
+}
+
+func (meta *MetaKQL) ListResource(ctx context.Context) (ImportList, error) {
+	meta.Logger().Debug("Query resource set by KQL")
+	rset, err := meta.queryResourceSetByKQL(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return meta.resourceSetToImportList(rset)
+}
+
+// queryResourceSetByKQL runs the KQL query against the subscriptions to query, and lists the resources identified by the "id" column of the result.
+func (meta MetaKQL) queryResourceSetByKQL(ctx context.Context) (*resourceset.AzureResourceSet, error) {
+	b := client.ClientBuilder{
+		Credential: meta.azureSDKCred,
+		Opt:        meta.azureSDKClientOpt,
+	}
+	graphClient, err := b.NewResourceGraphClient()
+	if err != nil {
+		return nil, fmt.Errorf("new resource graph client: %v", err)
+	}
+
+	var subscriptions []*string
+	for _, id := range meta.querySubscriptionIds {
+		subscriptions = append(subscriptions, ptr(id))
+	}
+	req := armresourcegraph.QueryRequest{
+		Query:         &meta.argQuery,
+		Subscriptions: subscriptions,
+	}
+	if meta.argAuthenticationScopeFilter != "" {
+		req.Options = &armresourcegraph.QueryRequestOptions{
+			AuthorizationScopeFilter: ptr(meta.argAuthenticationScopeFilter),
+		}
+	}
+	rows, err := queryResourceGraph(ctx, graphClient, req)
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		if _, ok := row["id"]; !ok {
+			return nil, fmt.Errorf(`the KQL query result has no "id" column, make sure to "project id"`)
+		}
+	}
+	ids, err := rowsResourceIds(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	// The resources are grouped by subscription, which are then listed by azlist per subscription.
+	var subscriptionIds []string
+	idsBySubscription := map[string][]armid.ResourceId{}
+	// The tags are available in case the query projects the "tags" column.
+	tagsById := map[string]map[string]string{}
+	for i, id := range ids {
+		key := strings.ToUpper(id.String())
+		if _, ok := tagsById[key]; ok {
+			continue
+		}
+		tagsById[key] = resourceTags(rows[i])
+
+		var subscriptionId string
+		if sub, ok := id.RootScope().(*armid.SubscriptionId); ok {
+			subscriptionId = sub.Id
+		} else if rg, ok := id.RootScope().(*armid.ResourceGroup); ok {
+			subscriptionId = rg.SubscriptionId
+		} else {
+			return nil, fmt.Errorf("resource %s is not within a subscription", id)
+		}
+		if _, ok := idsBySubscription[subscriptionId]; !ok {
+			subscriptionIds = append(subscriptionIds, subscriptionId)
+		}
+		idsBySubscription[subscriptionId] = append(idsBySubscription[subscriptionId], id)
+	}
+
+	var rl []resourceset.AzureResource
+	listedSet := map[string]bool{}
+	for _, subscriptionId := range subscriptionIds {
+		// In case only the resulting resources are to list, no need to call azlist as the resource ids are already provided.
+		if !meta.recursiveQuery && len(meta.includeExtensions) == 0 && !meta.includeManagedResource && !meta.includeResourceGroup {
+			for _, id := range idsBySubscription[subscriptionId] {
+				rl = append(rl, resourceset.AzureResource{
+					Id:   id,
+					Tags: tagsById[strings.ToUpper(id.String())],
+				})
+			}
+			continue
+		}
+
+		var ids []string
+		for _, id := range idsBySubscription[subscriptionId] {
+			ids = append(ids, id.String())
+		}
+
+		opt := azlist.Option{
+			Logger:                 meta.logger.WithGroup("azlist"),
+			SubscriptionId:         subscriptionId,
+			Cred:                   meta.azureSDKCred,
+			ClientOpt:              meta.azureSDKClientOpt,
+			Parallelism:            meta.parallelism,
+			Recursive:              meta.recursiveQuery,
+			IncludeResourceGroup:   meta.includeResourceGroup,
+			ExtensionResourceTypes: extBuilder{includeExtensions: meta.includeExtensions}.Build(),
+			IncludeManaged:         meta.includeManagedResource,
+		}
+		lister, err := azlist.NewLister(opt)
+		if err != nil {
+			return nil, fmt.Errorf("building azlister for subscription %s: %v", subscriptionId, err)
+		}
+		result, err := lister.ListByIds(ctx, ids)
+		if err != nil {
+			return nil, fmt.Errorf("listing resources of subscription %s: %w", subscriptionId, err)
+		}
+		listed, err := extBuilder{includeExtensions: meta.includeExtensions}.AppendLinked(ctx, opt, result.Resources)
+		if err != nil {
+			return nil, fmt.Errorf("listing linked resources of subscription %s: %w", subscriptionId, err)
+		}
+		for _, res := range listed {
+			key := strings.ToUpper(res.Id.String())
+			if listedSet[key] {
+				continue
+			}
+			listedSet[key] = true
+			rl = append(rl, resourceset.AzureResource{
+				Id:   res.Id,
+				Tags: resourceTags(res.Properties),
+			})
+		}
+	}
+
+	return &resourceset.AzureResourceSet{Resources: rl}, nil
+}
@@ -0,0 +1,26 @@
+package meta
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadKQLQuery(t *testing.T) {
+	query := `Resources | where type =~ "microsoft.network/networkinterfaces" | project id`
+
+	out, err := ReadKQLQuery(query)
+	require.NoError(t, err)
+	require.Equal(t, query, out)
+
+	path := filepath.Join(t.TempDir(), "nics.kql")
+	require.NoError(t, os.WriteFile(path, []byte(query), 0644))
+	out, err = ReadKQLQuery(path)
+	require.NoError(t, err)
+	require.Equal(t, query, out)
+
+	_, err = ReadKQLQuery(filepath.Join(t.TempDir(), "notexist.kql"))
+	require.ErrorContains(t, err, "reading KQL file")
+}
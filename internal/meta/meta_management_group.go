@@ -0,0 +1,188 @@
+package meta
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Azure/aztfexport/internal/client"
+	"github.com/Azure/aztfexport/internal/resourceset"
+	"github.com/Azure/aztfexport/pkg/config"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/resourcegraph/armresourcegraph"
+	"github.com/magodo/armid"
+)
+
+type MetaManagementGroup struct {
+	MetaQuery
+	managementGroup string
+	governanceOnly  bool
+}
+
+func NewMetaManagementGroup(cfg config.Config) (*MetaManagementGroup, error) {
+	cfg.Logger.Info("New management group meta")
+	// The resources of the subscriptions other than the one in use are managed by the aliased provider configs, which are only supported in the root module.
+	if cfg.ModulePath != "" {
+		return nil, fmt.Errorf("management group can't be used together with module path")
+	}
+	if cfg.ModuleLayout != "" && cfg.ModuleLayout != config.ModuleLayoutNone {
+		return nil, fmt.Errorf("management group can't be used together with module layout")
+	}
+	cfg.ARGPredicate = scopeQueryPredicate
+	cfg.RecursiveQuery = true
+	cfg.ARGTable = ""
+	// The resource groups are queried as the subscription level resources.
+	cfg.IncludeResourceGroup = false
+	cfg.ARGAuthorizationScopeFilter = string(armresourcegraph.AuthorizationScopeFilterAtScopeAndBelow)
+	metaQuery, err := NewMetaQuery(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return &MetaManagementGroup{
+		MetaQuery:       *metaQuery,
+		managementGroup: cfg.ManagementGroupName,
+		governanceOnly:  cfg.GovernanceOnly,
+	}, nil
+}
+
+func (meta MetaManagementGroup) ScopeName() string {
+	return "management group " + meta.managementGroup
+}
+
+func (meta *MetaManagementGroup) ListResource(ctx context.Context) (ImportList, error) {
+	b := client.ClientBuilder{
+		Credential: meta.azureSDKCred,
+		Opt:        meta.azureSDKClientOpt,
+	}
+	graphClient, err := b.NewResourceGraphClient()
+	if err != nil {
+		return nil, fmt.Errorf("new resource graph client: %v", err)
+	}
+
+	meta.Logger().Debug("Query subscriptions of the management group")
+	subscriptionIds, err := meta.listSubscriptions(ctx, graphClient)
+	if err != nil {
+		return nil, err
+	}
+	if len(subscriptionIds) == 0 {
+		return nil, fmt.Errorf("no subscription found under management group %s", meta.managementGroup)
+	}
+	meta.querySubscriptionIds = subscriptionIds
+
+	rset := &resourceset.AzureResourceSet{}
+	if !meta.governanceOnly {
+		meta.Logger().Debug("Query resource set")
+		rset, err = meta.queryResourceSet(ctx, meta.argPredicate, meta.recursiveQuery)
+		if err != nil {
+			return nil, err
+		}
+	}
+	meta.Logger().Debug("Query subscription level resource set")
+	rl, err := meta.querySubscriptionLevelResources(ctx, !meta.governanceOnly)
+	if err != nil {
+		return nil, err
+	}
+	meta.Logger().Debug("Query management group level resource set")
+	mgrl, err := meta.queryManagementGroupLevelResources(ctx, graphClient)
+	if err != nil {
+		return nil, err
+	}
+	rset.Resources = dedupResources(append(append(mgrl, rl...), rset.Resources...))
+	return meta.resourceSetToImportList(rset)
+}
+
+// listSubscriptions lists the subscriptions under the management group, including the ones under its descendant management groups.
+func (meta MetaManagementGroup) listSubscriptions(ctx context.Context, graphClient *armresourcegraph.Client) ([]string, error) {
+	query := `ResourceContainers | where type =~ "microsoft.resources/subscriptions" | project subscriptionId | order by subscriptionId asc`
+	rows, err := queryResourceGraph(ctx, graphClient, armresourcegraph.QueryRequest{
+		Query:            &query,
+		ManagementGroups: []*string{&meta.managementGroup},
+		Options: &armresourcegraph.QueryRequestOptions{
+			AuthorizationScopeFilter: ptr(armresourcegraph.AuthorizationScopeFilterAtScopeAndBelow),
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("listing subscriptions of management group %s: %v", meta.managementGroup, err)
+	}
+	var ids []string
+	for _, row := range rows {
+		if id, ok := row["subscriptionId"].(string); ok && id != "" {
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
+
+// queryManagementGroupLevelResources queries the resources that are defined at the management group scopes, i.e. the governance objects of the management group
+// and its descendant management groups.
+func (meta MetaManagementGroup) queryManagementGroupLevelResources(ctx context.Context, graphClient *armresourcegraph.Client) ([]resourceset.AzureResource, error) {
+	var rl []resourceset.AzureResource
+	for _, q := range managementGroupGovernanceQueries {
+		query := fmt.Sprintf("%s | where %s | order by id desc", q.table, q.predicate)
+		rows, err := queryResourceGraph(ctx, graphClient, armresourcegraph.QueryRequest{
+			Query:            &query,
+			ManagementGroups: []*string{&meta.managementGroup},
+			Options: &armresourcegraph.QueryRequestOptions{
+				AuthorizationScopeFilter: ptr(armresourcegraph.AuthorizationScopeFilterAtScopeAndBelow),
+			},
+		})
+		if err != nil {
+			return nil, fmt.Errorf("listing %s of management group %s: %v", q.table, meta.managementGroup, err)
+		}
+		ids, err := rowsResourceIds(rows)
+		if err != nil {
+			return nil, err
+		}
+		for _, id := range ids {
+			rl = append(rl, resourceset.AzureResource{
+				Id: id,
+			})
+		}
+	}
+	return rl, nil
+}
+
+// queryResourceGraph runs the ARG query request, and returns the rows of all the pages.
+func queryResourceGraph(ctx context.Context, graphClient *armresourcegraph.Client, req armresourcegraph.QueryRequest) ([]map[string]any, error) {
+	if req.Options == nil {
+		req.Options = &armresourcegraph.QueryRequestOptions{}
+	}
+	req.Options.ResultFormat = ptr(armresourcegraph.ResultFormatObjectArray)
+
+	var rows []map[string]any
+	for {
+		resp, err := graphClient.Resources(ctx, req, nil)
+		if err != nil {
+			return nil, fmt.Errorf("executing ARG query %q: %w", *req.Query, err)
+		}
+		data, ok := resp.Data.([]any)
+		if !ok {
+			return nil, fmt.Errorf("unexpected ARG query response data type %T", resp.Data)
+		}
+		for _, row := range data {
+			if row, ok := row.(map[string]any); ok {
+				rows = append(rows, row)
+			}
+		}
+		if resp.SkipToken == nil || *resp.SkipToken == "" {
+			return rows, nil
+		}
+		req.Options.SkipToken = resp.SkipToken
+	}
+}
+
+// rowsResourceIds returns the parsed resource ids of the ARG query rows.
+func rowsResourceIds(rows []map[string]any) ([]armid.ResourceId, error) {
+	var ids []armid.ResourceId
+	for _, row := range rows {
+		v, _ := row["id"].(string)
+		id, err := armid.ParseResourceId(v)
+		if err != nil {
+			return nil, fmt.Errorf("parsing resource id %s: %v", v, err)
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
@@ -0,0 +1,21 @@
+package meta
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRowsResourceIds(t *testing.T) {
+	ids, err := rowsResourceIds([]map[string]any{
+		{"id": "/subscriptions/123"},
+		{"id": "/providers/Microsoft.Management/managementGroups/mg1/providers/Microsoft.Authorization/policyAssignments/pa1"},
+	})
+	require.NoError(t, err)
+	require.Len(t, ids, 2)
+	require.Equal(t, "/subscriptions/123", ids[0].String())
+	require.Equal(t, "/providers/Microsoft.Management/managementGroups/mg1/providers/Microsoft.Authorization/policyAssignments/pa1", ids[1].String())
+
+	_, err = rowsResourceIds([]map[string]any{{"id": "foo"}})
+	require.Error(t, err)
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"os"
 	"sort"
+	"strings"
 
 	"github.com/Azure/aztfexport/pkg/config"
 
@@ -49,6 +50,10 @@ func (meta *MetaMap) ListResource(_ context.Context) (ImportList, error) {
 	if err := json.Unmarshal(b, &m); err != nil {
 		return nil, fmt.Errorf("unmarshalling the mapping file: %v", err)
 	}
+	// The same resource listed in different casings would otherwise be imported twice.
+	if err := m.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid mapping file %s: %v", meta.mappingFile, err)
+	}
 
 	var l ImportList
 	for id, res := range m {
@@ -71,9 +76,17 @@ func (meta *MetaMap) ListResource(_ context.Context) (ImportList, error) {
 	}
 
 	l = meta.excludeImportList(l)
+	if err := meta.checkModuleLayoutSubscriptions(l); err != nil {
+		return nil, err
+	}
+
+	l, err = meta.markManagedImportList(l)
+	if err != nil {
+		return nil, err
+	}
 
 	sort.Slice(l, func(i, j int) bool {
-		return l[i].AzureResourceID.String() < l[j].AzureResourceID.String()
+		return strings.ToUpper(l[i].AzureResourceID.String()) < strings.ToUpper(l[j].AzureResourceID.String())
 	})
 
 	return l, nil
@@ -3,6 +3,7 @@ package meta
 import (
 	"context"
 	"fmt"
+	"strings"
 
 	"github.com/Azure/aztfexport/internal/resourceset"
 	"github.com/Azure/aztfexport/internal/tfaddr"
@@ -21,6 +22,8 @@ type MetaQuery struct {
 	includeResourceGroup         bool
 	argTable                     string
 	argAuthenticationScopeFilter armresourcegraph.AuthorizationScopeFilter
+	// The subscriptions to query, which are the subscription in use together with the additional subscriptions
+	querySubscriptionIds []string
 }
 
 func NewMetaQuery(cfg config.Config) (*MetaQuery, error) {
@@ -30,17 +33,30 @@ func NewMetaQuery(cfg config.Config) (*MetaQuery, error) {
 		return nil, err
 	}
 
+	if len(cfg.AdditionalSubscriptionIds) != 0 {
+		if cfg.ModulePath != "" {
+			return nil, fmt.Errorf("additional subscriptions can't be used together with module path")
+		}
+		if cfg.ModuleLayout != "" && cfg.ModuleLayout != config.ModuleLayoutNone {
+			return nil, fmt.Errorf("additional subscriptions can't be used together with module layout")
+		}
+	}
+
 	meta := &MetaQuery{
 		baseMeta:                     *baseMeta,
-		argPredicate:                 cfg.ARGPredicate,
+		argPredicate:                 withTagPredicate(cfg.ARGPredicate, cfg.TagFilter),
 		recursiveQuery:               cfg.RecursiveQuery,
 		includeExtensions:            cfg.IncludeExtensions,
 		includeManagedResource:       cfg.IncludeManagedResource,
 		includeResourceGroup:         cfg.IncludeResourceGroup,
 		argTable:                     cfg.ARGTable,
 		argAuthenticationScopeFilter: armresourcegraph.AuthorizationScopeFilter(cfg.ARGAuthorizationScopeFilter),
+		querySubscriptionIds:         append([]string{baseMeta.subscriptionId}, cfg.AdditionalSubscriptionIds...),
+	}
+	meta.resourceNameExpander, err = newNameExpanderWithPolicy(cfg.ResourceNamePattern, cfg.NamingPolicyFile)
+	if err != nil {
+		return nil, err
 	}
-	meta.resourceNameExpander = newNameExpander(cfg.ResourceNamePattern)
 
 	return meta, nil
 }
@@ -59,6 +75,11 @@ func (meta *MetaQuery) ListResource(ctx context.Context) (ImportList, error) {
 	if err != nil {
 		return nil, err
 	}
+	return meta.resourceSetToImportList(rset)
+}
+
+// resourceSetToImportList maps the Azure resource set to the import list, with the excluded resources removed and the managed resources marked.
+func (meta *MetaQuery) resourceSetToImportList(rset *resourceset.AzureResourceSet) (ImportList, error) {
 	var rl []resourceset.TFResource
 	if meta.useAzAPI() {
 		meta.Logger().Debug("Azure Resource set map to TF resource set")
@@ -74,8 +95,9 @@ func (meta *MetaQuery) ListResource(ctx context.Context) (ImportList, error) {
 	}
 
 	var l ImportList
-	for _, res := range rl {
-		name := meta.resourceNameExpander.Expand(res)
+	names := meta.resourceNameExpander.ExpandAll(rl)
+	for i, res := range rl {
+		name := names[i]
 		item := ImportItem{
 			AzureResourceID: res.AzureId,
 			TFResourceId:    res.TFId,
@@ -100,38 +122,56 @@ func (meta *MetaQuery) ListResource(ctx context.Context) (ImportList, error) {
 
 	l = meta.excludeImportList(l)
 
+	l, err := meta.markManagedImportList(l)
+	if err != nil {
+		return nil, err
+	}
+
 	return l, nil
 }
 
+// queryResourceSet queries the resources in the subscriptions to query. The resources are deduplicated by their ids.
 func (meta MetaQuery) queryResourceSet(ctx context.Context, predicate string, recursive bool) (*resourceset.AzureResourceSet, error) {
-	opt := azlist.Option{
-		Logger:                      meta.logger.WithGroup("azlist"),
-		SubscriptionId:              meta.subscriptionId,
-		Cred:                        meta.azureSDKCred,
-		ClientOpt:                   meta.azureSDKClientOpt,
-		Parallelism:                 meta.parallelism,
-		Recursive:                   recursive,
-		IncludeResourceGroup:        meta.includeResourceGroup,
-		ExtensionResourceTypes:      extBuilder{includeExtensions: meta.includeExtensions}.Build(),
-		IncludeManaged:              meta.includeManagedResource,
-		ARGTable:                    meta.argTable,
-		ARGAuthorizationScopeFilter: meta.argAuthenticationScopeFilter,
-	}
-	lister, err := azlist.NewLister(opt)
-	if err != nil {
-		return nil, fmt.Errorf("building azlister: %v", err)
-	}
-	result, err := lister.ListByQuery(ctx, predicate)
-	if err != nil {
-		return nil, fmt.Errorf("listing resource set: %w", err)
-	}
-
 	var rl []resourceset.AzureResource
-	for _, res := range result.Resources {
-		res := resourceset.AzureResource{
-			Id: res.Id,
+	dedup := map[string]bool{}
+	for _, subscriptionId := range meta.querySubscriptionIds {
+		opt := azlist.Option{
+			Logger:                      meta.logger.WithGroup("azlist"),
+			SubscriptionId:              subscriptionId,
+			Cred:                        meta.azureSDKCred,
+			ClientOpt:                   meta.azureSDKClientOpt,
+			Parallelism:                 meta.parallelism,
+			Recursive:                   recursive,
+			IncludeResourceGroup:        meta.includeResourceGroup,
+			ExtensionResourceTypes:      extBuilder{includeExtensions: meta.includeExtensions}.Build(),
+			IncludeManaged:              meta.includeManagedResource,
+			ARGTable:                    meta.argTable,
+			ARGAuthorizationScopeFilter: meta.argAuthenticationScopeFilter,
+		}
+		lister, err := azlist.NewLister(opt)
+		if err != nil {
+			return nil, fmt.Errorf("building azlister for subscription %s: %v", subscriptionId, err)
+		}
+		result, err := lister.ListByQuery(ctx, predicate)
+		if err != nil {
+			return nil, fmt.Errorf("listing resource set of subscription %s: %w", subscriptionId, err)
+		}
+		resources, err := extBuilder{includeExtensions: meta.includeExtensions}.AppendLinked(ctx, opt, result.Resources)
+		if err != nil {
+			return nil, fmt.Errorf("listing linked resources of subscription %s: %w", subscriptionId, err)
+		}
+
+		for _, res := range resources {
+			key := strings.ToUpper(res.Id.String())
+			if dedup[key] {
+				continue
+			}
+			dedup[key] = true
+			rl = append(rl, resourceset.AzureResource{
+				Id:   res.Id,
+				Tags: resourceTags(res.Properties),
+			})
 		}
-		rl = append(rl, res)
 	}
 
 	return &resourceset.AzureResourceSet{Resources: rl}, nil
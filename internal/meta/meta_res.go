@@ -53,7 +53,10 @@ func NewMetaResource(cfg config.Config) (*MetaResource, error) {
 		includeResourceGroup:   cfg.IncludeResourceGroup,
 	}
 
-	meta.resourceNameExpander = newNameExpander(cfg.ResourceNamePattern)
+	meta.resourceNameExpander, err = newNameExpanderWithPolicy(cfg.ResourceNamePattern, cfg.NamingPolicyFile)
+	if err != nil {
+		return nil, err
+	}
 
 	return meta, nil
 }
@@ -150,15 +153,24 @@ func (meta *MetaResource) ListResource(ctx context.Context) (ImportList, error)
 	l = append(l, meta.toImportList(tfpl)...)
 
 	l = meta.excludeImportList(l)
+	if err := meta.checkModuleLayoutSubscriptions(l); err != nil {
+		return nil, err
+	}
+
+	l, err = meta.markManagedImportList(l)
+	if err != nil {
+		return nil, err
+	}
 	return l, nil
 }
 
 func (meta MetaResource) toImportList(rl []resourceset.TFResource) ImportList {
 	var l ImportList
-	for _, res := range rl {
+	names := meta.resourceNameExpander.ExpandAll(rl)
+	for i, res := range rl {
 		tfAddr := tfaddr.TFAddr{
 			Type: "",
-			Name: meta.resourceNameExpander.Expand(res),
+			Name: names[i],
 		}
 		item := ImportItem{
 			AzureResourceID: res.AzureId,
@@ -217,11 +229,16 @@ func (meta MetaResource) listByIds(ctx context.Context, resources []resourceset.
 	if err != nil {
 		return nil, fmt.Errorf("azlist listing resources by ids: %w", err)
 	}
+	listed, err := extBuilder{includeExtensions: meta.includeExtensions}.AppendLinked(ctx, opt, result.Resources)
+	if err != nil {
+		return nil, fmt.Errorf("listing linked resources: %w", err)
+	}
 
 	var rl []resourceset.AzureResource
-	for _, res := range result.Resources {
+	for _, res := range listed {
 		res := resourceset.AzureResource{
-			Id: res.Id,
+			Id:   res.Id,
+			Tags: resourceTags(res.Properties),
 		}
 		rl = append(rl, res)
 	}
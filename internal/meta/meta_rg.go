@@ -3,19 +3,24 @@ package meta
 import (
 	"context"
 	"fmt"
+	"path"
+	"strings"
 
 	"github.com/Azure/aztfexport/internal/resourceset"
 	"github.com/Azure/aztfexport/internal/tfaddr"
 	"github.com/Azure/aztfexport/pkg/config"
+	"github.com/magodo/armid"
 	"github.com/magodo/azlist/azlist"
 )
 
 type MetaResourceGroup struct {
 	baseMeta
-	resourceGroup          string
+	// The names or glob patterns of the resource groups
+	resourceGroups         []string
 	resourceNameExpander   *nameExpander
 	includeExtensions      []string
 	includeManagedResource bool
+	tagFilter              map[string]string
 }
 
 func NewMetaResourceGroup(cfg config.Config) (*MetaResourceGroup, error) {
@@ -25,24 +30,39 @@ func NewMetaResourceGroup(cfg config.Config) (*MetaResourceGroup, error) {
 		return nil, err
 	}
 
+	var resourceGroups []string
+	if cfg.ResourceGroupName != "" {
+		resourceGroups = append(resourceGroups, cfg.ResourceGroupName)
+	}
+	resourceGroups = append(resourceGroups, cfg.ResourceGroupNames...)
+	for _, rg := range resourceGroups {
+		if _, err := path.Match(rg, ""); err != nil {
+			return nil, fmt.Errorf("invalid resource group pattern %q: %v", rg, err)
+		}
+	}
+
 	meta := &MetaResourceGroup{
 		baseMeta:               *baseMeta,
-		resourceGroup:          cfg.ResourceGroupName,
+		resourceGroups:         resourceGroups,
 		includeExtensions:      cfg.IncludeExtensions,
 		includeManagedResource: cfg.IncludeManagedResource,
+		tagFilter:              cfg.TagFilter,
+	}
+	meta.resourceNameExpander, err = newNameExpanderWithPolicy(cfg.ResourceNamePattern, cfg.NamingPolicyFile)
+	if err != nil {
+		return nil, err
 	}
-	meta.resourceNameExpander = newNameExpander(cfg.ResourceNamePattern)
 
 	return meta, nil
 }
 
 func (meta MetaResourceGroup) ScopeName() string {
-	return meta.resourceGroup
+	return strings.Join(meta.resourceGroups, ", ")
 }
 
 func (meta *MetaResourceGroup) ListResource(ctx context.Context) (ImportList, error) {
 	meta.Logger().Debug("Query resource set")
-	rset, err := meta.queryResourceSet(ctx, meta.resourceGroup)
+	rset, err := meta.queryResourceSet(ctx, meta.resourceGroups)
 	if err != nil {
 		return nil, err
 	}
@@ -61,10 +81,11 @@ func (meta *MetaResourceGroup) ListResource(ctx context.Context) (ImportList, er
 	}
 
 	var l ImportList
-	for _, res := range rl {
+	names := meta.resourceNameExpander.ExpandAll(rl)
+	for i, res := range rl {
 		tfAddr := tfaddr.TFAddr{
 			Type: "",
-			Name: meta.resourceNameExpander.Expand(res),
+			Name: names[i],
 		}
 		item := ImportItem{
 			AzureResourceID: res.AzureId,
@@ -84,13 +105,70 @@ func (meta *MetaResourceGroup) ListResource(ctx context.Context) (ImportList, er
 
 	l = meta.excludeImportList(l)
 
+	l, err = meta.markManagedImportList(l)
+	if err != nil {
+		return nil, err
+	}
+
 	return l, nil
 }
 
-func (meta MetaResourceGroup) queryResourceSet(ctx context.Context, rg string) (*resourceset.AzureResourceSet, error) {
+// isResourceGroupPattern tells whether the resource group is a glob pattern, rather than a name.
+func isResourceGroupPattern(rg string) bool {
+	return strings.ContainsAny(rg, "*?[")
+}
+
+// matchResourceGroup tells whether the resource group name matches any of the names or glob patterns.
+// Both the names and the glob patterns are matched case insensitively, as are the resource group names in Azure.
+func matchResourceGroup(rgs []string, name string) bool {
+	for _, rg := range rgs {
+		if !isResourceGroupPattern(rg) {
+			if strings.EqualFold(rg, name) {
+				return true
+			}
+			continue
+		}
+		if ok, _ := path.Match(strings.ToLower(rg), strings.ToLower(name)); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// kqlStringReplacer escapes the backslashes and the single quotes in a single quoted KQL string literal.
+var kqlStringReplacer = strings.NewReplacer(`\`, `\\`, `'`, `\'`)
+
+// kqlString returns the value in the form of a single quoted KQL string literal, e.g. 'a'.
+func kqlString(v string) string {
+	return "'" + kqlStringReplacer.Replace(v) + "'"
+}
+
+// quotedList returns the values in the form of a KQL list of the single quoted string literals, e.g. ('a', 'b').
+func quotedList(values []string) string {
+	var l []string
+	for _, v := range values {
+		l = append(l, kqlString(v))
+	}
+	return "(" + strings.Join(l, ", ") + ")"
+}
+
+func (meta MetaResourceGroup) queryResourceSet(ctx context.Context, rgs []string) (*resourceset.AzureResourceSet, error) {
 	var rl []resourceset.AzureResource
 
-	// Try to get the resource group (with any extension resources) first, in case it doesn't exist.
+	// Try to get the resource groups (with any extension resources) first, in case they don't exist.
+	// The glob patterns are matched against all the resource groups of the subscription.
+	hasPattern := false
+	for _, rg := range rgs {
+		if isResourceGroupPattern(rg) {
+			hasPattern = true
+			break
+		}
+	}
+	predicate := fmt.Sprintf("name in~ %s", quotedList(rgs))
+	if hasPattern {
+		predicate = `type =~ "microsoft.resources/subscriptions/resourcegroups"`
+	}
+
 	opt := azlist.Option{
 		Logger:                 meta.logger.WithGroup("azlist"),
 		SubscriptionId:         meta.subscriptionId,
@@ -105,23 +183,37 @@ func (meta MetaResourceGroup) queryResourceSet(ctx context.Context, rg string) (
 	if err != nil {
 		return nil, fmt.Errorf("building azlister for listing resource group only: %v", err)
 	}
-	result, err := lister.ListByQuery(ctx, fmt.Sprintf("name == %q", rg))
+	result, err := lister.ListByQuery(ctx, predicate)
 	if err != nil {
 		return nil, fmt.Errorf("listing resource group only: %w", err)
 	}
+	var names []string
 	for _, res := range result.Resources {
+		rg, ok := res.Id.RootScope().(*armid.ResourceGroup)
+		if hasPattern && (!ok || !matchResourceGroup(rgs, rg.Name)) {
+			continue
+		}
+		if id, ok := res.Id.(*armid.ResourceGroup); ok && len(id.AttrTypes) == 0 {
+			names = append(names, id.Name)
+		}
 		res := resourceset.AzureResource{
-			Id: res.Id,
+			Id:   res.Id,
+			Tags: resourceTags(res.Properties),
 		}
 		rl = append(rl, res)
 	}
+	for _, rg := range rgs {
+		if !isResourceGroupPattern(rg) && !matchResourceGroup(names, rg) {
+			meta.Logger().Warn("Resource group not found", "resource_group", rg)
+		}
+	}
 
-	// Skip the resource listing if the resource group itself doesn't exist.
-	if len(result.Resources) == 0 {
+	// Skip the resource listing if none of the resource groups exists.
+	if len(names) == 0 {
 		return &resourceset.AzureResourceSet{}, nil
 	}
 
-	// List the resources within the resource group.
+	// List the resources within the resource groups.
 	opt = azlist.Option{
 		Logger:                 meta.logger.WithGroup("azlist"),
 		SubscriptionId:         meta.subscriptionId,
@@ -136,13 +228,18 @@ func (meta MetaResourceGroup) queryResourceSet(ctx context.Context, rg string) (
 	if err != nil {
 		return nil, fmt.Errorf("building azlister for listing resource group: %v", err)
 	}
-	result, err = lister.ListByQuery(ctx, fmt.Sprintf("resourceGroup =~ %q", rg))
+	result, err = lister.ListByQuery(ctx, withTagPredicate(fmt.Sprintf("resourceGroup in~ %s", quotedList(names)), meta.tagFilter))
 	if err != nil {
 		return nil, fmt.Errorf("listing resource group: %w", err)
 	}
-	for _, res := range result.Resources {
+	resources, err := extBuilder{includeExtensions: meta.includeExtensions}.AppendLinked(ctx, opt, result.Resources)
+	if err != nil {
+		return nil, fmt.Errorf("listing linked resources: %w", err)
+	}
+	for _, res := range resources {
 		res := resourceset.AzureResource{
-			Id: res.Id,
+			Id:   res.Id,
+			Tags: resourceTags(res.Properties),
 		}
 		rl = append(rl, res)
 	}
@@ -0,0 +1,24 @@
+package meta
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMatchResourceGroup(t *testing.T) {
+	rgs := []string{"rg1", "App-*", "db-?"}
+	require.True(t, matchResourceGroup(rgs, "rg1"))
+	require.True(t, matchResourceGroup(rgs, "RG1"))
+	require.True(t, matchResourceGroup(rgs, "app-frontend"))
+	require.True(t, matchResourceGroup(rgs, "APP-backend"))
+	require.True(t, matchResourceGroup(rgs, "db-1"))
+	require.False(t, matchResourceGroup(rgs, "db-10"))
+	require.False(t, matchResourceGroup(rgs, "rg2"))
+}
+
+func TestQuotedList(t *testing.T) {
+	require.Equal(t, `('rg1')`, quotedList([]string{"rg1"}))
+	require.Equal(t, `('rg1', 'rg2')`, quotedList([]string{"rg1", "rg2"}))
+	require.Equal(t, `('rg\'1', 'rg\\2', 'rg"3')`, quotedList([]string{`rg'1`, `rg\2`, `rg"3`}))
+}
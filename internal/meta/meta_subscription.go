@@ -0,0 +1,184 @@
+package meta
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/Azure/aztfexport/internal/client"
+	"github.com/Azure/aztfexport/internal/resourceset"
+	"github.com/Azure/aztfexport/pkg/config"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/arm"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/runtime"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/resourcegraph/armresourcegraph"
+	"github.com/magodo/armid"
+	"github.com/magodo/azlist/azlist"
+)
+
+// scopeQueryPredicate is the ARG predicate that matches all the resources of the scope.
+const scopeQueryPredicate = "isnotempty(id)"
+
+// resourceGroupQuery is the ARG query for the resource groups, which are not returned by the "Resources" table.
+var resourceGroupQuery = argQuery{
+	table:     "ResourceContainers",
+	predicate: `type =~ "microsoft.resources/subscriptions/resourcegroups"`,
+}
+
+// subscriptionLevelListedResourceTypes are the subscription level resource types that are not available in ARG, which are listed via the ARM API instead.
+var subscriptionLevelListedResourceTypes = []struct {
+	resourceType string
+	apiVersion   string
+}{
+	{
+		resourceType: "Microsoft.Consumption/budgets",
+		apiVersion:   "2023-05-01",
+	},
+}
+
+type MetaSubscription struct {
+	MetaQuery
+	governanceOnly bool
+}
+
+func NewMetaSubscription(cfg config.Config) (*MetaSubscription, error) {
+	cfg.Logger.Info("New subscription meta")
+	cfg.ARGPredicate = scopeQueryPredicate
+	cfg.RecursiveQuery = true
+	cfg.ARGTable = ""
+	// The resource groups are queried as the subscription level resources.
+	cfg.IncludeResourceGroup = false
+	// Only the resources within the subscription are exported, rather than the ones inherited from its management groups.
+	cfg.ARGAuthorizationScopeFilter = string(armresourcegraph.AuthorizationScopeFilterAtScopeAndBelow)
+	metaQuery, err := NewMetaQuery(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return &MetaSubscription{
+		MetaQuery:      *metaQuery,
+		governanceOnly: cfg.GovernanceOnly,
+	}, nil
+}
+
+func (meta MetaSubscription) ScopeName() string {
+	return "subscription " + meta.subscriptionId
+}
+
+func (meta *MetaSubscription) ListResource(ctx context.Context) (ImportList, error) {
+	rset := &resourceset.AzureResourceSet{}
+	if !meta.governanceOnly {
+		meta.Logger().Debug("Query resource set")
+		var err error
+		rset, err = meta.queryResourceSet(ctx, meta.argPredicate, meta.recursiveQuery)
+		if err != nil {
+			return nil, err
+		}
+	}
+	meta.Logger().Debug("Query subscription level resource set")
+	rl, err := meta.querySubscriptionLevelResources(ctx, !meta.governanceOnly)
+	if err != nil {
+		return nil, err
+	}
+	rset.Resources = dedupResources(append(rl, rset.Resources...))
+	return meta.resourceSetToImportList(rset)
+}
+
+// querySubscriptionLevelResources queries the resources in the subscriptions to query, that are not returned by the "Resources" table,
+// i.e. the governance objects, the budgets, and optionally the resource groups.
+func (meta MetaQuery) querySubscriptionLevelResources(ctx context.Context, includeResourceGroups bool) ([]resourceset.AzureResource, error) {
+	b := client.ClientBuilder{
+		Credential: meta.azureSDKCred,
+		Opt:        meta.azureSDKClientOpt,
+	}
+	armClient, err := b.NewARMClient()
+	if err != nil {
+		return nil, fmt.Errorf("new ARM client: %v", err)
+	}
+
+	queries := subscriptionGovernanceQueries
+	if includeResourceGroups {
+		queries = append([]argQuery{resourceGroupQuery}, queries...)
+	}
+
+	var rl []resourceset.AzureResource
+	for _, subscriptionId := range meta.querySubscriptionIds {
+		for _, q := range queries {
+			opt := azlist.Option{
+				Logger:                      meta.logger.WithGroup("azlist"),
+				SubscriptionId:              subscriptionId,
+				Cred:                        meta.azureSDKCred,
+				ClientOpt:                   meta.azureSDKClientOpt,
+				Parallelism:                 meta.parallelism,
+				ExtensionResourceTypes:      extBuilder{includeExtensions: meta.includeExtensions}.Build(),
+				IncludeManaged:              meta.includeManagedResource,
+				ARGTable:                    q.table,
+				ARGAuthorizationScopeFilter: armresourcegraph.AuthorizationScopeFilterAtScopeAndBelow,
+			}
+			lister, err := azlist.NewLister(opt)
+			if err != nil {
+				return nil, fmt.Errorf("building azlister for %s of subscription %s: %v", q.table, subscriptionId, err)
+			}
+			result, err := lister.ListByQuery(ctx, q.predicate)
+			if err != nil {
+				return nil, fmt.Errorf("listing %s of subscription %s: %w", q.table, subscriptionId, err)
+			}
+			for _, res := range result.Resources {
+				rl = append(rl, resourceset.AzureResource{
+					Id:   res.Id,
+					Tags: resourceTags(res.Properties),
+				})
+			}
+		}
+
+		for _, rt := range subscriptionLevelListedResourceTypes {
+			ids, err := listResourceIdsByARM(ctx, armClient, "/subscriptions/"+subscriptionId, rt.resourceType, rt.apiVersion)
+			if err != nil {
+				// Some subscriptions (e.g. the ones without billing access) don't support listing these resources, which shouldn't fail the export.
+				meta.Logger().Warn("Failed to list resources", "subscription", subscriptionId, "type", rt.resourceType, "error", err)
+				continue
+			}
+			for _, id := range ids {
+				rl = append(rl, resourceset.AzureResource{
+					Id: id,
+				})
+			}
+		}
+	}
+	return rl, nil
+}
+
+// listResourceIdsByARM lists the ids of the resources of the resource type under the scope, via the ARM list API.
+func listResourceIdsByARM(ctx context.Context, armClient *arm.Client, scope, resourceType, apiVersion string) ([]armid.ResourceId, error) {
+	var ids []armid.ResourceId
+	url := runtime.JoinPaths(armClient.Endpoint(), scope, "providers", resourceType) + "?api-version=" + apiVersion
+	for url != "" {
+		req, err := runtime.NewRequest(ctx, http.MethodGet, url)
+		if err != nil {
+			return nil, err
+		}
+		resp, err := armClient.Pipeline().Do(req)
+		if err != nil {
+			return nil, err
+		}
+		if !runtime.HasStatusCode(resp, http.StatusOK) {
+			return nil, runtime.NewResponseError(resp)
+		}
+		var page struct {
+			Value []struct {
+				Id string `json:"id"`
+			} `json:"value"`
+			NextLink string `json:"nextLink"`
+		}
+		if err := runtime.UnmarshalAsJSON(resp, &page); err != nil {
+			return nil, err
+		}
+		for _, v := range page.Value {
+			id, err := armid.ParseResourceId(v.Id)
+			if err != nil {
+				return nil, fmt.Errorf("parsing resource id %s: %v", v.Id, err)
+			}
+			ids = append(ids, id)
+		}
+		url = page.NextLink
+	}
+	return ids, nil
+}
@@ -0,0 +1,421 @@
+package meta
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/Azure/aztfexport/pkg/config"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/hashicorp/terraform-config-inspect/tfconfig"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// childModulesDir is the directory, relative to the output directory, where the child modules of the module layout are generated.
+const childModulesDir = "modules"
+
+var moduleNameInvalidChars = regexp.MustCompile(`[^a-z0-9_-]`)
+
+// layoutModuleName returns the name of the child module that the import item belongs to in the module layout.
+// An empty name means the item belongs to the root module.
+func layoutModuleName(layout config.ModuleLayout, item ImportItem) string {
+	if layout == "" || layout == config.ModuleLayoutNone {
+		return ""
+	}
+	// The module layouts share the same keys as the file layouts of the same names.
+	name := moduleNameInvalidChars.ReplaceAllString(layoutFileKey(config.FileLayout(layout), item), "_")
+	// The module name must start with a letter or an underscore.
+	if name != "" && (name[0] == '-' || (name[0] >= '0' && name[0] <= '9')) {
+		name = "_" + name
+	}
+	return name
+}
+
+// moduleLayout determines the child modules that the import items belong to.
+type moduleLayout struct {
+	layout config.ModuleLayout
+	// rules are the module rules of the rule module layout.
+	rules *ModuleRules
+}
+
+// enabled returns whether the resources are organized into the child modules.
+func (l moduleLayout) enabled() bool {
+	return l.layout != "" && l.layout != config.ModuleLayoutNone
+}
+
+// moduleName returns the name of the child module that the import item belongs to, or an empty string for the root module.
+func (l moduleLayout) moduleName(item ImportItem) string {
+	if l.layout == config.ModuleLayoutRule {
+		if l.rules == nil {
+			return ""
+		}
+		return l.rules.moduleName(item)
+	}
+	return layoutModuleName(l.layout, item)
+}
+
+// partitionConfigInfosByModule partitions the config infos by the child module names of the layout, with the order of the config infos in each partition kept.
+// The root module (i.e. the empty name) always comes first.
+func partitionConfigInfosByModule(layout moduleLayout, cfgs ConfigInfos) (names []string, partitions map[string]ConfigInfos) {
+	partitions = map[string]ConfigInfos{"": nil}
+	for _, cfg := range cfgs {
+		name := layout.moduleName(cfg.ImportItem)
+		if _, ok := partitions[name]; !ok {
+			names = append(names, name)
+		}
+		partitions[name] = append(partitions[name], cfg)
+	}
+	sort.Strings(names)
+	return append([]string{""}, names...), partitions
+}
+
+// resourceModuleAddr returns the module address (e.g. "module.rg1") where the resource of the import item is imported.
+// This is an empty string for the root module.
+func (meta baseMeta) resourceModuleAddr(item ImportItem) string {
+	if meta.moduleAddr != "" {
+		return meta.moduleAddr
+	}
+	if name := meta.moduleLayout.moduleName(item); name != "" {
+		return "module." + name
+	}
+	return ""
+}
+
+// moduleRef is a value of the producer module that is referenced by the resources of the consumer module.
+type moduleRef struct {
+	producer string
+	// value is the expression of the value in the producer module, e.g. "azurerm_virtual_network.res-0.id".
+	value string
+}
+
+// moduleWiring passes the references to the resources in the other modules of the module layout through the module outputs,
+// the module call arguments and the module variables.
+type moduleWiring struct {
+	layout moduleLayout
+	// refs are the references keyed by the consumer module name, and then by the reference name, which names the output of the producer module,
+	// as well as the variable of the consumer module.
+	refs map[string]map[string]moduleRef
+}
+
+func newModuleWiring(layout moduleLayout) *moduleWiring {
+	return &moduleWiring{
+		layout: layout,
+		refs:   map[string]map[string]moduleRef{},
+	}
+}
+
+// Wire rewrites the references to the resources in the other modules, to the variables (e.g. "var.azurerm_virtual_network_res-0_id") in the child modules,
+// or to the module outputs (e.g. "module.rg1.azurerm_virtual_network_res-0_id") in the root module. The reference is named after the referenced attribute
+// (e.g. "azurerm_subnet_res-1_id", or "azurerm_subnet_res-1_key_id" for an instance of the for_each resource), in the same way as the generated outputs.
+// The references in the "depends_on" are rewritten to the ids of the resources, so that the ordering is kept.
+func (w *moduleWiring) Wire(configs ConfigInfos) (ConfigInfos, error) {
+	type target struct {
+		module  string
+		forEach bool
+	}
+	targets := map[string]target{}
+	for _, cfg := range configs {
+		targets[cfg.TFAddr.String()] = target{module: w.layout.moduleName(cfg.ImportItem), forEach: len(cfg.ForEach) != 0}
+	}
+
+	for i, cfg := range configs {
+		consumer := w.layout.moduleName(cfg.ImportItem)
+		src := cfg.HCL.Bytes()
+		file, diags := hclsyntax.ParseConfig(src, "main.tf", hcl.InitialPos)
+		if diags.HasErrors() {
+			return nil, fmt.Errorf("parsing the config of %s: %v", cfg.TFAddr, diags.Error())
+		}
+		var dependsOnRange hcl.Range
+		if blocks := file.Body.(*hclsyntax.Body).Blocks; len(blocks) != 0 {
+			if attr, ok := blocks[0].Body.Attributes["depends_on"]; ok {
+				dependsOnRange = attr.Expr.Range()
+			}
+		}
+
+		type edit struct {
+			start, end int
+			text       string
+		}
+		var edits []edit
+		hclsyntax.VisitAll(file.Body.(*hclsyntax.Body), func(node hclsyntax.Node) hcl.Diagnostics {
+			expr, ok := node.(*hclsyntax.ScopeTraversalExpr)
+			if !ok || len(expr.Traversal) < 2 {
+				return nil
+			}
+			attr, ok := expr.Traversal[1].(hcl.TraverseAttr)
+			if !ok {
+				return nil
+			}
+			t, ok := targets[expr.Traversal.RootName()+"."+attr.Name]
+			if !ok || t.module == consumer {
+				return nil
+			}
+
+			var (
+				ref   hcl.Traversal
+				value string
+			)
+			end := expr.SrcRange.End.Byte
+			if dependsOnRange.ContainsOffset(expr.SrcRange.Start.Byte) {
+				// Depending on the resource is passed as depending on its id.
+				addr := hcl.Traversal{expr.Traversal[0], expr.Traversal[1]}
+				ref = append(addr, hcl.TraverseAttr{Name: "id"})
+				value = string(hclwrite.TokensForTraversal(ref).Bytes())
+				if t.forEach {
+					value = fmt.Sprintf("{ for k, v in %s : k => v.id }", hclwrite.TokensForTraversal(addr).Bytes())
+				}
+			} else {
+				// The reference is the resource (or instance) address, followed by the attribute if any, e.g. `azurerm_subnet.res-1["key"].id`.
+				n := 2
+				if n < len(expr.Traversal) && t.forEach {
+					if _, ok := expr.Traversal[n].(hcl.TraverseIndex); ok {
+						n++
+					}
+				}
+				if n < len(expr.Traversal) {
+					if _, ok := expr.Traversal[n].(hcl.TraverseAttr); ok {
+						n++
+					}
+				}
+				ref = expr.Traversal[:n]
+				value = string(hclwrite.TokensForTraversal(ref).Bytes())
+				end = ref[n-1].SourceRange().End.Byte
+			}
+			name := moduleRefName(ref)
+
+			text := "var." + name
+			if consumer == "" {
+				text = "module." + t.module + "." + name
+			}
+			edits = append(edits, edit{start: expr.SrcRange.Start.Byte, end: end, text: text})
+			if w.refs[consumer] == nil {
+				w.refs[consumer] = map[string]moduleRef{}
+			}
+			w.refs[consumer][name] = moduleRef{producer: t.module, value: value}
+			return nil
+		})
+		if len(edits) == 0 {
+			continue
+		}
+
+		sort.Slice(edits, func(i, j int) bool {
+			return edits[i].start > edits[j].start
+		})
+		for _, e := range edits {
+			src = append(src[:e.start:e.start], append([]byte(e.text), src[e.end:]...)...)
+		}
+		f, diags := hclwrite.ParseConfig(src, "", hcl.InitialPos)
+		if diags.HasErrors() {
+			return nil, fmt.Errorf("wiring the module references of %s: %v", cfg.TFAddr, diags.Error())
+		}
+		cfg.HCL = f
+		configs[i] = cfg
+	}
+	return configs, nil
+}
+
+// moduleRefName returns the name of the reference (e.g. "azurerm_subnet_res-1_id"), which is the same as the name of the generated output of the attribute.
+func moduleRefName(ref hcl.Traversal) string {
+	var segs []string
+	for _, step := range ref {
+		switch step := step.(type) {
+		case hcl.TraverseRoot:
+			segs = append(segs, step.Name)
+		case hcl.TraverseAttr:
+			segs = append(segs, step.Name)
+		case hcl.TraverseIndex:
+			if step.Key.Type() == cty.String {
+				segs = append(segs, step.Key.AsString())
+			} else if step.Key.Type() == cty.Number {
+				segs = append(segs, step.Key.AsBigFloat().String())
+			}
+		}
+	}
+	return outputName(strings.Join(segs, "_"))
+}
+
+// sortedRefNames returns the reference names of the consumer module in order.
+func (w *moduleWiring) sortedRefNames(consumer string) []string {
+	var names []string
+	for name := range w.refs[consumer] {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// variablesFile returns the HCL file of the variables of the child module, which receive the references to the other modules.
+func (w *moduleWiring) variablesFile(module string) *hclwrite.File {
+	f := hclwrite.NewFile()
+	body := f.Body()
+	for i, name := range w.sortedRefNames(module) {
+		if i != 0 {
+			body.AppendNewline()
+		}
+		body.AppendNewBlock("variable", []string{name})
+	}
+	return f
+}
+
+// outputsFile returns the HCL file of the outputs of the child module that are referenced by the other modules, except the generated ones.
+// The names of the outputs are added to the generated ones.
+func (w *moduleWiring) outputsFile(module string, generated map[string]bool) (*hclwrite.File, error) {
+	var consumers []string
+	for consumer := range w.refs {
+		consumers = append(consumers, consumer)
+	}
+	sort.Strings(consumers)
+
+	f := hclwrite.NewFile()
+	body := f.Body()
+	for _, consumer := range consumers {
+		for _, name := range w.sortedRefNames(consumer) {
+			ref := w.refs[consumer][name]
+			if ref.producer != module || generated[name] {
+				continue
+			}
+			generated[name] = true
+			tokens, err := exprTokens(ref.value)
+			if err != nil {
+				return nil, fmt.Errorf("building the output value of %s: %v", name, err)
+			}
+			if len(body.Blocks()) != 0 {
+				body.AppendNewline()
+			}
+			body.AppendNewBlock("output", []string{name}).Body().SetAttributeRaw("value", tokens)
+		}
+	}
+	return f, nil
+}
+
+// moduleCallArgs returns the names of the arguments of the module call of the child module in order, together with their expressions,
+// which are either the outputs of the producer modules, or the values of the root module.
+func (w *moduleWiring) moduleCallArgs(module string) ([]string, map[string]string) {
+	if w == nil {
+		return nil, nil
+	}
+	names := w.sortedRefNames(module)
+	args := map[string]string{}
+	for _, name := range names {
+		ref := w.refs[module][name]
+		args[name] = ref.value
+		if ref.producer != "" {
+			args[name] = "module." + ref.producer + "." + name
+		}
+	}
+	return names, args
+}
+
+// exprTokens returns the tokens of the expression source.
+func exprTokens(src string) (hclwrite.Tokens, error) {
+	f, diags := hclwrite.ParseConfig([]byte("expr = "+src+"\n"), "", hcl.InitialPos)
+	if diags.HasErrors() {
+		return nil, diags
+	}
+	return f.Body().GetAttribute("expr").Expr().BuildTokens(nil), nil
+}
+
+// initChildModule creates the child module directory, together with the terraform block (if not exists) that declares the provider in use.
+func (meta baseMeta) initChildModule(dir string) error {
+	// #nosec G301
+	if err := os.MkdirAll(dir, 0750); err != nil {
+		return fmt.Errorf("creating module dir %s: %v", dir, err)
+	}
+	cfgFile := filepath.Join(dir, meta.outputFileNames.TerraformFileName)
+	if _, err := os.Stat(cfgFile); err == nil {
+		return nil
+	}
+	// #nosec G306
+	if err := os.WriteFile(cfgFile, []byte(meta.buildTerraformConfig("")), 0644); err != nil {
+		return fmt.Errorf("error creating terraform config: %w", err)
+	}
+	return nil
+}
+
+// writeModuleCalls appends the module blocks that call the child modules to the module file of the root module, with the arguments of the wired references.
+// The child modules that are already called by the root module are skipped, whose arguments of the wired references have to be added manually.
+func (meta baseMeta) writeModuleCalls(names []string, wiring *moduleWiring) error {
+	module, diags := tfconfig.LoadModule(meta.moduleDir)
+	if diags.HasErrors() {
+		return fmt.Errorf("loading the module %s: %v", meta.moduleDir, diags.Err())
+	}
+
+	f := hclwrite.NewFile()
+	body := f.Body()
+	for _, name := range names {
+		if name == "" {
+			continue
+		}
+		argNames, args := wiring.moduleCallArgs(name)
+		if module.ModuleCalls[name] != nil {
+			if len(argNames) != 0 {
+				meta.Logger().Warn("The module is already called, the arguments of the references from the other modules need to be added manually", "module", name, "arguments", argNames)
+			}
+			continue
+		}
+		if len(body.Blocks()) != 0 {
+			body.AppendNewline()
+		}
+		blk := body.AppendNewBlock("module", []string{name})
+		blk.Body().SetAttributeValue("source", cty.StringVal("./"+childModulesDir+"/"+name))
+		for _, argName := range argNames {
+			tokens, err := exprTokens(args[argName])
+			if err != nil {
+				return fmt.Errorf("building the argument %s of module %s: %v", argName, name, err)
+			}
+			blk.Body().SetAttributeRaw(argName, tokens)
+		}
+	}
+	if len(body.Blocks()) == 0 {
+		return nil
+	}
+
+	b, err := meta.outputFormatter.File(f)
+	if err != nil {
+		return fmt.Errorf("generating the module calls: %v", err)
+	}
+	moduleFile := filepath.Join(meta.moduleDir, meta.outputFileNames.ModuleFileName)
+	if err := meta.outputFormatter.AppendToFile(moduleFile, b); err != nil {
+		return fmt.Errorf("generating module file: %w", err)
+	}
+	return nil
+}
+
+// importChildModuleDir returns the directory of the child module in the import directory, which is declared and installed on its first use.
+func (meta *baseMeta) importChildModuleDir(ctx context.Context, importIdx int, name string) (string, error) {
+	baseDir := meta.importBaseDirs[importIdx]
+	dir := filepath.Join(baseDir, childModulesDir, name)
+	if meta.importChildModules[importIdx][name] {
+		return dir, nil
+	}
+
+	// #nosec G301
+	if err := os.MkdirAll(dir, 0750); err != nil {
+		return "", fmt.Errorf("creating module dir %s: %v", dir, err)
+	}
+	terraformFile := filepath.Join(dir, "terraform.tf")
+	// #nosec G306
+	if err := os.WriteFile(terraformFile, []byte(meta.buildTerraformConfig("")), 0644); err != nil {
+		return "", fmt.Errorf("error creating terraform config: %w", err)
+	}
+	moduleFile := filepath.Join(baseDir, fmt.Sprintf("module.%s.tf", name))
+	// #nosec G306
+	if err := os.WriteFile(moduleFile, []byte(fmt.Sprintf(`module "%s" {
+  source = "./%s/%s"
+}
+`, name, childModulesDir, name)), 0644); err != nil {
+		return "", fmt.Errorf("creating %s: %v", moduleFile, err)
+	}
+	meta.Logger().Debug(`Run "terraform get" for the import directory`, "dir", baseDir, "module", name)
+	if err := meta.importTFs[importIdx].Get(ctx); err != nil {
+		return "", fmt.Errorf("error running terraform get: %s", err)
+	}
+	meta.importChildModules[importIdx][name] = true
+	return dir, nil
+}
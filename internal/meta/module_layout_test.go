@@ -0,0 +1,241 @@
+package meta
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Azure/aztfexport/internal/tfaddr"
+	"github.com/Azure/aztfexport/pkg/config"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLayoutModuleName(t *testing.T) {
+	item := ImportItem{
+		AzureResourceID: mustParseResourceId("/subscriptions/123/resourceGroups/1-RG.test/providers/Microsoft.Network/virtualNetworks/vnet1"),
+		TFAddr:          tfaddr.TFAddr{Type: "azurerm_virtual_network", Name: "res-0"},
+	}
+	require.Equal(t, "", layoutModuleName("", item))
+	require.Equal(t, "", layoutModuleName(config.ModuleLayoutNone, item))
+	require.Equal(t, "_1-rg_test", layoutModuleName(config.ModuleLayoutResourceGroup, item))
+	require.Equal(t, "azurerm_virtual_network", layoutModuleName(config.ModuleLayoutResourceType, item))
+	require.Equal(t, "network", layoutModuleName(config.ModuleLayoutProvider, item))
+
+	sub := ImportItem{AzureResourceID: mustParseResourceId("/subscriptions/123/providers/Microsoft.Network/networkWatchers/nw1")}
+	require.Equal(t, "", layoutModuleName(config.ModuleLayoutResourceGroup, sub))
+}
+
+func TestModuleLayout(t *testing.T) {
+	cfgs := ConfigInfos{
+		newConfigInfo(
+			"/subscriptions/123/resourceGroups/rg1",
+			"/subscriptions/123/resourceGroups/rg1",
+			"azurerm_resource_group.res-0",
+			`resource "azurerm_resource_group" "res-0" {
+  name = "rg1"
+}
+`,
+			nil,
+		),
+		newConfigInfo(
+			"/subscriptions/123/resourceGroups/rg2",
+			"/subscriptions/123/resourceGroups/rg2",
+			"azurerm_resource_group.res-1",
+			`resource "azurerm_resource_group" "res-1" {
+  name = "rg2"
+}
+`,
+			nil,
+		),
+		newConfigInfo(
+			"/subscriptions/123/resourceGroups/rg2/providers/Microsoft.Network/virtualNetworks/vnet1",
+			"/subscriptions/123/resourceGroups/rg2/providers/Microsoft.Network/virtualNetworks/vnet1",
+			"azurerm_virtual_network.res-2",
+			`resource "azurerm_virtual_network" "res-2" {
+  name                = "vnet1"
+  resource_group_name = "rg2"
+  peer_id             = "/subscriptions/123/resourceGroups/rg1"
+}
+`,
+			nil,
+		),
+		newConfigInfo(
+			"/subscriptions/123/providers/Microsoft.Network/networkWatchers/nw1",
+			"/subscriptions/123/providers/Microsoft.Network/networkWatchers/nw1",
+			"azurerm_network_watcher.res-3",
+			`resource "azurerm_network_watcher" "res-3" {
+  name    = "nw1"
+  vnet_id = "/subscriptions/123/resourceGroups/rg2/providers/Microsoft.Network/virtualNetworks/vnet1"
+}
+`,
+			nil,
+		),
+		newConfigInfo(
+			"/subscriptions/123/resourceGroups/rg1/providers/Microsoft.ManagedIdentity/userAssignedIdentities/id1",
+			"/subscriptions/123/resourceGroups/rg1/providers/Microsoft.ManagedIdentity/userAssignedIdentities/id1",
+			"azurerm_user_assigned_identity.res-4",
+			`resource "azurerm_user_assigned_identity" "res-4" {
+  name                = "id1"
+  resource_group_name = "rg1"
+  watcher_id          = "/subscriptions/123/providers/Microsoft.Network/networkWatchers/nw1"
+}
+`,
+			nil,
+		),
+	}
+
+	meta := baseMeta{moduleLayout: moduleLayout{layout: config.ModuleLayoutResourceGroup}}
+	wiring := newModuleWiring(meta.moduleLayout)
+	cfgs, err := meta.terraformMetaHook(cfgs, append(meta.configTransformers(), wiring.Wire)...)
+	require.NoError(t, err)
+
+	names, partitions := partitionConfigInfosByModule(meta.moduleLayout, cfgs)
+	require.Equal(t, []string{"", "rg1", "rg2"}, names)
+	require.Len(t, partitions[""], 1)
+	require.Len(t, partitions["rg1"], 2)
+	require.Len(t, partitions["rg2"], 2)
+
+	// The references to the resources in the other modules are wired through the variables of the child modules, or the module outputs in the root module.
+	require.Equal(t, `resource "azurerm_virtual_network" "res-2" {
+  name                = "vnet1"
+  resource_group_name = azurerm_resource_group.res-1.name
+  peer_id             = var.azurerm_resource_group_res-0_id
+}
+`, string(hclwrite.Format(partitions["rg2"][1].HCL.Bytes())))
+	require.Equal(t, `resource "azurerm_network_watcher" "res-3" {
+  name    = "nw1"
+  vnet_id = module.rg2.azurerm_virtual_network_res-2_id
+}
+`, string(hclwrite.Format(partitions[""][0].HCL.Bytes())))
+	require.Equal(t, `resource "azurerm_user_assigned_identity" "res-4" {
+  name                = "id1"
+  resource_group_name = azurerm_resource_group.res-0.name
+  watcher_id          = var.azurerm_network_watcher_res-3_id
+}
+`, string(hclwrite.Format(partitions["rg1"][1].HCL.Bytes())))
+
+	require.Equal(t, `variable "azurerm_network_watcher_res-3_id" {
+}
+`, string(hclwrite.Format(wiring.variablesFile("rg1").Bytes())))
+	of, err := wiring.outputsFile("rg1", map[string]bool{})
+	require.NoError(t, err)
+	require.Equal(t, `output "azurerm_resource_group_res-0_id" {
+  value = azurerm_resource_group.res-0.id
+}
+`, string(hclwrite.Format(of.Bytes())))
+	// The generated outputs are skipped.
+	of, err = wiring.outputsFile("rg2", map[string]bool{"azurerm_virtual_network_res-2_id": true})
+	require.NoError(t, err)
+	require.Len(t, of.Body().Blocks(), 0)
+
+	dir := t.TempDir()
+	meta.moduleDir = dir
+	meta.outputFormatter = hclFormatter{}
+	meta.outputFileNames = config.OutputFileNames{ModuleFileName: "modules.tf"}
+	require.NoError(t, meta.writeModuleCalls(names, wiring))
+	b, err := os.ReadFile(filepath.Join(dir, "modules.tf"))
+	require.NoError(t, err)
+	require.Equal(t, `module "rg1" {
+  source                           = "./modules/rg1"
+  azurerm_network_watcher_res-3_id = azurerm_network_watcher.res-3.id
+}
+
+module "rg2" {
+  source                          = "./modules/rg2"
+  azurerm_resource_group_res-0_id = module.rg1.azurerm_resource_group_res-0_id
+}
+`, string(b))
+
+	l := ImportList{cfgs[0].ImportItem, cfgs[2].ImportItem}
+	require.Equal(t, "module.rg1", meta.resourceModuleAddr(l[0]))
+	require.Equal(t, `import {
+  id = "/subscriptions/123/resourceGroups/rg1"
+  to = module.rg1.azurerm_resource_group.res-0
+}
+import {
+  id = "/subscriptions/123/resourceGroups/rg2/providers/Microsoft.Network/virtualNetworks/vnet1"
+  to = module.rg2.azurerm_virtual_network.res-2
+}
+`, string(hclwrite.Format(hclFormatter{}.ImportBlocks(meta.importBlocks(l)))))
+	require.JSONEq(t, `{
+  "import": [
+    {"id": "/subscriptions/123/resourceGroups/rg1", "to": "module.rg1.azurerm_resource_group.res-0"},
+    {"id": "/subscriptions/123/resourceGroups/rg2/providers/Microsoft.Network/virtualNetworks/vnet1", "to": "module.rg2.azurerm_virtual_network.res-2"}
+  ]
+}`, string(jsonFormatter{}.ImportBlocks(meta.importBlocks(l))))
+}
+
+func TestModuleWiring_DependsOn(t *testing.T) {
+	vnet := newConfigInfo(
+		"/subscriptions/123/resourceGroups/rg1/providers/Microsoft.Network/virtualNetworks/vnet1",
+		"/subscriptions/123/resourceGroups/rg1/providers/Microsoft.Network/virtualNetworks/vnet1",
+		"azurerm_virtual_network.res-0",
+		`resource "azurerm_virtual_network" "res-0" {
+  for_each = local.azurerm_virtual_network_res-0
+  name     = each.value.name
+}
+`,
+		nil,
+	)
+	vnet.ForEach = []ImportItem{vnet.ImportItem}
+	subnet := newConfigInfo(
+		"/subscriptions/123/resourceGroups/rg1/providers/Microsoft.Network/virtualNetworks/vnet1/subnets/subnet1",
+		"/subscriptions/123/resourceGroups/rg1/providers/Microsoft.Network/virtualNetworks/vnet1/subnets/subnet1",
+		"azurerm_subnet.res-1",
+		`resource "azurerm_subnet" "res-1" {
+  name                 = "subnet1"
+  virtual_network_name = azurerm_virtual_network.res-0["vnet1"].name
+  depends_on = [
+    azurerm_virtual_network.res-0,
+  ]
+}
+`,
+		nil,
+	)
+
+	wiring := newModuleWiring(moduleLayout{layout: config.ModuleLayoutResourceType})
+	cfgs, err := wiring.Wire(ConfigInfos{vnet, subnet})
+	require.NoError(t, err)
+	require.Equal(t, `resource "azurerm_subnet" "res-1" {
+  name                 = "subnet1"
+  virtual_network_name = var.azurerm_virtual_network_res-0_vnet1_name
+  depends_on = [
+    var.azurerm_virtual_network_res-0_id,
+  ]
+}
+`, string(hclwrite.Format(cfgs[1].HCL.Bytes())))
+
+	of, err := wiring.outputsFile("azurerm_virtual_network", map[string]bool{})
+	require.NoError(t, err)
+	require.Equal(t, `output "azurerm_virtual_network_res-0_id" {
+  value = { for k, v in azurerm_virtual_network.res-0 : k => v.id }
+}
+
+output "azurerm_virtual_network_res-0_vnet1_name" {
+  value = azurerm_virtual_network.res-0["vnet1"].name
+}
+`, string(hclwrite.Format(of.Bytes())))
+}
+
+func TestWriteModuleCalls(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "main.tf"), []byte(`module "rg1" {
+  source = "./modules/rg1"
+}
+`), 0644))
+
+	meta := baseMeta{
+		moduleDir:       dir,
+		outputFormatter: hclFormatter{},
+		outputFileNames: config.OutputFileNames{ModuleFileName: "modules.tf"},
+	}
+	require.NoError(t, meta.writeModuleCalls([]string{"", "rg1", "rg2"}, nil))
+
+	b, err := os.ReadFile(filepath.Join(dir, "modules.tf"))
+	require.NoError(t, err)
+	require.Equal(t, `module "rg2" {
+  source = "./modules/rg2"
+}
+`, string(b))
+}
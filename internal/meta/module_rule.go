@@ -0,0 +1,101 @@
+package meta
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+
+	"github.com/Azure/aztfexport/internal/spec"
+)
+
+var moduleRuleNameRegexp = regexp.MustCompile(`^[a-z_][a-z0-9_-]*$`)
+
+// ModuleRules groups the resources into the child modules by a list of rules for the rule module layout, which are evaluated in order.
+// The resource is generated into the child module named by the first matched rule, while the resources matching no rule are generated into the root module.
+// Multiple rules can name the same module, so that a module can group the resources of different conditions.
+//
+// The rule file is defined in either HCL or YAML (determined by the file extension, same as the spec file). In HCL:
+//
+//	module "network" {
+//	  azure_type = "Microsoft.Network/*"
+//	}
+//
+//	module "core" {
+//	  id_regex = "(?i)/resourceGroups/core-[^/]+/"
+//	}
+//
+// In YAML, the rules are listed under the "modules" key, with the module name specified by the "name" key.
+type ModuleRules struct {
+	Modules []ModuleRule `hcl:"module,block" yaml:"modules"`
+}
+
+// ModuleRule matches the resources that meet all its specified conditions, and groups them into the child module of its name.
+type ModuleRule struct {
+	// Name is the name of the child module, which consists of the lower case letters, digits, underscores and dashes, and doesn't start with a digit or a dash.
+	Name string `hcl:"name,label" yaml:"name"`
+	// TFType is the glob pattern of the TF resource type, e.g. "azurerm_*", which is matched case insensitively.
+	TFType string `hcl:"tf_type,optional" yaml:"tf_type"`
+	// AzureType is the glob pattern of the Azure resource type, e.g. "Microsoft.Network/*", which is matched case insensitively.
+	AzureType string `hcl:"azure_type,optional" yaml:"azure_type"`
+	// IdRegex is the regular expression that the Azure resource id matches.
+	IdRegex string `hcl:"id_regex,optional" yaml:"id_regex"`
+
+	idRegexp *regexp.Regexp
+}
+
+// ParseModuleRuleFile parses and validates the module rule file.
+func ParseModuleRuleFile(p string) (*ModuleRules, error) {
+	var rules ModuleRules
+	if err := spec.DecodeFile(p, &rules); err != nil {
+		return nil, err
+	}
+
+	for i := range rules.Modules {
+		if err := rules.Modules[i].init(); err != nil {
+			return nil, fmt.Errorf("invalid module rule %d in %s: %v", i, p, err)
+		}
+	}
+	return &rules, nil
+}
+
+func (rule *ModuleRule) init() error {
+	if !moduleRuleNameRegexp.MatchString(rule.Name) {
+		return fmt.Errorf("invalid module name %q: must match %s", rule.Name, moduleRuleNameRegexp)
+	}
+	for _, pattern := range []string{rule.TFType, rule.AzureType} {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid pattern %q: %v", pattern, err)
+		}
+	}
+	if rule.IdRegex != "" {
+		re, err := regexp.Compile(rule.IdRegex)
+		if err != nil {
+			return fmt.Errorf("invalid id_regex %q: %v", rule.IdRegex, err)
+		}
+		rule.idRegexp = re
+	}
+	return nil
+}
+
+// moduleName returns the module name of the first rule that matches the import item, or an empty string (i.e. the root module) if none matches.
+func (rules *ModuleRules) moduleName(item ImportItem) string {
+	for i := range rules.Modules {
+		if rule := &rules.Modules[i]; rule.matches(item) {
+			return rule.Name
+		}
+	}
+	return ""
+}
+
+func (rule *ModuleRule) matches(item ImportItem) bool {
+	if rule.TFType != "" && !globMatchFold(rule.TFType, item.TFAddr.Type) {
+		return false
+	}
+	if rule.AzureType != "" && (item.AzureResourceID == nil || !globMatchFold(rule.AzureType, item.AzureResourceID.TypeString())) {
+		return false
+	}
+	if rule.idRegexp != nil && (item.AzureResourceID == nil || !rule.idRegexp.MatchString(item.AzureResourceID.String())) {
+		return false
+	}
+	return true
+}
@@ -0,0 +1,63 @@
+package meta
+
+import (
+	"testing"
+
+	"github.com/Azure/aztfexport/internal/tfaddr"
+	"github.com/Azure/aztfexport/pkg/config"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseModuleRuleFile(t *testing.T) {
+	hclRules := `
+module "core" {
+  id_regex = "(?i)/resourceGroups/core-[^/]+/"
+}
+
+module "network" {
+  azure_type = "Microsoft.Network/*"
+}
+`
+	yamlRules := `
+modules:
+  - name: core
+    id_regex: "(?i)/resourceGroups/core-[^/]+/"
+  - name: network
+    azure_type: Microsoft.Network/*
+`
+	for _, p := range []string{
+		writeTestFile(t, "modules.hcl", hclRules),
+		writeTestFile(t, "modules.yaml", yamlRules),
+	} {
+		rules, err := ParseModuleRuleFile(p)
+		require.NoError(t, err, p)
+		require.Len(t, rules.Modules, 2, p)
+
+		layout := moduleLayout{layout: config.ModuleLayoutRule, rules: rules}
+		require.True(t, layout.enabled())
+		require.Equal(t, "core", layout.moduleName(ImportItem{
+			AzureResourceID: mustParseResourceId("/subscriptions/123/resourceGroups/core-rg/providers/Microsoft.Network/virtualNetworks/vnet1"),
+			TFAddr:          tfaddr.TFAddr{Type: "azurerm_virtual_network", Name: "res-0"},
+		}), p)
+		require.Equal(t, "network", layout.moduleName(ImportItem{
+			AzureResourceID: mustParseResourceId("/subscriptions/123/resourceGroups/rg1/providers/Microsoft.Network/virtualNetworks/vnet1"),
+			TFAddr:          tfaddr.TFAddr{Type: "azurerm_virtual_network", Name: "res-1"},
+		}), p)
+		// Matching no rule goes to the root module
+		require.Equal(t, "", layout.moduleName(ImportItem{
+			AzureResourceID: mustParseResourceId("/subscriptions/123/resourceGroups/rg1"),
+			TFAddr:          tfaddr.TFAddr{Type: "azurerm_resource_group", Name: "res-2"},
+		}), p)
+	}
+
+	invalids := map[string]string{
+		"invalid name":      "module \"Core\" {\n}\n",
+		"invalid glob":      "module \"core\" {\n  tf_type = \"[\"\n}\n",
+		"invalid id_regex":  "module \"core\" {\n  id_regex = \"(\"\n}\n",
+		"unknown attribute": "module \"core\" {\n  foo = \"bar\"\n}\n",
+	}
+	for name, content := range invalids {
+		_, err := ParseModuleRuleFile(writeTestFile(t, "modules.hcl", content))
+		require.Error(t, err, name)
+	}
+}
@@ -0,0 +1,50 @@
+package meta
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/Azure/aztfexport/internal/resmap"
+	"github.com/Azure/aztfexport/internal/tfaddr"
+)
+
+// writeMovedBlocks writes the moved blocks of the import list against the previous resource mapping to the moved block file.
+func (meta baseMeta) writeMovedBlocks(l ImportList) error {
+	b := meta.outputFormatter.MovedBlocks(movedBlocks(meta.previousMapping, meta.moduleLayout, l))
+	oMovedFile := filepath.Join(meta.moduleDir, meta.outputFileNames.MovedBlockFileName)
+	// #nosec G306
+	if err := os.WriteFile(oMovedFile, b, 0644); err != nil {
+		return fmt.Errorf("writing the moved blocks to %s: %v", oMovedFile, err)
+	}
+	return nil
+}
+
+// movedBlocks returns the moved blocks of the non-skipped import items, whose addresses are changed since the previous resource mapping.
+// The items are matched with the previous mapping entries by the Azure resource ids, case insensitively.
+// The resources that are retyped are not moved, as a resource can't be moved across resource types.
+// The previous addresses are assumed to be in the root module, as the resource mapping doesn't record the modules.
+func movedBlocks(previous resmap.ResourceMapping, layout moduleLayout, l ImportList) []movedBlock {
+	var out []movedBlock
+	idx := previous.Index()
+	for _, item := range l.NonSkipped() {
+		_, entity, ok := idx.Lookup(item.AzureResourceID.String())
+		if !ok || entity.ResourceType != item.TFAddr.Type {
+			continue
+		}
+		blk := movedBlock{
+			From:   tfaddr.TFAddr{Type: entity.ResourceType, Name: entity.ResourceName},
+			Module: layout.moduleName(item),
+			TFAddr: item.TFAddr,
+		}
+		if blk.From.String() == blk.to() {
+			continue
+		}
+		out = append(out, blk)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].From.String() < out[j].From.String()
+	})
+	return out
+}
@@ -0,0 +1,91 @@
+package meta
+
+import (
+	"testing"
+
+	"github.com/Azure/aztfexport/internal/resmap"
+	"github.com/Azure/aztfexport/internal/tfaddr"
+	"github.com/Azure/aztfexport/pkg/config"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMovedBlocks(t *testing.T) {
+	previous := resmap.ResourceMapping{
+		"/subscriptions/123/resourceGroups/rg1": {
+			ResourceId:   "/subscriptions/123/resourceGroups/rg1",
+			ResourceType: "azurerm_resource_group",
+			ResourceName: "res-0",
+		},
+		"/subscriptions/123/resourceGroups/rg1/providers/Microsoft.Network/virtualNetworks/vnet1": {
+			ResourceId:   "/subscriptions/123/resourceGroups/rg1/providers/Microsoft.Network/virtualNetworks/vnet1",
+			ResourceType: "azurerm_virtual_network",
+			ResourceName: "vnet1",
+		},
+		"/subscriptions/123/resourceGroups/rg1/providers/Microsoft.Compute/virtualMachines/vm1": {
+			ResourceId:   "/subscriptions/123/resourceGroups/rg1/providers/Microsoft.Compute/virtualMachines/vm1",
+			ResourceType: "azurerm_virtual_machine",
+			ResourceName: "res-1",
+		},
+		"/subscriptions/123/resourceGroups/rg1/providers/Microsoft.Network/networkSecurityGroups/nsg1": {
+			ResourceId:   "/subscriptions/123/resourceGroups/rg1/providers/Microsoft.Network/networkSecurityGroups/nsg1",
+			ResourceType: "azurerm_network_security_group",
+			ResourceName: "res-2",
+		},
+	}
+	l := ImportList{
+		// Renamed, with the id matched case insensitively
+		{
+			AzureResourceID: mustParseID(t, "/subscriptions/123/resourcegroups/RG1"),
+			TFAddr:          tfaddr.TFAddr{Type: "azurerm_resource_group", Name: "rg1"},
+		},
+		// Not renamed
+		{
+			AzureResourceID: mustParseID(t, "/subscriptions/123/resourceGroups/rg1/providers/Microsoft.Network/virtualNetworks/vnet1"),
+			TFAddr:          tfaddr.TFAddr{Type: "azurerm_virtual_network", Name: "vnet1"},
+		},
+		// Retyped
+		{
+			AzureResourceID: mustParseID(t, "/subscriptions/123/resourceGroups/rg1/providers/Microsoft.Compute/virtualMachines/vm1"),
+			TFAddr:          tfaddr.TFAddr{Type: "azurerm_linux_virtual_machine", Name: "vm1"},
+		},
+		// Skipped
+		{
+			AzureResourceID: mustParseID(t, "/subscriptions/123/resourceGroups/rg1/providers/Microsoft.Network/networkSecurityGroups/nsg1"),
+		},
+		// Newly added
+		{
+			AzureResourceID: mustParseID(t, "/subscriptions/123/resourceGroups/rg1/providers/Microsoft.Network/virtualNetworks/vnet2"),
+			TFAddr:          tfaddr.TFAddr{Type: "azurerm_virtual_network", Name: "vnet2"},
+		},
+	}
+
+	require.Equal(t, []movedBlock{
+		{
+			From:   tfaddr.TFAddr{Type: "azurerm_resource_group", Name: "res-0"},
+			TFAddr: tfaddr.TFAddr{Type: "azurerm_resource_group", Name: "rg1"},
+		},
+	}, movedBlocks(previous, moduleLayout{layout: config.ModuleLayoutNone}, l))
+
+	// The resources moved into the child modules of the module layout
+	blks := movedBlocks(previous, moduleLayout{layout: config.ModuleLayoutResourceGroup}, l)
+	require.Len(t, blks, 2)
+	require.Equal(t, "module.rg1.azurerm_resource_group.rg1", blks[0].to())
+	require.Equal(t, "module.rg1.azurerm_virtual_network.vnet1", blks[1].to())
+
+	require.Equal(t, `moved {
+  from = azurerm_resource_group.res-0
+  to   = module.rg1.azurerm_resource_group.rg1
+}
+
+moved {
+  from = azurerm_virtual_network.vnet1
+  to   = module.rg1.azurerm_virtual_network.vnet1
+}
+`, string(hclFormatter{}.MovedBlocks(blks)))
+	require.JSONEq(t, `{
+  "moved": [
+    {"from": "azurerm_resource_group.res-0", "to": "module.rg1.azurerm_resource_group.rg1"},
+    {"from": "azurerm_virtual_network.vnet1", "to": "module.rg1.azurerm_virtual_network.vnet1"}
+  ]
+}`, string(jsonFormatter{}.MovedBlocks(blks)))
+}
@@ -2,6 +2,7 @@ package meta
 
 import (
 	"fmt"
+	"regexp"
 	"strconv"
 	"strings"
 	"unicode"
@@ -11,12 +12,16 @@ import (
 )
 
 const (
-	phType      = "{type}"       // last Azure resource type segment, e.g. "virtual_machines"
-	phRP        = "{rp}"         // Azure resource provider namespace, e.g. "microsoft_compute"
-	phName      = "{name}"       // last name segment of the Azure resource id
-	phRootScope = "{root_scope}" // last name of the root scope (e.g. resource group name)
+	phType      = "{type}"        // last Azure resource type segment, e.g. "virtual_machines"
+	phRP        = "{rp}"          // Azure resource provider namespace, e.g. "microsoft_compute"
+	phName      = "{name}"        // last name segment of the Azure resource id
+	phRootScope = "{root_scope}"  // last name of the root scope (e.g. resource group name)
+	phParent    = "{parent_name}" // name of the parent resource, or the root scope for a top level resource
 )
 
+// phTag matches the "{tag:<key>}" placeholder, which expands to the value of the resource's tag of the key.
+var phTag = regexp.MustCompile(`\{tag:([^}]*)\}`)
+
 const (
 	// idxOptional expands to an incremental index only when the same name is
 	// shared by more than one resource, in which case the index starts from 2
@@ -30,43 +35,115 @@ const (
 var idxChars = string([]rune{idxOptional, idxAlways})
 
 // ValidateNamePattern validates the resource name pattern, which can contain at
-// most one index character, either `*` or `+`.
+// most one index character, either `*` or `+`. The tag placeholders must specify
+// the tag key.
 func ValidateNamePattern(pattern string) error {
 	if n := strings.Count(pattern, string(idxOptional)) + strings.Count(pattern, string(idxAlways)); n > 1 {
 		return fmt.Errorf("the name pattern %q contains %d %q/%q, while at most one (exclusively) is allowed", pattern, n, string(idxOptional), string(idxAlways))
 	}
+	for _, m := range phTag.FindAllStringSubmatch(pattern, -1) {
+		if strings.TrimSpace(m[1]) == "" {
+			return fmt.Errorf("the name pattern %q contains a tag placeholder without the tag key", pattern)
+		}
+	}
 	return nil
 }
 
-type nameExpander struct {
+// namePattern is a parsed name pattern, which is split by the index character.
+type namePattern struct {
 	// prefix and suffix are the pattern segments before/after the index character.
 	prefix string
 	suffix string
 	// always indicates the index character is `+`, rather than `*`.
 	always bool
-	// counts counts the name per resource type.
-	counts map[string]map[string]int
 }
 
-func newNameExpander(pattern string) *nameExpander {
+func parseNamePattern(pattern string) namePattern {
 	// An `*` is implicitly appended at the end when no index character is specified.
 	if !strings.ContainsAny(pattern, idxChars) {
 		pattern += string(idxOptional)
 	}
 
 	pos := strings.IndexAny(pattern, idxChars)
-	return &nameExpander{
+	return namePattern{
 		prefix: pattern[:pos],
 		suffix: pattern[pos+1:],
 		always: rune(pattern[pos]) == idxAlways,
-		counts: map[string]map[string]int{},
 	}
 }
 
-// Expand returns the resource name produced by applying the pattern to the
-// given TF resource.
+type nameExpander struct {
+	pattern namePattern
+	// semantic indicates the resources (not matched by the policy) are named semantically, rather than by the pattern.
+	semantic bool
+	// policy is the optional naming policy, whose first matched rule takes precedence over the pattern.
+	policy *NamingPolicy
+	// counts counts the name per resource type.
+	counts map[string]map[string]int
+	// taken records the produced names per resource type.
+	taken map[string]map[string]bool
+}
+
+// newNameExpander returns a name expander of the pattern. An empty pattern indicates the semantic naming, see semanticBaseName for details.
+func newNameExpander(pattern string) *nameExpander {
+	return &nameExpander{
+		pattern:  parseNamePattern(pattern),
+		semantic: pattern == "",
+		counts:   map[string]map[string]int{},
+		taken:    map[string]map[string]bool{},
+	}
+}
+
+// newNameExpanderWithPolicy returns a name expander of the pattern, together with the naming policy read from the policy file, if specified.
+func newNameExpanderWithPolicy(pattern, policyFile string) (*nameExpander, error) {
+	e := newNameExpander(pattern)
+	if policyFile != "" {
+		policy, err := ParseNamingPolicyFile(policyFile)
+		if err != nil {
+			return nil, err
+		}
+		e.policy = policy
+	}
+	return e, nil
+}
+
+// Expand returns the resource name of the given TF resource, see ExpandAll for details.
 func (e *nameExpander) Expand(res resourceset.TFResource) string {
-	prefix, suffix := expandPlaceholders(e.prefix, res), expandPlaceholders(e.suffix, res)
+	return e.ExpandAll([]resourceset.TFResource{res})[0]
+}
+
+// ExpandAll returns the resource names of the given TF resources, in the same order.
+//
+// The name is produced by applying the pattern (or the template of the first
+// matched naming policy rule) to the resource. The names are de-duplicated per
+// TF resource type by the index, regardless of which pattern they are produced from.
+//
+// For the semantic naming, the resources that don't match any naming policy
+// rule are named semantically, which are suffixed by the hash of the resource
+// id instead, so that the names are independent of the other resources.
+func (e *nameExpander) ExpandAll(rl []resourceset.TFResource) []string {
+	names := make([]string, len(rl))
+	var semantic []int
+	for i, res := range rl {
+		pattern, ok := e.pattern, !e.semantic
+		if e.policy != nil {
+			if rule := e.policy.match(res); rule != nil {
+				pattern, ok = rule.pattern, true
+			}
+		}
+		if !ok {
+			semantic = append(semantic, i)
+			continue
+		}
+		names[i] = e.expandPattern(pattern, res)
+		e.take(res.TFType, names[i])
+	}
+	e.expandSemantic(rl, semantic, names)
+	return names
+}
+
+func (e *nameExpander) expandPattern(pattern namePattern, res resourceset.TFResource) string {
+	prefix, suffix := expandPlaceholders(pattern.prefix, res), expandPlaceholders(pattern.suffix, res)
 
 	key := prefix + "\x00" + suffix
 
@@ -77,13 +154,20 @@ func (e *nameExpander) Expand(res resourceset.TFResource) string {
 	ic[key]++
 
 	var idx string
-	if n := ic[key]; e.always || n > 1 {
+	if n := ic[key]; pattern.always || n > 1 {
 		idx = strconv.Itoa(n)
 	}
 
 	return toTFName(prefix + idx + suffix)
 }
 
+func (e *nameExpander) take(tfType, name string) {
+	if e.taken[tfType] == nil {
+		e.taken[tfType] = map[string]bool{}
+	}
+	e.taken[tfType][name] = true
+}
+
 func expandPlaceholders(pattern string, res resourceset.TFResource) string {
 	id := res.AzureId
 
@@ -97,9 +181,16 @@ func expandPlaceholders(pattern string, res resourceset.TFResource) string {
 	if strings.Contains(out, phName) {
 		out = strings.ReplaceAll(out, phName, snakeCase(lastSegment(id.Names())))
 	}
+	if strings.Contains(out, phParent) {
+		out = strings.ReplaceAll(out, phParent, snakeCase(parentName(id)))
+	}
 	if strings.Contains(out, phRootScope) {
 		out = strings.ReplaceAll(out, phRootScope, snakeCase(rootScopeName(id)))
 	}
+	out = phTag.ReplaceAllStringFunc(out, func(s string) string {
+		key := phTag.FindStringSubmatch(s)[1]
+		return snakeCase(lookupTag(res.Tags, key))
+	})
 	return out
 }
 
@@ -110,6 +201,15 @@ func lastSegment(segs []string) string {
 	return segs[len(segs)-1]
 }
 
+// parentName returns the name of the parent resource of the resource id,
+// which is the root scope name for a top level resource.
+func parentName(id armid.ResourceId) string {
+	if names := id.Names(); len(names) > 1 {
+		return names[len(names)-2]
+	}
+	return rootScopeName(id)
+}
+
 // rootScopeName returns a short, identifier-friendly representation of the
 // root scope of the resource id (e.g. the resource group name, the
 // subscription id, or the management group name).
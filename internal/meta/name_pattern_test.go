@@ -151,6 +151,37 @@ func TestNameExpander(t *testing.T) {
 		}
 	})
 
+	t.Run("parent_name-placeholder", func(t *testing.T) {
+		subnet := resourceset.TFResource{
+			AzureId: mustParseID(t, "/subscriptions/00000000-0000-0000-0000-000000000000/resourceGroups/myRg/providers/Microsoft.Network/virtualNetworks/myvnet/subnets/default"),
+			TFType:  "azurerm_subnet",
+		}
+		e := newNameExpander("{parent_name}_{name}")
+		got := []string{e.Expand(subnet), e.Expand(vnet)}
+		// The parent of a top level resource is its root scope.
+		want := []string{"myvnet_default", "my_rg_myvnet"}
+		for i := range got {
+			if got[i] != want[i] {
+				t.Errorf("[%d] = %q, want %q", i, got[i], want[i])
+			}
+		}
+	})
+
+	t.Run("tag-placeholder", func(t *testing.T) {
+		tagged1, tagged2 := vm1, vm2
+		tagged1.Tags = map[string]string{"Component": "Web Frontend"}
+		tagged2.Tags = map[string]string{"component": "web-frontend"}
+		e := newNameExpander("{tag:component}_{type}")
+		got := []string{e.Expand(tagged1), e.Expand(tagged2), e.Expand(vnet)}
+		// The tag key is matched case insensitively, and the missing tag expands to empty.
+		want := []string{"web_frontend_virtual_machines", "web_frontend_virtual_machines2", "_virtual_networks"}
+		for i := range got {
+			if got[i] != want[i] {
+				t.Errorf("[%d] = %q, want %q", i, got[i], want[i])
+			}
+		}
+	})
+
 	t.Run("sanitizes-invalid-chars", func(t *testing.T) {
 		e := newNameExpander("bad name!*")
 		got := e.Expand(vm1)
@@ -164,3 +195,20 @@ func TestNameExpander(t *testing.T) {
 		}
 	})
 }
+
+func TestValidateNamePattern(t *testing.T) {
+	cases := []struct {
+		pattern string
+		ok      bool
+	}{
+		{"res-+", true},
+		{"{tag:component}-{type}*", true},
+		{"res-*+", false},
+		{"{tag:}-{type}", false},
+	}
+	for _, c := range cases {
+		if err := ValidateNamePattern(c.pattern); (err == nil) != c.ok {
+			t.Errorf("ValidateNamePattern(%q) = %v, want ok: %t", c.pattern, err, c.ok)
+		}
+	}
+}
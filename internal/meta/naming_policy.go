@@ -0,0 +1,129 @@
+package meta
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/Azure/aztfexport/internal/resourceset"
+	"github.com/Azure/aztfexport/internal/spec"
+)
+
+// NamingPolicy maps the resources to the name templates by a list of rules, which are evaluated in order.
+// The template of the first matched rule is used to name the resource, while the resources matching no rule
+// are named by the name pattern. The rules don't compose, i.e. a resource matching multiple rules is only named by the first one,
+// hence the more specific rules shall come first.
+//
+// The policy file is defined in either HCL or YAML (determined by the file extension, same as the spec file). In HCL:
+//
+//	rule {
+//	  tf_type  = "azurerm_subnet"
+//	  id_regex = "(?i)/resourceGroups/core-[^/]+/"
+//	  template = "core_{parent_name}_{name}"
+//	}
+//
+//	rule {
+//	  tf_type  = "azurerm_subnet"
+//	  template = "{parent_name}_{name}"
+//	}
+//
+//	rule {
+//	  id_regex = "(?i)/resourceGroups/core-[^/]+/"
+//	  template = "core_{name}"
+//	}
+//
+// In YAML, the rules are listed under the "rules" key.
+type NamingPolicy struct {
+	Rules []NamingRule `hcl:"rule,block" yaml:"rules"`
+}
+
+// NamingRule matches the resources that meet all its specified conditions, and names them by its template.
+type NamingRule struct {
+	// TFType is the glob pattern of the TF resource type, e.g. "azurerm_*", which is matched case insensitively.
+	TFType string `hcl:"tf_type,optional" yaml:"tf_type"`
+	// AzureType is the glob pattern of the Azure resource type, e.g. "Microsoft.Network/virtualNetworks/subnets", which is matched case insensitively.
+	AzureType string `hcl:"azure_type,optional" yaml:"azure_type"`
+	// IdRegex is the regular expression that the Azure resource id matches.
+	IdRegex string `hcl:"id_regex,optional" yaml:"id_regex"`
+	// Tags are the tags (key to value) that the resource must have, which are matched case insensitively. An empty value matches any value of the tag.
+	Tags map[string]string `hcl:"tags,optional" yaml:"tags"`
+	// Template is the name template, which has the same syntax as the name pattern.
+	Template string `hcl:"template" yaml:"template"`
+
+	idRegexp *regexp.Regexp
+	pattern  namePattern
+}
+
+// ParseNamingPolicyFile parses and validates the naming policy file.
+func ParseNamingPolicyFile(p string) (*NamingPolicy, error) {
+	var policy NamingPolicy
+	if err := spec.DecodeFile(p, &policy); err != nil {
+		return nil, err
+	}
+
+	for i := range policy.Rules {
+		if err := policy.Rules[i].init(); err != nil {
+			return nil, fmt.Errorf("invalid naming rule %d in %s: %v", i, p, err)
+		}
+	}
+	return &policy, nil
+}
+
+func (rule *NamingRule) init() error {
+	if rule.Template == "" {
+		return fmt.Errorf("template is not specified")
+	}
+	if err := ValidateNamePattern(rule.Template); err != nil {
+		return err
+	}
+	for _, pattern := range []string{rule.TFType, rule.AzureType} {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid pattern %q: %v", pattern, err)
+		}
+	}
+	if rule.IdRegex != "" {
+		re, err := regexp.Compile(rule.IdRegex)
+		if err != nil {
+			return fmt.Errorf("invalid id_regex %q: %v", rule.IdRegex, err)
+		}
+		rule.idRegexp = re
+	}
+	rule.pattern = parseNamePattern(rule.Template)
+	return nil
+}
+
+// match returns the first rule that matches the resource, or nil if none matches.
+func (policy *NamingPolicy) match(res resourceset.TFResource) *NamingRule {
+	for i := range policy.Rules {
+		if rule := &policy.Rules[i]; rule.matches(res) {
+			return rule
+		}
+	}
+	return nil
+}
+
+func (rule *NamingRule) matches(res resourceset.TFResource) bool {
+	if rule.TFType != "" && !globMatchFold(rule.TFType, res.TFType) {
+		return false
+	}
+	if rule.AzureType != "" && (res.AzureId == nil || !globMatchFold(rule.AzureType, res.AzureId.TypeString())) {
+		return false
+	}
+	if rule.idRegexp != nil && (res.AzureId == nil || !rule.idRegexp.MatchString(res.AzureId.String())) {
+		return false
+	}
+	for k, v := range rule.Tags {
+		tv, ok := lookupTagOK(res.Tags, k)
+		if !ok || (v != "" && !strings.EqualFold(tv, v)) {
+			return false
+		}
+	}
+	return true
+}
+
+// globMatchFold reports whether the value matches the glob pattern case insensitively.
+func globMatchFold(pattern, v string) bool {
+	ok, _ := path.Match(strings.ToLower(pattern), strings.ToLower(v))
+	return ok
+}
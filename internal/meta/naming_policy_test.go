@@ -0,0 +1,150 @@
+package meta
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Azure/aztfexport/internal/resourceset"
+	"github.com/stretchr/testify/require"
+)
+
+func writeTestFile(t *testing.T, name, content string) string {
+	t.Helper()
+	p := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(p, []byte(content), 0644))
+	return p
+}
+
+func TestParseNamingPolicyFile(t *testing.T) {
+	hclPolicy := `
+rule {
+  tf_type  = "azurerm_subnet"
+  template = "{parent_name}_{name}"
+}
+
+rule {
+  azure_type = "Microsoft.Compute/*"
+  tags       = { env = "" }
+  template   = "{tag:env}_{name}"
+}
+`
+	yamlPolicy := `
+rules:
+  - tf_type: azurerm_subnet
+    template: "{parent_name}_{name}"
+  - azure_type: Microsoft.Compute/*
+    tags:
+      env: ""
+    template: "{tag:env}_{name}"
+`
+	for _, p := range []string{
+		writeTestFile(t, "policy.hcl", hclPolicy),
+		writeTestFile(t, "policy.yaml", yamlPolicy),
+	} {
+		policy, err := ParseNamingPolicyFile(p)
+		require.NoError(t, err, p)
+		require.Len(t, policy.Rules, 2, p)
+		require.Equal(t, "azurerm_subnet", policy.Rules[0].TFType, p)
+		require.Equal(t, "Microsoft.Compute/*", policy.Rules[1].AzureType, p)
+		require.Equal(t, map[string]string{"env": ""}, policy.Rules[1].Tags, p)
+		require.Equal(t, "{tag:env}_{name}", policy.Rules[1].Template, p)
+	}
+
+	invalids := map[string]string{
+		"no template":        "rule {\n  tf_type = \"azurerm_subnet\"\n}\n",
+		"invalid template":   "rule {\n  template = \"{name}*+\"\n}\n",
+		"invalid glob":       "rule {\n  tf_type  = \"[\"\n  template = \"{name}\"\n}\n",
+		"invalid id_regex":   "rule {\n  id_regex = \"(\"\n  template = \"{name}\"\n}\n",
+		"unknown attribute":  "rule {\n  foo      = \"bar\"\n  template = \"{name}\"\n}\n",
+		"unknown yaml field": "rules:\n  - foo: bar\n    template: \"{name}\"\n",
+	}
+	for name, content := range invalids {
+		ext := ".hcl"
+		if name == "unknown yaml field" {
+			ext = ".yaml"
+		}
+		_, err := ParseNamingPolicyFile(writeTestFile(t, "policy"+ext, content))
+		require.Error(t, err, name)
+	}
+}
+
+func TestNamingPolicyMatch(t *testing.T) {
+	policy, err := ParseNamingPolicyFile(writeTestFile(t, "policy.hcl", `
+rule {
+  tf_type  = "azurerm_subnet"
+  template = "subnet_{name}"
+}
+
+rule {
+  azure_type = "microsoft.compute/virtualmachines"
+  tags       = { Env = "Prod" }
+  template   = "prod_vm_{name}"
+}
+
+rule {
+  id_regex = "(?i)/resourceGroups/core-[^/]+/"
+  template = "core_{name}"
+}
+`))
+	require.NoError(t, err)
+
+	subnet := resourceset.TFResource{
+		AzureId: mustParseID(t, "/subscriptions/00000000-0000-0000-0000-000000000000/resourceGroups/myRg/providers/Microsoft.Network/virtualNetworks/myvnet/subnets/default"),
+		TFType:  "azurerm_subnet",
+	}
+	prodVM := resourceset.TFResource{
+		AzureId: mustParseID(t, "/subscriptions/00000000-0000-0000-0000-000000000000/resourceGroups/myRg/providers/Microsoft.Compute/virtualMachines/vm1"),
+		TFType:  "azurerm_linux_virtual_machine",
+		Tags:    map[string]string{"env": "prod"},
+	}
+	devVM := resourceset.TFResource{
+		AzureId: mustParseID(t, "/subscriptions/00000000-0000-0000-0000-000000000000/resourceGroups/myRg/providers/Microsoft.Compute/virtualMachines/vm2"),
+		TFType:  "azurerm_linux_virtual_machine",
+		Tags:    map[string]string{"env": "dev"},
+	}
+	coreVnet := resourceset.TFResource{
+		AzureId: mustParseID(t, "/subscriptions/00000000-0000-0000-0000-000000000000/resourceGroups/core-network/providers/Microsoft.Network/virtualNetworks/vnet1"),
+		TFType:  "azurerm_virtual_network",
+	}
+	coreSubnet := resourceset.TFResource{
+		AzureId: mustParseID(t, "/subscriptions/00000000-0000-0000-0000-000000000000/resourceGroups/core-network/providers/Microsoft.Network/virtualNetworks/vnet1/subnets/default"),
+		TFType:  "azurerm_subnet",
+	}
+
+	require.Equal(t, "subnet_{name}", policy.match(subnet).Template)
+	require.Equal(t, "prod_vm_{name}", policy.match(prodVM).Template)
+	require.Nil(t, policy.match(devVM))
+	require.Equal(t, "core_{name}", policy.match(coreVnet).Template)
+	// The rules don't compose, only the first matched rule applies.
+	require.Equal(t, "subnet_{name}", policy.match(coreSubnet).Template)
+}
+
+func TestNameExpanderWithPolicy(t *testing.T) {
+	p := writeTestFile(t, "policy.hcl", `
+rule {
+  id_regex = "(?i)/resourceGroups/core-[^/]+/"
+  template = "core_{type}"
+}
+`)
+	e, err := newNameExpanderWithPolicy("{type}", p)
+	require.NoError(t, err)
+
+	vnet1 := resourceset.TFResource{
+		AzureId: mustParseID(t, "/subscriptions/00000000-0000-0000-0000-000000000000/resourceGroups/core-network/providers/Microsoft.Network/virtualNetworks/vnet1"),
+		TFType:  "azurerm_virtual_network",
+	}
+	vnet2 := resourceset.TFResource{
+		AzureId: mustParseID(t, "/subscriptions/00000000-0000-0000-0000-000000000000/resourceGroups/core-shared/providers/Microsoft.Network/virtualNetworks/vnet2"),
+		TFType:  "azurerm_virtual_network",
+	}
+	vnet3 := resourceset.TFResource{
+		AzureId: mustParseID(t, "/subscriptions/00000000-0000-0000-0000-000000000000/resourceGroups/app/providers/Microsoft.Network/virtualNetworks/vnet3"),
+		TFType:  "azurerm_virtual_network",
+	}
+	// The names produced by the policy rules and the pattern are de-duplicated together.
+	require.Equal(t, []string{"core_virtual_networks", "core_virtual_networks2", "virtual_networks"}, []string{e.Expand(vnet1), e.Expand(vnet2), e.Expand(vnet3)})
+
+	_, err = newNameExpanderWithPolicy("{type}", filepath.Join(t.TempDir(), "not-exist.hcl"))
+	require.Error(t, err)
+}
@@ -0,0 +1,72 @@
+package meta
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+)
+
+var outputNameInvalidChars = regexp.MustCompile(`[^a-zA-Z0-9_-]+`)
+
+// ParseOutputAttribute parses the attribute path (e.g. "identity[0].principal_id") of a resource into a relative traversal.
+func ParseOutputAttribute(attr string) (hcl.Traversal, error) {
+	traversal, diags := hclsyntax.ParseTraversalAbs([]byte(attr), "", hcl.InitialPos)
+	if diags.HasErrors() {
+		return nil, fmt.Errorf("parsing attribute %q: %v", attr, diags.Error())
+	}
+	// The root name is the first attribute of the resource
+	rel := hcl.Traversal{hcl.TraverseAttr{Name: traversal.RootName()}}
+	return append(rel, traversal[1:]...), nil
+}
+
+// outputsFile returns the HCL file of the output blocks of the configs. Each resource has an output of its id,
+// plus the outputs of the extra attributes specified for its TF resource type. The key of the attributes is the TF resource type.
+// The output is named as "<type>_<name>_<attribute>". For a for_each resource, the output is a map of the attribute keyed by the instance keys.
+func outputsFile(cfgs ConfigInfos, attrs map[string][]string) (*hclwrite.File, error) {
+	f := hclwrite.NewFile()
+	body := f.Body()
+	for _, cfg := range cfgs {
+		for _, attr := range append([]string{"id"}, attrs[cfg.TFAddr.Type]...) {
+			rel, err := ParseOutputAttribute(attr)
+			if err != nil {
+				return nil, err
+			}
+			name := outputName(fmt.Sprintf("%s_%s_%s", cfg.TFAddr.Type, cfg.TFAddr.Name, attr))
+			if len(body.Blocks()) != 0 {
+				body.AppendNewline()
+			}
+			blk := body.AppendNewBlock("output", []string{name})
+
+			if len(cfg.ForEach) != 0 {
+				src := fmt.Sprintf("{ for k, v in %s : k => v%s }", cfg.TFAddr, traversalString(rel))
+				expr, diags := hclwrite.ParseConfig([]byte("value = "+src+"\n"), "", hcl.InitialPos)
+				if diags.HasErrors() {
+					return nil, fmt.Errorf("building the output value of %s: %v", attr, diags.Error())
+				}
+				blk.Body().SetAttributeRaw("value", expr.Body().GetAttribute("value").Expr().BuildTokens(nil))
+				continue
+			}
+
+			traversal := append(hcl.Traversal{
+				hcl.TraverseRoot{Name: cfg.TFAddr.Type},
+				hcl.TraverseAttr{Name: cfg.TFAddr.Name},
+			}, rel...)
+			blk.Body().SetAttributeTraversal("value", traversal)
+		}
+	}
+	return f, nil
+}
+
+// outputName returns the valid output name of the raw name, by replacing the invalid characters with underscores.
+func outputName(raw string) string {
+	return strings.Trim(outputNameInvalidChars.ReplaceAllString(raw, "_"), "_")
+}
+
+// traversalString returns the source of the relative traversal, e.g. `.identity[0].principal_id`.
+func traversalString(rel hcl.Traversal) string {
+	return string(hclwrite.TokensForTraversal(append(hcl.Traversal{hcl.TraverseRoot{Name: "_"}}, rel...)).Bytes()[1:])
+}
@@ -0,0 +1,487 @@
+package meta
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/Azure/aztfexport/internal/tfaddr"
+	"github.com/Azure/aztfexport/pkg/config"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// outputFormatter renders the generated config infos and import blocks in a certain Terraform configuration syntax.
+type outputFormatter interface {
+	// Config renders the config infos, whose dependencies are already applied.
+	Config(cfgs ConfigInfos) ([]byte, error)
+	// File renders the HCL file that contains blocks other than the resources, e.g. the variable blocks.
+	File(f *hclwrite.File) ([]byte, error)
+	// ImportBlocks renders the import blocks.
+	ImportBlocks(blks []importBlock) []byte
+	// MovedBlocks renders the moved blocks.
+	MovedBlocks(blks []movedBlock) []byte
+	// AppendToFile appends the rendered content to the file, which will be created if not exists.
+	AppendToFile(path string, b []byte) error
+}
+
+// importBlock is an import block in the root module.
+type importBlock struct {
+	ID string
+	// The child module (of the root module) that the resource belongs to. This is empty for the resource in the root module.
+	Module string
+	TFAddr tfaddr.TFAddr
+}
+
+// to returns the address of the resource to import to, e.g. "module.rg1.azurerm_virtual_network.res-1".
+func (blk importBlock) to() string {
+	return moduleResourceAddr(blk.Module, blk.TFAddr)
+}
+
+// toTraversal returns the traversal of the address of the resource to import to.
+func (blk importBlock) toTraversal() hcl.Traversal {
+	return moduleResourceTraversal(blk.Module, blk.TFAddr)
+}
+
+// movedBlock is a moved block in the root module, which moves the resource from its address of a previous export.
+type movedBlock struct {
+	From tfaddr.TFAddr
+	// The child module (of the root module) that the resource belongs to. This is empty for the resource in the root module.
+	Module string
+	TFAddr tfaddr.TFAddr
+}
+
+// to returns the address of the resource to move to, e.g. "module.rg1.azurerm_virtual_network.res-1".
+func (blk movedBlock) to() string {
+	return moduleResourceAddr(blk.Module, blk.TFAddr)
+}
+
+// moduleResourceAddr returns the address of the resource in the child module, or in the root module if the module is empty.
+func moduleResourceAddr(module string, addr tfaddr.TFAddr) string {
+	if module == "" {
+		return addr.String()
+	}
+	return "module." + module + "." + addr.String()
+}
+
+// moduleResourceTraversal returns the traversal of the address of the resource in the child module, or in the root module if the module is empty.
+func moduleResourceTraversal(module string, addr tfaddr.TFAddr) hcl.Traversal {
+	traversal := addrTraversal(addr)
+	if module == "" {
+		return traversal
+	}
+	return append(hcl.Traversal{
+		hcl.TraverseRoot{Name: "module"},
+		hcl.TraverseAttr{Name: module},
+		hcl.TraverseAttr{Name: addr.Type},
+	}, traversal[1:]...)
+}
+
+func newOutputFormatter(format config.OutputFormat) outputFormatter {
+	switch format {
+	case config.OutputFormatJSON:
+		return jsonFormatter{}
+	default:
+		return hclFormatter{}
+	}
+}
+
+type hclFormatter struct{}
+
+var _ outputFormatter = hclFormatter{}
+
+func (hclFormatter) Config(cfgs ConfigInfos) ([]byte, error) {
+	buf := bytes.NewBuffer([]byte{})
+	for _, cfg := range cfgs {
+		if _, err := cfg.DumpHCL(buf); err != nil {
+			return nil, err
+		}
+		buf.Write([]byte("\n"))
+	}
+	return buf.Bytes(), nil
+}
+
+func (hclFormatter) File(f *hclwrite.File) ([]byte, error) {
+	return hclwrite.Format(f.Bytes()), nil
+}
+
+func (hclFormatter) ImportBlocks(blks []importBlock) []byte {
+	f := hclwrite.NewFile()
+	body := f.Body()
+	for _, ib := range blks {
+		// The import block
+		blk := hclwrite.NewBlock("import", nil)
+		blk.Body().SetAttributeValue("id", cty.StringVal(ib.ID))
+		blk.Body().SetAttributeTraversal("to", ib.toTraversal())
+		body.AppendBlock(blk)
+	}
+	return f.Bytes()
+}
+
+func (hclFormatter) MovedBlocks(blks []movedBlock) []byte {
+	f := hclwrite.NewFile()
+	body := f.Body()
+	for i, mb := range blks {
+		if i != 0 {
+			body.AppendNewline()
+		}
+		blk := hclwrite.NewBlock("moved", nil)
+		blk.Body().SetAttributeTraversal("from", addrTraversal(mb.From))
+		blk.Body().SetAttributeTraversal("to", moduleResourceTraversal(mb.Module, mb.TFAddr))
+		body.AppendBlock(blk)
+	}
+	return f.Bytes()
+}
+
+func (hclFormatter) AppendToFile(path string, b []byte) error {
+	return appendToFile(path, string(b))
+}
+
+// jsonFormatter renders the Terraform JSON configuration syntax.
+// See: https://developer.hashicorp.com/terraform/language/syntax/json
+type jsonFormatter struct{}
+
+var _ outputFormatter = jsonFormatter{}
+
+func (jsonFormatter) Config(cfgs ConfigInfos) ([]byte, error) {
+	out := map[string]any{}
+	for _, cfg := range cfgs {
+		obj, err := hclToJSON(cfg.HCL.Bytes())
+		if err != nil {
+			return nil, fmt.Errorf("converting the config of %s to JSON: %v", cfg.TFAddr, err)
+		}
+		// The comments are lost during the conversion, hence we record the ambiguous dependencies via the JSON comment property.
+		if comments := cfg.Dependencies.ambiguousDepsComments(); len(comments) != 0 {
+			body, err := jsonResourceBody(obj, cfg.TFAddr.Type, cfg.TFAddr.Name)
+			if err != nil {
+				return nil, fmt.Errorf("recording the ambiguous dependencies of %s: %v", cfg.TFAddr, err)
+			}
+			body["//"] = strings.Join(comments, "\n")
+		}
+		jsonMerge(out, obj)
+	}
+	return jsonMarshal(out)
+}
+
+// jsonResourceBody returns the body object of the resource of the type and name, in the JSON converted config.
+func jsonResourceBody(obj map[string]any, rt, name string) (map[string]any, error) {
+	resources, ok := obj["resource"].(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf(`no "resource" object`)
+	}
+	types, ok := resources[rt].(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("no resource object of type %s", rt)
+	}
+	body, ok := types[name].(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("no resource object of type %s named %s", rt, name)
+	}
+	return body, nil
+}
+
+func (jsonFormatter) File(f *hclwrite.File) ([]byte, error) {
+	obj, err := hclToJSON(f.Bytes())
+	if err != nil {
+		return nil, err
+	}
+	return jsonMarshal(obj)
+}
+
+func (jsonFormatter) ImportBlocks(blks []importBlock) []byte {
+	objs := []any{}
+	for _, blk := range blks {
+		objs = append(objs, map[string]any{
+			"id": jsonEscapeTemplate(blk.ID),
+			"to": blk.to(),
+		})
+	}
+	// Marshalling the strings never fails.
+	b, _ := jsonMarshal(map[string]any{"import": objs})
+	return b
+}
+
+func (jsonFormatter) MovedBlocks(blks []movedBlock) []byte {
+	objs := []any{}
+	for _, blk := range blks {
+		objs = append(objs, map[string]any{
+			"from": blk.From.String(),
+			"to":   blk.to(),
+		})
+	}
+	// Marshalling the strings never fails.
+	b, _ := jsonMarshal(map[string]any{"moved": objs})
+	return b
+}
+
+// AppendToFile merges the rendered JSON object into the JSON object of the existing file, as a JSON file can't be simply appended.
+func (jsonFormatter) AppendToFile(path string, b []byte) error {
+	// #nosec G304
+	existing, err := os.ReadFile(path)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			return err
+		}
+		// #nosec G306
+		return os.WriteFile(path, b, 0644)
+	}
+
+	var dst, src map[string]any
+	if err := jsonUnmarshal(existing, &dst); err != nil {
+		return fmt.Errorf("unmarshalling the existing file %s: %v", path, err)
+	}
+	if err := jsonUnmarshal(b, &src); err != nil {
+		return fmt.Errorf("unmarshalling the content to append: %v", err)
+	}
+	if dst == nil {
+		dst = map[string]any{}
+	}
+	jsonMerge(dst, src)
+	b, err = jsonMarshal(dst)
+	if err != nil {
+		return err
+	}
+	// #nosec G306
+	return os.WriteFile(path, b, 0644)
+}
+
+func jsonMarshal(v any) ([]byte, error) {
+	b, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(b, '\n'), nil
+}
+
+// jsonUnmarshal unmarshals the JSON with the numbers kept as is.
+func jsonUnmarshal(b []byte, v any) error {
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	return dec.Decode(v)
+}
+
+// jsonMerge merges the src object into the dst object recursively. Arrays are concatenated, while the other values in src win.
+func jsonMerge(dst, src map[string]any) {
+	for k, sv := range src {
+		dv, ok := dst[k]
+		if !ok {
+			dst[k] = sv
+			continue
+		}
+		switch sv := sv.(type) {
+		case map[string]any:
+			if dv, ok := dv.(map[string]any); ok {
+				jsonMerge(dv, sv)
+				continue
+			}
+		case []any:
+			if dv, ok := dv.([]any); ok {
+				dst[k] = append(dv, sv...)
+				continue
+			}
+		}
+		dst[k] = sv
+	}
+}
+
+// hclToJSON converts the HCL native syntax to the equivalent object of the Terraform JSON syntax.
+// The blocks are nested by their labels, while the nested blocks are converted to arrays of objects.
+func hclToJSON(src []byte) (map[string]any, error) {
+	f, diags := hclsyntax.ParseConfig(src, "main.tf", hcl.InitialPos)
+	if diags.HasErrors() {
+		return nil, diags
+	}
+	out := map[string]any{}
+	for _, blk := range f.Body.(*hclsyntax.Body).Blocks {
+		bareAttrs := metaArgAttrs
+		switch blk.Type {
+		case "variable":
+			bareAttrs = variableBareAttrs
+		case "moved":
+			bareAttrs = movedBareAttrs
+		}
+		body, err := hclBodyToJSON(blk.Body, src, bareAttrs)
+		if err != nil {
+			return nil, err
+		}
+		var v any = body
+		for i := len(blk.Labels) - 1; i >= 0; i-- {
+			v = map[string]any{blk.Labels[i]: v}
+		}
+		// The unlabeled blocks (e.g. "moved") can repeat, which are represented as arrays of objects.
+		// Except for the "locals" blocks, which are simply merged.
+		if len(blk.Labels) == 0 && blk.Type != "locals" {
+			v = []any{v}
+		}
+		jsonMerge(out, map[string]any{blk.Type: v})
+	}
+	return out, nil
+}
+
+// metaArgAttrs are the meta arguments whose expressions are references rather than values, which are represented as bare strings.
+// The "provider" is also parsed as the provider config address (e.g. "azurerm.sub-123"), rather than a template.
+var metaArgAttrs = map[string]bool{
+	"provider":             true,
+	"depends_on":           true,
+	"ignore_changes":       true,
+	"replace_triggered_by": true,
+}
+
+// movedBareAttrs are the attributes of the moved block whose expressions are resource addresses, which are represented as bare strings.
+var movedBareAttrs = map[string]bool{
+	"from": true,
+	"to":   true,
+}
+
+// variableBareAttrs are the attributes of the variable block whose expressions are represented as bare strings.
+var variableBareAttrs = map[string]bool{
+	"type": true,
+}
+
+func hclBodyToJSON(body *hclsyntax.Body, src []byte, bareAttrs map[string]bool) (map[string]any, error) {
+	out := map[string]any{}
+	for name, attr := range body.Attributes {
+		v, err := hclExprToJSON(attr.Expr, src, bareAttrs[name])
+		if err != nil {
+			return nil, fmt.Errorf("converting attribute %q: %v", name, err)
+		}
+		out[name] = v
+	}
+	for _, blk := range body.Blocks {
+		nb, err := hclBodyToJSON(blk.Body, src, metaArgAttrs)
+		if err != nil {
+			return nil, fmt.Errorf("converting block %q: %v", blk.Type, err)
+		}
+		var v any = nb
+		for i := len(blk.Labels) - 1; i >= 0; i-- {
+			v = map[string]any{blk.Labels[i]: v}
+		}
+		l, _ := out[blk.Type].([]any)
+		out[blk.Type] = append(l, v)
+	}
+	return out, nil
+}
+
+func hclExprToJSON(expr hclsyntax.Expression, src []byte, bare bool) (any, error) {
+	exprSrc := func(e hclsyntax.Expression) string {
+		return string(e.Range().SliceBytes(src))
+	}
+
+	switch expr := expr.(type) {
+	case *hclsyntax.ScopeTraversalExpr:
+		if bare {
+			return exprSrc(expr), nil
+		}
+		return "${" + exprSrc(expr) + "}", nil
+	case *hclsyntax.FunctionCallExpr:
+		// E.g. the type constraint "map(string)"
+		if bare {
+			return exprSrc(expr), nil
+		}
+		return "${" + exprSrc(expr) + "}", nil
+	case *hclsyntax.TemplateWrapExpr:
+		return "${" + exprSrc(expr.Wrapped) + "}", nil
+	case *hclsyntax.TemplateExpr:
+		var sb strings.Builder
+		for _, part := range expr.Parts {
+			if lit, ok := part.(*hclsyntax.LiteralValueExpr); ok && lit.Val.Type() == cty.String {
+				sb.WriteString(jsonEscapeTemplate(lit.Val.AsString()))
+				continue
+			}
+			sb.WriteString("${" + exprSrc(part) + "}")
+		}
+		return sb.String(), nil
+	case *hclsyntax.TupleConsExpr:
+		out := []any{}
+		for _, e := range expr.Exprs {
+			v, err := hclExprToJSON(e, src, bare)
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, v)
+		}
+		return out, nil
+	case *hclsyntax.ObjectConsExpr:
+		out := map[string]any{}
+		for _, item := range expr.Items {
+			var key string
+			if name := hcl.ExprAsKeyword(item.KeyExpr); name != "" {
+				key = name
+			} else {
+				kv, diags := item.KeyExpr.Value(nil)
+				if diags.HasErrors() || !kv.IsWhollyKnown() || kv.IsNull() || kv.Type() != cty.String {
+					// The object key is not a literal string, fallback to the expression as a whole.
+					return "${" + exprSrc(expr) + "}", nil
+				}
+				key = kv.AsString()
+			}
+			v, err := hclExprToJSON(item.ValueExpr, src, bare)
+			if err != nil {
+				return nil, err
+			}
+			out[key] = v
+		}
+		return out, nil
+	}
+
+	// Any other expression is either a constant value, or an expression that is kept as is via the interpolation sequence (e.g. function calls).
+	v, diags := expr.Value(nil)
+	if diags.HasErrors() || !v.IsWhollyKnown() {
+		return "${" + exprSrc(expr) + "}", nil
+	}
+	return ctyToJSON(v)
+}
+
+func ctyToJSON(v cty.Value) (any, error) {
+	if v.IsNull() {
+		return nil, nil
+	}
+	ty := v.Type()
+	switch {
+	case ty == cty.String:
+		return jsonEscapeTemplate(v.AsString()), nil
+	case ty == cty.Number:
+		bf := v.AsBigFloat()
+		if bf.IsInt() {
+			i, _ := bf.Int(nil)
+			return json.Number(i.String()), nil
+		}
+		return json.Number(bf.Text('g', -1)), nil
+	case ty == cty.Bool:
+		return v.True(), nil
+	case ty.IsListType() || ty.IsSetType() || ty.IsTupleType():
+		out := []any{}
+		for it := v.ElementIterator(); it.Next(); {
+			_, ev := it.Element()
+			jv, err := ctyToJSON(ev)
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, jv)
+		}
+		return out, nil
+	case ty.IsMapType() || ty.IsObjectType():
+		out := map[string]any{}
+		for it := v.ElementIterator(); it.Next(); {
+			k, ev := it.Element()
+			jv, err := ctyToJSON(ev)
+			if err != nil {
+				return nil, err
+			}
+			out[k.AsString()] = jv
+		}
+		return out, nil
+	}
+	return nil, fmt.Errorf("unsupported value type %s", ty.FriendlyName())
+}
+
+// jsonEscapeTemplate escapes the template sequences in a literal string, as the JSON strings are interpreted as string templates.
+func jsonEscapeTemplate(s string) string {
+	s = strings.ReplaceAll(s, "${", "$${")
+	s = strings.ReplaceAll(s, "%{", "%%{")
+	return s
+}
@@ -0,0 +1,163 @@
+package meta
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Azure/aztfexport/internal/tfaddr"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJSONFormatter_Config(t *testing.T) {
+	cfgs := ConfigInfos{
+		newConfigInfo(
+			"/subscriptions/123/resourceGroups/rg1",
+			"/subscriptions/123/resourceGroups/rg1",
+			"azurerm_resource_group.res-0",
+			`
+resource "azurerm_resource_group" "res-0" {
+  name     = "rg1"
+  location = "westus"
+  tags = {
+    "foo" = "$${bar}"
+  }
+}
+`,
+			nil,
+		),
+		newConfigInfo(
+			"/subscriptions/123/resourceGroups/rg1/providers/Microsoft.Network/virtualNetworks/vnet1",
+			"/subscriptions/123/resourceGroups/rg1/providers/Microsoft.Network/virtualNetworks/vnet1",
+			"azurerm_virtual_network.res-1",
+			`
+resource "azurerm_virtual_network" "res-1" {
+  name                = "vnet1"
+  resource_group_name = azurerm_resource_group.res-0.name
+  address_space       = ["10.0.0.0/16"]
+  flow_timeout        = 10
+  body = jsonencode({
+    foo = "bar"
+  })
+  subnet {
+    name = "subnet1"
+  }
+  subnet {
+    name = "subnet-${azurerm_resource_group.res-0.name}"
+  }
+  depends_on = [
+    azurerm_resource_group.res-0,
+  ]
+}
+`,
+			&Dependencies{
+				ByIdRefAmbiguous: map[string][]Dependency{
+					"/foo": {
+						{TFAddr: tfaddr.TFAddr{Type: "azurerm_foo", Name: "res-2"}},
+						{TFAddr: tfaddr.TFAddr{Type: "azurerm_foo", Name: "res-3"}},
+					},
+				},
+			},
+		),
+	}
+
+	b, err := jsonFormatter{}.Config(cfgs)
+	require.NoError(t, err)
+	require.JSONEq(t, `{
+  "resource": {
+    "azurerm_resource_group": {
+      "res-0": {
+        "name": "rg1",
+        "location": "westus",
+        "tags": {"foo": "$${bar}"}
+      }
+    },
+    "azurerm_virtual_network": {
+      "res-1": {
+        "//": "One of azurerm_foo.res-2,azurerm_foo.res-3 (can't auto-resolve as their ids are identical)",
+        "name": "vnet1",
+        "resource_group_name": "${azurerm_resource_group.res-0.name}",
+        "address_space": ["10.0.0.0/16"],
+        "flow_timeout": 10,
+        "body": "${jsonencode({\n    foo = \"bar\"\n  })}",
+        "subnet": [
+          {"name": "subnet1"},
+          {"name": "subnet-${azurerm_resource_group.res-0.name}"}
+        ],
+        "depends_on": ["azurerm_resource_group.res-0"]
+      }
+    }
+  }
+}`, string(b))
+
+	// The config doesn't contain the resource of the TF address
+	cfgs[1].TFAddr.Name = "res-4"
+	_, err = jsonFormatter{}.Config(cfgs)
+	require.ErrorContains(t, err, "no resource object of type azurerm_virtual_network named res-4")
+}
+
+func TestHCLToJSON_Provider(t *testing.T) {
+	out, err := hclToJSON([]byte(`
+resource "azurerm_resource_group" "res-0" {
+  provider = azurerm.sub-x
+  name     = "rg1"
+}
+
+data "azurerm_subnet" "subnet1" {
+  provider             = azurerm.sub-x
+  name                 = "subnet1"
+  virtual_network_name = "vnet1"
+}
+`))
+	require.NoError(t, err)
+	require.Equal(t, map[string]any{
+		"resource": map[string]any{
+			"azurerm_resource_group": map[string]any{
+				"res-0": map[string]any{
+					"provider": "azurerm.sub-x",
+					"name":     "rg1",
+				},
+			},
+		},
+		"data": map[string]any{
+			"azurerm_subnet": map[string]any{
+				"subnet1": map[string]any{
+					"provider":             "azurerm.sub-x",
+					"name":                 "subnet1",
+					"virtual_network_name": "vnet1",
+				},
+			},
+		},
+	}, out)
+}
+
+func TestJSONFormatter_AppendToFile(t *testing.T) {
+	l := ImportList{
+		{
+			TFResourceId: "/subscriptions/123/resourceGroups/rg1",
+			TFAddr:       tfaddr.TFAddr{Type: "azurerm_resource_group", Name: "res-0"},
+		},
+		{
+			TFResourceId: "/subscriptions/123/resourceGroups/rg2",
+		},
+		{
+			TFResourceId: "/subscriptions/123/resourceGroups/rg3",
+			TFAddr:       tfaddr.TFAddr{Type: "azurerm_resource_group", Name: "res-2"},
+			Managed:      true,
+		},
+	}
+	path := filepath.Join(t.TempDir(), "import.tf.json")
+
+	f := jsonFormatter{}
+	require.NoError(t, f.AppendToFile(path, f.ImportBlocks(baseMeta{}.importBlocks(l))))
+	require.NoError(t, f.AppendToFile(path, f.ImportBlocks(baseMeta{}.importBlocks(l[:1]))))
+
+	b, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.JSONEq(t, `{
+  "import": [
+    {"id": "/subscriptions/123/resourceGroups/rg1", "to": "azurerm_resource_group.res-0"},
+    {"id": "/subscriptions/123/resourceGroups/rg1", "to": "azurerm_resource_group.res-0"}
+  ]
+}`, string(b))
+}
@@ -0,0 +1,49 @@
+package meta
+
+import (
+	"testing"
+
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOutputsFile(t *testing.T) {
+	cfgs := ConfigInfos{
+		newConfigInfo(
+			"/subscriptions/123/resourceGroups/rg1",
+			"/subscriptions/123/resourceGroups/rg1",
+			"azurerm_resource_group.res-0",
+			`resource "azurerm_resource_group" "res-0" {}`,
+			nil,
+		),
+		newConfigInfo(
+			"/subscriptions/123/resourceGroups/rg1/providers/Microsoft.Compute/virtualMachines/vm1",
+			"/subscriptions/123/resourceGroups/rg1/providers/Microsoft.Compute/virtualMachines/vm1",
+			"azurerm_linux_virtual_machine.res-1",
+			`resource "azurerm_linux_virtual_machine" "res-1" {}`,
+			nil,
+		),
+	}
+
+	f, err := outputsFile(cfgs, map[string][]string{
+		"azurerm_linux_virtual_machine": {"identity[0].principal_id"},
+	})
+	require.NoError(t, err)
+	require.Equal(t, `output "azurerm_resource_group_res-0_id" {
+  value = azurerm_resource_group.res-0.id
+}
+
+output "azurerm_linux_virtual_machine_res-1_id" {
+  value = azurerm_linux_virtual_machine.res-1.id
+}
+
+output "azurerm_linux_virtual_machine_res-1_identity_0_principal_id" {
+  value = azurerm_linux_virtual_machine.res-1.identity[0].principal_id
+}
+`, string(hclwrite.Format(f.Bytes())))
+
+	_, err = outputsFile(cfgs, map[string][]string{
+		"azurerm_linux_virtual_machine": {"identity["},
+	})
+	require.Error(t, err)
+}
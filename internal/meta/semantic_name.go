@@ -0,0 +1,94 @@
+package meta
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"regexp"
+	"strings"
+
+	"github.com/Azure/aztfexport/internal/resourceset"
+	"github.com/magodo/armid"
+)
+
+// semanticHashLen is the initial length of the resource id hash that is appended to the semantic names.
+const semanticHashLen = 8
+
+// guidName matches the resource names that are GUIDs (e.g. the role assignments), which are meaningless as the resource names.
+var guidName = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// expandSemantic names the resources of the indexes semantically, and sets the names in place.
+//
+// The semantic name is always suffixed by the hash of the resource id, as the resources of the same TF resource type can share
+// the same semantic name (e.g. two virtual networks of the same name in different resource groups or subscriptions, or the names only
+// differ in the characters that are sanitized). As a result, the name of a resource only depends on the resource itself, regardless of
+// the other resources exported together, so that it is stable across runs even when the resource set changes.
+func (e *nameExpander) expandSemantic(rl []resourceset.TFResource, indexes []int, names []string) {
+	for _, i := range indexes {
+		res := rl[i]
+		base, hash := semanticBaseName(res), resourceIdHash(res.AzureId)
+		// The hash is lengthened in the unlikely case that the hashed name is already taken.
+		name := base + "_" + hash[:semanticHashLen]
+		for n := semanticHashLen + 1; e.taken[res.TFType][name] && n <= len(hash); n++ {
+			name = base + "_" + hash[:n]
+		}
+		names[i] = name
+		e.take(res.TFType, name)
+	}
+}
+
+// semanticBaseName returns the semantic name of the resource, which combines:
+//   - the name of the parent resource, for a child resource (e.g. the virtual network of a subnet), or for an extension resource (e.g. the resource of a role assignment)
+//   - the name of the resource, which is replaced by the type hint (i.e. the last Azure resource type segment) followed by its first segment if it is a GUID
+//   - the type hint, for the resources whose TF resource type doesn't tell the Azure resource type (e.g. "azapi_resource"), or whose name doesn't start with a letter
+//
+// The name is sanitized to be a valid Terraform identifier.
+func semanticBaseName(res resourceset.TFResource) string {
+	id := res.AzureId
+	typeHint := snakeCase(lastSegment(id.Types()))
+	name := lastSegment(id.Names())
+
+	var segs []string
+	if parent := semanticParentName(id); parent != "" {
+		segs = append(segs, parent)
+	}
+	if guidName.MatchString(name) {
+		segs = append(segs, typeHint, name[:8])
+	} else {
+		segs = append(segs, name)
+		if res.TFType == "" || res.TFType == "azapi_resource" {
+			segs = append(segs, typeHint)
+		}
+	}
+
+	out := snakeCase(strings.Join(segs, "_"))
+	if out == "" || !(out[0] >= 'a' && out[0] <= 'z') {
+		out = snakeCase(typeHint + "_" + out)
+	}
+	return toTFName(out)
+}
+
+// semanticParentName returns the name of the parent resource of a child resource, or the name of the scope resource of an extension resource.
+// It returns empty for a root scope, or a top level resource whose parent scope is a root scope.
+func semanticParentName(id armid.ResourceId) string {
+	// The names of a root scope (e.g. a resource group) include the names of its enclosing scopes (e.g. the subscription).
+	if _, ok := id.(armid.RootScope); ok {
+		return ""
+	}
+	if names := id.Names(); len(names) > 1 {
+		return names[len(names)-2]
+	}
+	scope := id.ParentScope()
+	if scope == nil {
+		return ""
+	}
+	if _, ok := scope.(armid.RootScope); ok {
+		return ""
+	}
+	return lastSegment(scope.Names())
+}
+
+// resourceIdHash returns the hex encoded hash of the resource id, which is case insensitive.
+func resourceIdHash(id armid.ResourceId) string {
+	sum := sha256.Sum256([]byte(strings.ToUpper(id.String())))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,89 @@
+package meta
+
+import (
+	"testing"
+
+	"github.com/Azure/aztfexport/internal/resourceset"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSemanticBaseName(t *testing.T) {
+	cases := []struct {
+		name   string
+		id     string
+		tfType string
+		expect string
+	}{
+		{
+			name:   "top level resource",
+			id:     "/subscriptions/123/resourceGroups/rg1/providers/Microsoft.Network/virtualNetworks/Hub-VNet",
+			tfType: "azurerm_virtual_network",
+			expect: "hub_v_net",
+		},
+		{
+			name:   "child resource",
+			id:     "/subscriptions/123/resourceGroups/rg1/providers/Microsoft.Network/virtualNetworks/vnet1/subnets/default",
+			tfType: "azurerm_subnet",
+			expect: "vnet1_default",
+		},
+		{
+			name:   "extension resource with a GUID name",
+			id:     "/subscriptions/123/resourceGroups/rg1/providers/Microsoft.Network/virtualNetworks/vnet1/providers/Microsoft.Authorization/roleAssignments/8f0e1a2b-0000-0000-0000-000000000000",
+			tfType: "azurerm_role_assignment",
+			expect: "vnet1_role_assignments_8f0e1a2b",
+		},
+		{
+			name:   "azapi resource",
+			id:     "/subscriptions/123/resourceGroups/rg1/providers/Microsoft.Network/virtualNetworks/vnet1",
+			tfType: "azapi_resource",
+			expect: "vnet1_virtual_networks",
+		},
+		{
+			name:   "name starting with a digit",
+			id:     "/subscriptions/123/resourceGroups/rg1/providers/Microsoft.Storage/storageAccounts/1storage",
+			tfType: "azurerm_storage_account",
+			expect: "storage_accounts_1storage",
+		},
+		{
+			name:   "resource group",
+			id:     "/subscriptions/123/resourceGroups/rg1",
+			tfType: "azurerm_resource_group",
+			expect: "rg1",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			res := resourceset.TFResource{AzureId: mustParseID(t, c.id), TFType: c.tfType}
+			require.Equal(t, c.expect, semanticBaseName(res))
+		})
+	}
+}
+
+func TestNameExpander_Semantic(t *testing.T) {
+	vnet := func(id string) resourceset.TFResource {
+		return resourceset.TFResource{AzureId: mustParseID(t, id), TFType: "azurerm_virtual_network"}
+	}
+	vnet1 := vnet("/subscriptions/123/resourceGroups/rg1/providers/Microsoft.Network/virtualNetworks/vnet1")
+	vnet1Dup := vnet("/subscriptions/123/resourceGroups/rg2/providers/Microsoft.Network/virtualNetworks/vnet1")
+	vnet2 := vnet("/subscriptions/123/resourceGroups/rg1/providers/Microsoft.Network/virtualNetworks/vnet2")
+	subnet := resourceset.TFResource{
+		AzureId: mustParseID(t, "/subscriptions/123/resourceGroups/rg1/providers/Microsoft.Network/virtualNetworks/vnet1/subnets/default"),
+		TFType:  "azurerm_subnet",
+	}
+	hashed := func(base string, res resourceset.TFResource) string {
+		return base + "_" + resourceIdHash(res.AzureId)[:semanticHashLen]
+	}
+
+	names := newNameExpander("").ExpandAll([]resourceset.TFResource{vnet1, vnet2, vnet1Dup, subnet})
+	require.Equal(t, []string{hashed("vnet1", vnet1), hashed("vnet2", vnet2), hashed("vnet1", vnet1Dup), hashed("vnet1_default", subnet)}, names)
+
+	// The names only depend on the resources themselves, i.e. they don't change when the other resources (even the ones sharing
+	// the same semantic name) join or leave, or the resources are ordered differently.
+	require.Equal(t, []string{names[0]}, newNameExpander("").ExpandAll([]resourceset.TFResource{vnet1}))
+	require.Equal(t, []string{names[3], names[2], names[0]}, newNameExpander("").ExpandAll([]resourceset.TFResource{subnet, vnet1Dup, vnet1}))
+
+	// The names are unique across multiple expansions, the hash is lengthened in case the hashed name is already taken.
+	e := newNameExpander("")
+	e.take(vnet1.TFType, names[0])
+	require.Equal(t, "vnet1_"+resourceIdHash(vnet1.AzureId)[:semanticHashLen+1], e.Expand(vnet1))
+}
@@ -0,0 +1,125 @@
+package meta
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/terraform-config-inspect/tfconfig"
+	"github.com/magodo/armid"
+)
+
+// subscriptionOfId returns the id of the subscription that the Azure resource belongs to, or an empty string if it is not within a subscription.
+func subscriptionOfId(id armid.ResourceId) string {
+	if id == nil {
+		return ""
+	}
+	switch scope := id.RootScope().(type) {
+	case *armid.SubscriptionId:
+		return scope.Id
+	case *armid.ResourceGroup:
+		return scope.SubscriptionId
+	}
+	return ""
+}
+
+// providerAlias returns the alias of the provider config for the import item, together with the subscription that the alias targets.
+// The alias is only used for the resource that belongs to a subscription other than the one in use.
+// An empty alias is returned for the default provider config. Especially, the aliases are not supported for the resources in non-root modules,
+// as they have to be passed from the root module.
+func (meta baseMeta) providerAlias(item ImportItem) (alias, subscriptionId string) {
+	if meta.subscriptionId == "" || meta.moduleAddr != "" || meta.moduleLayout.enabled() {
+		return "", ""
+	}
+	subscriptionId = subscriptionOfId(item.AzureResourceID)
+	if subscriptionId == "" || strings.EqualFold(subscriptionId, meta.subscriptionId) {
+		return "", ""
+	}
+	return "sub-" + strings.ToLower(subscriptionId), subscriptionId
+}
+
+// checkModuleLayoutSubscriptions ensures the import items all belong to the subscription in use when the module layout is enabled,
+// as the aliased provider configs for the other subscriptions are not passed to the child modules.
+func (meta baseMeta) checkModuleLayoutSubscriptions(l ImportList) error {
+	if meta.subscriptionId == "" || !meta.moduleLayout.enabled() {
+		return nil
+	}
+	for _, item := range l {
+		if subscriptionId := subscriptionOfId(item.AzureResourceID); subscriptionId != "" && !strings.EqualFold(subscriptionId, meta.subscriptionId) {
+			return fmt.Errorf("%s belongs to subscription %s other than %s, which is not supported by the module layout", item.AzureResourceID, subscriptionId, meta.subscriptionId)
+		}
+	}
+	return nil
+}
+
+// providerAddon sets the "provider" meta argument for the resources that belong to the subscriptions other than the one in use.
+func (meta baseMeta) providerAddon(configs ConfigInfos) (ConfigInfos, error) {
+	for _, cfg := range configs {
+		alias, _ := meta.providerAlias(cfg.ImportItem)
+		if alias == "" {
+			continue
+		}
+		cfg.HCL.Body().Blocks()[0].Body().SetAttributeTraversal("provider", hcl.Traversal{
+			hcl.TraverseRoot{Name: meta.providerName},
+			hcl.TraverseAttr{Name: alias},
+		})
+	}
+	return configs, nil
+}
+
+// writeProviderAliases appends the aliased provider configs used by the import items to the provider file of the output directory,
+// skipping the ones that are already defined.
+func (meta *baseMeta) writeProviderAliases(items []ImportItem) error {
+	aliases := map[string]string{}
+	for _, item := range items {
+		if alias, subscriptionId := meta.providerAlias(item); alias != "" {
+			aliases[alias] = subscriptionId
+		}
+	}
+	if len(aliases) == 0 {
+		return nil
+	}
+
+	module, diags := tfconfig.LoadModule(meta.outdir)
+	if diags.HasErrors() {
+		return fmt.Errorf("loading the module %s: %v", meta.outdir, diags.Err())
+	}
+
+	var names []string
+	for alias := range aliases {
+		if module.ProviderConfigs[meta.providerName+"."+alias] == nil {
+			names = append(names, alias)
+		}
+	}
+	sort.Strings(names)
+
+	var content string
+	for _, alias := range names {
+		content += "\n" + meta.buildAliasedProviderConfig(alias, aliases[alias])
+	}
+	if content == "" {
+		return nil
+	}
+	cfgFile := filepath.Join(meta.outdir, meta.outputFileNames.ProviderFileName)
+	if err := appendToFile(cfgFile, content); err != nil {
+		return fmt.Errorf("generating the aliased provider configs: %v", err)
+	}
+	return nil
+}
+
+// importProviderAlias ensures the aliased provider config is defined in the import directory on its first use.
+func (meta *baseMeta) importProviderAlias(importIdx int, alias, subscriptionId string) error {
+	if meta.importProviderAliases[importIdx][alias] {
+		return nil
+	}
+	providerFile := filepath.Join(meta.importBaseDirs[importIdx], fmt.Sprintf("provider.%s.tf", alias))
+	// #nosec G306
+	if err := os.WriteFile(providerFile, []byte(meta.buildAliasedProviderConfig(alias, subscriptionId)), 0644); err != nil {
+		return fmt.Errorf("error creating provider config: %w", err)
+	}
+	meta.importProviderAliases[importIdx][alias] = true
+	return nil
+}
@@ -0,0 +1,128 @@
+package meta
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Azure/aztfexport/pkg/config"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/stretchr/testify/require"
+	"github.com/zclconf/go-cty/cty"
+)
+
+func TestProviderAlias(t *testing.T) {
+	item := ImportItem{AzureResourceID: mustParseResourceId("/subscriptions/456/resourceGroups/rg1")}
+
+	alias, subscriptionId := baseMeta{subscriptionId: "123"}.providerAlias(item)
+	require.Equal(t, "sub-456", alias)
+	require.Equal(t, "456", subscriptionId)
+
+	alias, _ = baseMeta{subscriptionId: "456"}.providerAlias(item)
+	require.Equal(t, "", alias)
+	alias, _ = baseMeta{subscriptionId: "123", moduleAddr: "module.foo"}.providerAlias(item)
+	require.Equal(t, "", alias)
+	alias, _ = baseMeta{subscriptionId: "123", moduleLayout: moduleLayout{layout: config.ModuleLayoutResourceGroup}}.providerAlias(item)
+	require.Equal(t, "", alias)
+	alias, _ = baseMeta{subscriptionId: "123"}.providerAlias(ImportItem{AzureResourceID: mustParseResourceId("/providers/Microsoft.Management/managementGroups/mg1")})
+	require.Equal(t, "", alias)
+}
+
+func TestCheckModuleLayoutSubscriptions(t *testing.T) {
+	l := ImportList{
+		{AzureResourceID: mustParseResourceId("/subscriptions/123/resourceGroups/rg1")},
+		{AzureResourceID: mustParseResourceId("/providers/Microsoft.Management/managementGroups/mg1")},
+	}
+	meta := baseMeta{subscriptionId: "123", moduleLayout: moduleLayout{layout: config.ModuleLayoutResourceGroup}}
+	require.NoError(t, meta.checkModuleLayoutSubscriptions(l))
+
+	l = append(l, ImportItem{AzureResourceID: mustParseResourceId("/subscriptions/456/resourceGroups/rg1")})
+	require.ErrorContains(t, meta.checkModuleLayoutSubscriptions(l), "belongs to subscription 456")
+	// No module layout
+	require.NoError(t, baseMeta{subscriptionId: "123"}.checkModuleLayoutSubscriptions(l))
+}
+
+func TestProviderAddon(t *testing.T) {
+	cfgs := ConfigInfos{
+		newConfigInfo(
+			"/subscriptions/123/resourceGroups/rg1",
+			"/subscriptions/123/resourceGroups/rg1",
+			"azurerm_resource_group.res-0",
+			`resource "azurerm_resource_group" "res-0" {
+  name = "rg1"
+}
+`,
+			nil,
+		),
+		newConfigInfo(
+			"/subscriptions/456/resourceGroups/rg1",
+			"/subscriptions/456/resourceGroups/rg1",
+			"azurerm_resource_group.res-1",
+			`resource "azurerm_resource_group" "res-1" {
+  name = "rg1"
+}
+`,
+			nil,
+		),
+	}
+
+	cfgs, err := baseMeta{subscriptionId: "123", providerName: "azurerm"}.providerAddon(cfgs)
+	require.NoError(t, err)
+	require.Equal(t, `resource "azurerm_resource_group" "res-0" {
+  name = "rg1"
+}
+`, string(hclwrite.Format(cfgs[0].HCL.Bytes())))
+	require.Equal(t, `resource "azurerm_resource_group" "res-1" {
+  name     = "rg1"
+  provider = azurerm.sub-456
+}
+`, string(hclwrite.Format(cfgs[1].HCL.Bytes())))
+}
+
+func TestWriteProviderAliases(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "provider.tf"), []byte(`provider "azurerm" {
+  features {
+  }
+}
+
+provider "azurerm" {
+  alias           = "sub-456"
+  subscription_id = "456"
+}
+`), 0644))
+
+	meta := &baseMeta{
+		outdir:          dir,
+		subscriptionId:  "123",
+		providerName:    "azurerm",
+		providerConfig:  map[string]cty.Value{"subscription_id": cty.StringVal("123")},
+		outputFileNames: config.OutputFileNames{ProviderFileName: "provider.tf"},
+	}
+	cfgs := ConfigInfos{
+		newConfigInfo("/subscriptions/123/resourceGroups/rg1", "/subscriptions/123/resourceGroups/rg1", "azurerm_resource_group.res-0", `resource "azurerm_resource_group" "res-0" {}`, nil),
+		newConfigInfo("/subscriptions/456/resourceGroups/rg1", "/subscriptions/456/resourceGroups/rg1", "azurerm_resource_group.res-1", `resource "azurerm_resource_group" "res-1" {}`, nil),
+		newConfigInfo("/subscriptions/789/resourceGroups/rg1", "/subscriptions/789/resourceGroups/rg1", "azurerm_resource_group.res-2", `resource "azurerm_resource_group" "res-2" {}`, nil),
+	}
+	require.NoError(t, meta.writeProviderAliases(cfgs.importItems()))
+
+	b, err := os.ReadFile(filepath.Join(dir, "provider.tf"))
+	require.NoError(t, err)
+	require.Equal(t, `provider "azurerm" {
+  features {
+  }
+}
+
+provider "azurerm" {
+  alias           = "sub-456"
+  subscription_id = "456"
+}
+
+provider "azurerm" {
+  alias = "sub-789"
+  features {
+  }
+  subscription_id = "789"
+}
+`, string(b))
+}
@@ -0,0 +1,69 @@
+package meta
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// resourceTags returns the tags of a listed resource, whose properties are either an ARG query result row or an ARM response body.
+func resourceTags(props map[string]any) map[string]string {
+	raw, ok := props["tags"].(map[string]any)
+	if !ok || len(raw) == 0 {
+		return nil
+	}
+	tags := map[string]string{}
+	for k, v := range raw {
+		if v, ok := v.(string); ok {
+			tags[k] = v
+		}
+	}
+	return tags
+}
+
+// lookupTag looks up the tag value by the key, case insensitively as Azure does.
+func lookupTag(tags map[string]string, key string) string {
+	v, _ := lookupTagOK(tags, key)
+	return v
+}
+
+// lookupTagOK is like lookupTag, but also reports whether the tag exists.
+func lookupTagOK(tags map[string]string, key string) (string, bool) {
+	if v, ok := tags[key]; ok {
+		return v, true
+	}
+	for k, v := range tags {
+		if strings.EqualFold(k, key) {
+			return v, true
+		}
+	}
+	return "", false
+}
+
+// tagPredicate returns the ARG predicate that matches the resources having all the tags in the filter.
+// An empty value in the filter matches any value of the tag.
+func tagPredicate(filter map[string]string) string {
+	var keys []string
+	for k := range filter {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var conds []string
+	for _, k := range keys {
+		if v := filter[k]; v != "" {
+			conds = append(conds, fmt.Sprintf("tostring(tags[%s]) =~ %s", kqlString(k), kqlString(v)))
+		} else {
+			conds = append(conds, fmt.Sprintf("isnotnull(tags[%s])", kqlString(k)))
+		}
+	}
+	return strings.Join(conds, " and ")
+}
+
+// withTagPredicate returns the predicate combined with the tag filter, if any.
+func withTagPredicate(predicate string, filter map[string]string) string {
+	if len(filter) == 0 {
+		return predicate
+	}
+	return fmt.Sprintf("(%s) and %s", predicate, tagPredicate(filter))
+}
@@ -0,0 +1,21 @@
+package meta
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestResourceTags(t *testing.T) {
+	require.Nil(t, resourceTags(map[string]any{"id": "/subscriptions/123"}))
+	require.Nil(t, resourceTags(map[string]any{"tags": map[string]any{}}))
+	require.Equal(t, map[string]string{"env": "prod"}, resourceTags(map[string]any{"tags": map[string]any{"env": "prod", "invalid": 1}}))
+}
+
+func TestTagPredicate(t *testing.T) {
+	require.Equal(t, `type =~ "microsoft.network/virtualnetworks"`, withTagPredicate(`type =~ "microsoft.network/virtualnetworks"`, nil))
+	require.Equal(t,
+		`(type =~ "microsoft.network/virtualnetworks") and tostring(tags['env']) =~ 'prod' and isnotnull(tags['owner'])`,
+		withTagPredicate(`type =~ "microsoft.network/virtualnetworks"`, map[string]string{"owner": "", "env": "prod"}),
+	)
+}
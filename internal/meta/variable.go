@@ -0,0 +1,251 @@
+package meta
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/zclconf/go-cty/cty"
+	ctyjson "github.com/zclconf/go-cty/cty/json"
+)
+
+// hoistVariableMinOccurrences is the minimum number of configs that a literal value shall be repeated in to be hoisted as a variable.
+const hoistVariableMinOccurrences = 2
+
+// hoistableAttributes are the top level attributes whose literal values are hoisted as variables when repeated.
+var hoistableAttributes = []string{"location", "resource_group_name", "sku_name", "tags"}
+
+var subscriptionIdInIdRegexp = regexp.MustCompile(`(?i)(/subscriptions/)([0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12})`)
+
+type hoistedVariable struct {
+	Name  string
+	Value cty.Value
+}
+
+// variableHoister hoists the literal values that are repeated across the configs into variables, which are referenced by the configs instead.
+// The hoisted values include the values of the hoistableAttributes, and the subscription ids inside the resource ids.
+type variableHoister struct {
+	// The names that are already used, e.g. by the variables defined in the existing module.
+	usedNames map[string]bool
+	variables []hoistedVariable
+}
+
+func newVariableHoister(reservedNames []string) *variableHoister {
+	h := &variableHoister{usedNames: map[string]bool{}}
+	for _, name := range reservedNames {
+		h.usedNames[name] = true
+	}
+	return h
+}
+
+// Hoist is a TFConfigTransformer.
+func (h *variableHoister) Hoist(configs ConfigInfos) (ConfigInfos, error) {
+	if err := h.hoistAttributes(configs); err != nil {
+		return nil, err
+	}
+	h.hoistSubscriptionIds(configs)
+	return configs, nil
+}
+
+// Variables returns the hoisted variables, sorted by name.
+func (h *variableHoister) Variables() []hoistedVariable {
+	out := make([]hoistedVariable, len(h.variables))
+	copy(out, h.variables)
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].Name < out[j].Name
+	})
+	return out
+}
+
+// VariablesFile returns the HCL file of the variable blocks of the hoisted variables, whose default values are the hoisted values.
+func (h *variableHoister) VariablesFile() *hclwrite.File {
+	f := hclwrite.NewFile()
+	body := f.Body()
+	for i, v := range h.Variables() {
+		if i != 0 {
+			body.AppendNewline()
+		}
+		blk := body.AppendNewBlock("variable", []string{v.Name})
+		if typ := variableTypeTokens(v.Value.Type()); typ != nil {
+			blk.Body().SetAttributeRaw("type", typ)
+		}
+		blk.Body().SetAttributeValue("default", v.Value)
+	}
+	return f
+}
+
+func (h *variableHoister) newName(base string) string {
+	name := base
+	for n := 2; h.usedNames[name]; n++ {
+		name = fmt.Sprintf("%s_%d", base, n)
+	}
+	h.usedNames[name] = true
+	return name
+}
+
+type hoistCandidate struct {
+	key   string
+	value cty.Value
+	// The indexes of the configs that have this value
+	configs []int
+}
+
+// sortedHoistCandidates returns the candidates repeated enough to be hoisted, the most repeated comes first.
+func sortedHoistCandidates(m map[string]*hoistCandidate) []*hoistCandidate {
+	var out []*hoistCandidate
+	for _, c := range m {
+		if len(c.configs) >= hoistVariableMinOccurrences {
+			out = append(out, c)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if len(out[i].configs) != len(out[j].configs) {
+			return len(out[i].configs) > len(out[j].configs)
+		}
+		return out[i].key < out[j].key
+	})
+	return out
+}
+
+func (h *variableHoister) hoistAttributes(configs ConfigInfos) error {
+	for _, attrName := range hoistableAttributes {
+		candidates := map[string]*hoistCandidate{}
+		for i, cfg := range configs {
+			file, diags := hclsyntax.ParseConfig(cfg.HCL.Bytes(), "main.tf", hcl.InitialPos)
+			if diags.HasErrors() {
+				return fmt.Errorf("parsing hcl for %s: %v", cfg.AzureResourceID, diags.Error())
+			}
+			attr, ok := file.Body.(*hclsyntax.Body).Blocks[0].Body.Attributes[attrName]
+			if !ok {
+				continue
+			}
+			// Only the literal values are regarded, the references and function calls fail the evaluation here.
+			val, diags := attr.Expr.Value(nil)
+			if diags.HasErrors() || !val.IsWhollyKnown() || val.IsNull() {
+				continue
+			}
+			// Skip the empty values, e.g. `tags = {}`.
+			if (val.Type() == cty.String && val.AsString() == "") || (val.CanIterateElements() && val.LengthInt() == 0) {
+				continue
+			}
+			b, err := ctyjson.Marshal(val, val.Type())
+			if err != nil {
+				continue
+			}
+			key := string(b)
+			if _, ok := candidates[key]; !ok {
+				candidates[key] = &hoistCandidate{key: key, value: val}
+			}
+			candidates[key].configs = append(candidates[key].configs, i)
+		}
+
+		for _, c := range sortedHoistCandidates(candidates) {
+			name := h.newName(attrName)
+			h.variables = append(h.variables, hoistedVariable{Name: name, Value: c.value})
+			for _, i := range c.configs {
+				configs[i].HCL.Body().Blocks()[0].Body().SetAttributeTraversal(attrName, hcl.Traversal{
+					hcl.TraverseRoot{Name: "var"},
+					hcl.TraverseAttr{Name: name},
+				})
+			}
+		}
+	}
+	return nil
+}
+
+func (h *variableHoister) hoistSubscriptionIds(configs ConfigInfos) {
+	candidates := map[string]*hoistCandidate{}
+	for i, cfg := range configs {
+		found := map[string]string{}
+		walkQuotedLits(cfg.HCL.Body().Blocks()[0].Body(), func(lit []byte) []byte {
+			for _, m := range subscriptionIdInIdRegexp.FindAllSubmatch(lit, -1) {
+				found[strings.ToLower(string(m[2]))] = string(m[2])
+			}
+			return nil
+		})
+		for key, id := range found {
+			if _, ok := candidates[key]; !ok {
+				candidates[key] = &hoistCandidate{key: key, value: cty.StringVal(id)}
+			}
+			candidates[key].configs = append(candidates[key].configs, i)
+		}
+	}
+
+	names := map[string]string{}
+	toHoist := map[int]bool{}
+	for _, c := range sortedHoistCandidates(candidates) {
+		name := h.newName("subscription_id")
+		h.variables = append(h.variables, hoistedVariable{Name: name, Value: c.value})
+		names[c.key] = name
+		for _, i := range c.configs {
+			toHoist[i] = true
+		}
+	}
+
+	for i := range toHoist {
+		walkQuotedLits(configs[i].HCL.Body().Blocks()[0].Body(), func(lit []byte) []byte {
+			if !subscriptionIdInIdRegexp.Match(lit) {
+				return nil
+			}
+			return subscriptionIdInIdRegexp.ReplaceAllFunc(lit, func(m []byte) []byte {
+				sm := subscriptionIdInIdRegexp.FindSubmatch(m)
+				name, ok := names[strings.ToLower(string(sm[2]))]
+				if !ok {
+					return m
+				}
+				return fmt.Appendf(nil, "%s${var.%s}", sm[1], name)
+			})
+		})
+	}
+}
+
+// walkQuotedLits walks through the quoted string literals of the attributes in the body and its nested blocks.
+// The literal is replaced in case the function returns a non-nil value.
+func walkQuotedLits(body *hclwrite.Body, f func(lit []byte) []byte) {
+	for name, attr := range body.Attributes() {
+		tokens := attr.Expr().BuildTokens(nil)
+		newTokens := make(hclwrite.Tokens, 0, len(tokens))
+		toApply := false
+		for _, token := range tokens {
+			if token.Type == hclsyntax.TokenQuotedLit {
+				if nb := f(token.Bytes); nb != nil {
+					token = &hclwrite.Token{
+						Type:         token.Type,
+						Bytes:        nb,
+						SpacesBefore: token.SpacesBefore,
+					}
+					toApply = true
+				}
+			}
+			newTokens = append(newTokens, token)
+		}
+		if toApply {
+			body.SetAttributeRaw(name, newTokens)
+		}
+	}
+	for _, blk := range body.Blocks() {
+		walkQuotedLits(blk.Body(), f)
+	}
+}
+
+// variableTypeTokens returns the tokens of the type constraint of the value type, or nil if it is not a simple type.
+func variableTypeTokens(ty cty.Type) hclwrite.Tokens {
+	switch {
+	case ty == cty.String:
+		return hclwrite.TokensForIdentifier("string")
+	case ty.IsMapType() && ty.ElementType() == cty.String:
+		return hclwrite.TokensForFunctionCall("map", hclwrite.TokensForIdentifier("string"))
+	case ty.IsObjectType():
+		for _, aty := range ty.AttributeTypes() {
+			if aty != cty.String {
+				return nil
+			}
+		}
+		return hclwrite.TokensForFunctionCall("map", hclwrite.TokensForIdentifier("string"))
+	}
+	return nil
+}
@@ -0,0 +1,122 @@
+package meta
+
+import (
+	"testing"
+
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVariableHoister(t *testing.T) {
+	cfgs := ConfigInfos{
+		newConfigInfo(
+			"/subscriptions/00000000-0000-0000-0000-000000000000/resourceGroups/rg1",
+			"/subscriptions/00000000-0000-0000-0000-000000000000/resourceGroups/rg1",
+			"azurerm_resource_group.res-0",
+			`resource "azurerm_resource_group" "res-0" {
+  name     = "rg1"
+  location = "westus"
+  tags = {
+    env = "prod"
+  }
+}
+`,
+			nil,
+		),
+		newConfigInfo(
+			"/subscriptions/00000000-0000-0000-0000-000000000000/resourceGroups/rg1/providers/Microsoft.Network/virtualNetworks/vnet1",
+			"/subscriptions/00000000-0000-0000-0000-000000000000/resourceGroups/rg1/providers/Microsoft.Network/virtualNetworks/vnet1",
+			"azurerm_virtual_network.res-1",
+			`resource "azurerm_virtual_network" "res-1" {
+  name                = "vnet1"
+  location            = "westus"
+  resource_group_name = azurerm_resource_group.res-0.name
+  tags = {
+    env = "prod"
+  }
+  subnet {
+    route_table_id = "/subscriptions/00000000-0000-0000-0000-000000000000/resourceGroups/rg2/providers/Microsoft.Network/routeTables/rt1"
+  }
+}
+`,
+			nil,
+		),
+		newConfigInfo(
+			"/subscriptions/00000000-0000-0000-0000-000000000000/resourceGroups/rg1/providers/Microsoft.Network/networkSecurityGroups/nsg1",
+			"/subscriptions/00000000-0000-0000-0000-000000000000/resourceGroups/rg1/providers/Microsoft.Network/networkSecurityGroups/nsg1",
+			"azurerm_network_security_group.res-2",
+			`resource "azurerm_network_security_group" "res-2" {
+  name     = "nsg1"
+  location = "eastus"
+  tags     = {}
+  peer_id  = "/subscriptions/00000000-0000-0000-0000-000000000000/resourceGroups/rg3/providers/Microsoft.Network/networkSecurityGroups/nsg2"
+}
+`,
+			nil,
+		),
+	}
+
+	h := newVariableHoister([]string{"location"})
+	cfgs, err := h.Hoist(cfgs)
+	require.NoError(t, err)
+
+	var names []string
+	for _, v := range h.Variables() {
+		names = append(names, v.Name)
+	}
+	require.Equal(t, []string{"location_2", "subscription_id", "tags"}, names)
+
+	require.Equal(t, `resource "azurerm_resource_group" "res-0" {
+  name     = "rg1"
+  location = var.location_2
+  tags     = var.tags
+}
+`, string(hclwrite.Format(cfgs[0].HCL.Bytes())))
+
+	require.Equal(t, `resource "azurerm_virtual_network" "res-1" {
+  name                = "vnet1"
+  location            = var.location_2
+  resource_group_name = azurerm_resource_group.res-0.name
+  tags                = var.tags
+  subnet {
+    route_table_id = "/subscriptions/${var.subscription_id}/resourceGroups/rg2/providers/Microsoft.Network/routeTables/rt1"
+  }
+}
+`, string(hclwrite.Format(cfgs[1].HCL.Bytes())))
+
+	require.Equal(t, `resource "azurerm_network_security_group" "res-2" {
+  name     = "nsg1"
+  location = "eastus"
+  tags     = {}
+  peer_id  = "/subscriptions/${var.subscription_id}/resourceGroups/rg3/providers/Microsoft.Network/networkSecurityGroups/nsg2"
+}
+`, string(hclwrite.Format(cfgs[2].HCL.Bytes())))
+
+	require.Equal(t, `variable "location_2" {
+  type    = string
+  default = "westus"
+}
+
+variable "subscription_id" {
+  type    = string
+  default = "00000000-0000-0000-0000-000000000000"
+}
+
+variable "tags" {
+  type = map(string)
+  default = {
+    env = "prod"
+  }
+}
+`, string(hclwrite.Format(h.VariablesFile().Bytes())))
+
+	b, err := jsonFormatter{}.File(h.VariablesFile())
+	require.NoError(t, err)
+	require.JSONEq(t, `{
+  "variable": {
+    "location_2": {"type": "string", "default": "westus"},
+    "subscription_id": {"type": "string", "default": "00000000-0000-0000-0000-000000000000"},
+    "tags": {"type": "map(string)", "default": {"env": "prod"}}
+  }
+}`, string(b))
+}
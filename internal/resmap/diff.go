@@ -0,0 +1,96 @@
+package resmap
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+type DiffEntry struct {
+	// Azure resource ID
+	AzureResourceId string `json:"azure_resource_id"`
+	// The entity in the old mapping, which is nil for an added resource
+	Old *ResourceMapEntity `json:"old,omitempty"`
+	// The entity in the new mapping, which is nil for a removed resource
+	New *ResourceMapEntity `json:"new,omitempty"`
+}
+
+type DiffResult struct {
+	// Resources only exist in the new mapping
+	Added []DiffEntry `json:"added"`
+	// Resources only exist in the old mapping
+	Removed []DiffEntry `json:"removed"`
+	// Resources exist in both mappings, but map to different TF resource types
+	Retyped []DiffEntry `json:"retyped"`
+}
+
+// Diff compares the new resource mapping against the old one. The Azure resource ids are compared case insensitively.
+func Diff(old, new ResourceMapping) DiffResult {
+	oldm := old.index()
+	newm := new.index()
+
+	result := DiffResult{
+		Added:   []DiffEntry{},
+		Removed: []DiffEntry{},
+		Retyped: []DiffEntry{},
+	}
+	for key, id := range newm {
+		newEntity := new[id]
+		oid, ok := oldm[key]
+		if !ok {
+			result.Added = append(result.Added, DiffEntry{AzureResourceId: id, New: &newEntity})
+			continue
+		}
+		oldEntity := old[oid]
+		if !strings.EqualFold(oldEntity.ResourceType, newEntity.ResourceType) {
+			result.Retyped = append(result.Retyped, DiffEntry{AzureResourceId: id, Old: &oldEntity, New: &newEntity})
+		}
+	}
+	for key, id := range oldm {
+		if _, ok := newm[key]; ok {
+			continue
+		}
+		oldEntity := old[id]
+		result.Removed = append(result.Removed, DiffEntry{AzureResourceId: id, Old: &oldEntity})
+	}
+
+	for _, l := range [][]DiffEntry{result.Added, result.Removed, result.Retyped} {
+		sort.Slice(l, func(i, j int) bool {
+			return strings.ToUpper(l[i].AzureResourceId) < strings.ToUpper(l[j].AzureResourceId)
+		})
+	}
+	return result
+}
+
+// HasChanges tells whether there is any difference between the two mappings.
+func (r DiffResult) HasChanges() bool {
+	return len(r.Added)+len(r.Removed)+len(r.Retyped) != 0
+}
+
+// String returns a human readable report of the diff result.
+func (r DiffResult) String() string {
+	if !r.HasChanges() {
+		return "No changes.\n"
+	}
+
+	var sb strings.Builder
+	if len(r.Added) != 0 {
+		fmt.Fprintf(&sb, "Added (%d):\n", len(r.Added))
+		for _, e := range r.Added {
+			fmt.Fprintf(&sb, "  + %s (%s)\n", e.AzureResourceId, e.New.ResourceType)
+		}
+	}
+	if len(r.Removed) != 0 {
+		fmt.Fprintf(&sb, "Removed (%d):\n", len(r.Removed))
+		for _, e := range r.Removed {
+			fmt.Fprintf(&sb, "  - %s (%s.%s)\n", e.AzureResourceId, e.Old.ResourceType, e.Old.ResourceName)
+		}
+	}
+	if len(r.Retyped) != 0 {
+		fmt.Fprintf(&sb, "Re-typed (%d):\n", len(r.Retyped))
+		for _, e := range r.Retyped {
+			fmt.Fprintf(&sb, "  ~ %s (%s.%s -> %s)\n", e.AzureResourceId, e.Old.ResourceType, e.Old.ResourceName, e.New.ResourceType)
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,63 @@
+package resmap
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiff(t *testing.T) {
+	old := ResourceMapping{
+		"/subscriptions/123/resourceGroups/rg1": {
+			ResourceId:   "/subscriptions/123/resourceGroups/rg1",
+			ResourceType: "azurerm_resource_group",
+			ResourceName: "res-0",
+		},
+		"/subscriptions/123/resourceGroups/rg1/providers/Microsoft.Network/virtualNetworks/vnet1": {
+			ResourceId:   "/subscriptions/123/resourceGroups/rg1/providers/Microsoft.Network/virtualNetworks/vnet1",
+			ResourceType: "azurerm_virtual_network",
+			ResourceName: "res-1",
+		},
+		"/subscriptions/123/resourceGroups/rg1/providers/Microsoft.Compute/virtualMachines/vm1": {
+			ResourceId:   "/subscriptions/123/resourceGroups/rg1/providers/Microsoft.Compute/virtualMachines/vm1",
+			ResourceType: "azurerm_linux_virtual_machine",
+			ResourceName: "res-2",
+		},
+	}
+	new := ResourceMapping{
+		// Different casing is regarded as the same resource
+		"/subscriptions/123/resourcegroups/RG1": {
+			ResourceId:   "/subscriptions/123/resourceGroups/rg1",
+			ResourceType: "azurerm_resource_group",
+			ResourceName: "res-0",
+		},
+		"/subscriptions/123/resourceGroups/rg1/providers/Microsoft.Compute/virtualMachines/vm1": {
+			ResourceId:   "/subscriptions/123/resourceGroups/rg1/providers/Microsoft.Compute/virtualMachines/vm1",
+			ResourceType: "azurerm_windows_virtual_machine",
+			ResourceName: "res-1",
+		},
+		"/subscriptions/123/resourceGroups/rg1/providers/Microsoft.Storage/storageAccounts/sa1": {
+			ResourceId:   "/subscriptions/123/resourceGroups/rg1/providers/Microsoft.Storage/storageAccounts/sa1",
+			ResourceType: "azurerm_storage_account",
+			ResourceName: "res-2",
+		},
+	}
+
+	result := Diff(old, new)
+	require.True(t, result.HasChanges())
+
+	require.Len(t, result.Added, 1)
+	require.Equal(t, "/subscriptions/123/resourceGroups/rg1/providers/Microsoft.Storage/storageAccounts/sa1", result.Added[0].AzureResourceId)
+	require.Nil(t, result.Added[0].Old)
+
+	require.Len(t, result.Removed, 1)
+	require.Equal(t, "/subscriptions/123/resourceGroups/rg1/providers/Microsoft.Network/virtualNetworks/vnet1", result.Removed[0].AzureResourceId)
+	require.Nil(t, result.Removed[0].New)
+
+	require.Len(t, result.Retyped, 1)
+	require.Equal(t, "azurerm_linux_virtual_machine", result.Retyped[0].Old.ResourceType)
+	require.Equal(t, "azurerm_windows_virtual_machine", result.Retyped[0].New.ResourceType)
+
+	require.False(t, Diff(old, old).HasChanges())
+	require.Equal(t, "No changes.\n", Diff(old, old).String())
+}
@@ -1,5 +1,11 @@
 package resmap
 
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
 type ResourceMapEntity struct {
 	// TF resource ID
 	ResourceId string `json:"resource_id"`
@@ -9,5 +15,58 @@ type ResourceMapEntity struct {
 	ResourceName string `json:"resource_name"`
 }
 
-// ResourceMapping is the resource mapping file, the key is the Azure resource Id in uppercase.
+// ResourceMapping is the resource mapping file, the key is the Azure resource Id in its original casing.
+// As the Azure resource ids are case insensitive, the keys shall be compared case insensitively (e.g. via Index).
 type ResourceMapping map[string]ResourceMapEntity
+
+// Index is a case insensitive index of the resource mapping, to look up the entities by the Azure resource ids.
+type Index struct {
+	m    ResourceMapping
+	keys map[string]string
+}
+
+// Index builds the case insensitive index of the resource mapping, which shall be reused for multiple lookups.
+func (m ResourceMapping) Index() Index {
+	return Index{m: m, keys: m.index()}
+}
+
+// Lookup looks up the entity of the Azure resource id case insensitively, preferring the exact match.
+// It also returns the key of the entity, which is in its original casing.
+func (idx Index) Lookup(id string) (string, ResourceMapEntity, bool) {
+	if entity, ok := idx.m[id]; ok {
+		return id, entity, true
+	}
+	key, ok := idx.keys[strings.ToUpper(id)]
+	if !ok {
+		return "", ResourceMapEntity{}, false
+	}
+	return key, idx.m[key], true
+}
+
+// Validate ensures that there are no duplicate Azure resource ids, which are compared case insensitively.
+func (m ResourceMapping) Validate() error {
+	var dups []string
+	keys := map[string]string{}
+	for id := range m {
+		key := strings.ToUpper(id)
+		if oid, ok := keys[key]; ok {
+			dups = append(dups, fmt.Sprintf("%s and %s", oid, id))
+			continue
+		}
+		keys[key] = id
+	}
+	if len(dups) != 0 {
+		sort.Strings(dups)
+		return fmt.Errorf("duplicate resource ids (case insensitively): %s", strings.Join(dups, "; "))
+	}
+	return nil
+}
+
+// index returns the Azure resource ids, keyed by their upper cased forms.
+func (m ResourceMapping) index() map[string]string {
+	out := map[string]string{}
+	for id := range m {
+		out[strings.ToUpper(id)] = id
+	}
+	return out
+}
@@ -0,0 +1,36 @@
+package resmap
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestResourceMappingIndexLookup(t *testing.T) {
+	m := ResourceMapping{
+		"/subscriptions/123/resourceGroups/rg1": {
+			ResourceId:   "/subscriptions/123/resourceGroups/rg1",
+			ResourceType: "azurerm_resource_group",
+			ResourceName: "res-0",
+		},
+	}
+	idx := m.Index()
+	key, entity, ok := idx.Lookup("/SUBSCRIPTIONS/123/resourcegroups/RG1")
+	require.True(t, ok)
+	require.Equal(t, "/subscriptions/123/resourceGroups/rg1", key)
+	require.Equal(t, "res-0", entity.ResourceName)
+
+	_, _, ok = idx.Lookup("/subscriptions/123/resourceGroups/rg2")
+	require.False(t, ok)
+}
+
+func TestResourceMappingValidate(t *testing.T) {
+	m := ResourceMapping{
+		"/subscriptions/123/resourceGroups/rg1": {},
+		"/subscriptions/123/resourceGroups/rg2": {},
+	}
+	require.NoError(t, m.Validate())
+
+	m["/subscriptions/123/resourcegroups/RG1"] = ResourceMapEntity{}
+	require.ErrorContains(t, m.Validate(), "duplicate resource ids")
+}
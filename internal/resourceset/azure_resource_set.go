@@ -18,6 +18,8 @@ type AzureResourceSet struct {
 
 type AzureResource struct {
 	Id armid.ResourceId
+	// Tags are the tags of the resource, if any.
+	Tags map[string]string
 }
 
 type PesudoResourceInfo struct {
@@ -32,6 +34,7 @@ func (rset AzureResourceSet) ToTFAzureRMResources(logger *slog.Logger, paralleli
 
 	type result struct {
 		resid   armid.ResourceId
+		tags    map[string]string
 		tftypes []aztft.Type
 		tfids   []string
 		exact   bool
@@ -47,6 +50,7 @@ func (rset AzureResourceSet) ToTFAzureRMResources(logger *slog.Logger, paralleli
 				AzureId: res.resid,
 				// Use the azure ID as the TF ID as a fallback
 				TFId: res.resid.String(),
+				Tags: res.tags,
 			})
 		} else {
 			if !res.exact {
@@ -57,6 +61,7 @@ func (rset AzureResourceSet) ToTFAzureRMResources(logger *slog.Logger, paralleli
 					AzureId: res.resid,
 					// Use the azure ID as the TF ID as a fallback
 					TFId: res.resid.String(),
+					Tags: res.tags,
 				})
 			} else {
 				for i := range res.tfids {
@@ -64,6 +69,7 @@ func (rset AzureResourceSet) ToTFAzureRMResources(logger *slog.Logger, paralleli
 						AzureId: res.tftypes[i].AzureId,
 						TFId:    res.tfids[i],
 						TFType:  res.tftypes[i].TFType,
+						Tags:    res.tags,
 					})
 				}
 			}
@@ -82,6 +88,7 @@ func (rset AzureResourceSet) ToTFAzureRMResources(logger *slog.Logger, paralleli
 			)
 			return result{
 				resid:   res.Id,
+				tags:    res.Tags,
 				tftypes: tftypes,
 				tfids:   tfids,
 				exact:   exact,
@@ -106,6 +113,7 @@ func (rset AzureResourceSet) ToTFAzAPIResources() (result []TFResource) {
 			AzureId: res.Id,
 			TFId:    res.Id.String(),
 			TFType:  "azapi_resource",
+			Tags:    res.Tags,
 		})
 	}
 	return
@@ -34,7 +34,8 @@ func (rset *AzureResourceSet) reduceForKeyVaultCertificate() error {
 		certId := res.Id.Clone().(*armid.ScopedResourceId)
 		certId.AttrTypes[len(certId.AttrTypes)-1] = "certificates"
 		newResoruces = append(newResoruces, AzureResource{
-			Id: certId,
+			Id:   certId,
+			Tags: res.Tags,
 		})
 	}
 	for _, res := range pending {
@@ -6,4 +6,6 @@ type TFResource struct {
 	AzureId armid.ResourceId
 	TFId    string
 	TFType  string
+	// Tags are the tags of the Azure resource that this TF resource is mapped from.
+	Tags map[string]string
 }
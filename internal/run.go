@@ -79,6 +79,8 @@ func BatchImport(ctx context.Context, cfg config.NonInteractiveModeConfig) error
 				idx := i + j
 				if list[idx].Skip() {
 					messages = append(messages, fmt.Sprintf("(%d/%d) Skipping %s", idx+1, len(list), list[idx].TFResourceId))
+				} else if list[idx].Managed {
+					messages = append(messages, fmt.Sprintf("(%d/%d) Skipping %s (already managed as %s)", idx+1, len(list), list[idx].TFResourceId, list[idx].TFAddr))
 				} else {
 					messages = append(messages, fmt.Sprintf("(%d/%d) Importing %s as %s", idx+1, len(list), list[idx].TFResourceId, list[idx].TFAddr))
 				}
@@ -0,0 +1,276 @@
+package spec
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2/gohcl"
+	"github.com/hashicorp/hcl/v2/hclparse"
+	"gopkg.in/yaml.v3"
+)
+
+// Spec describes a sequence of export jobs, which is defined in either HCL (e.g. aztfexport.hcl) or YAML (e.g. aztfexport.yaml).
+//
+// In HCL, each job is a "job" block labeled by the job name:
+//
+//	job "network" {
+//	  resource_group = "rg-network"
+//	  output_dir     = "./network"
+//	}
+//
+// In YAML, the jobs are listed under the "jobs" key, with the job name specified by the "name" key.
+type Spec struct {
+	Jobs []Job `hcl:"job,block" yaml:"jobs"`
+}
+
+// Job describes an export job. Exactly one of the scopes (ResourceIds, ResourceGroup, Query, KQL, MappingFile, Subscription or ManagementGroup) must be specified.
+// ResourceGroups can be specified together with, or instead of, ResourceGroup to export multiple resource groups into one workspace, each of which can be a glob pattern.
+// The unset settings fall back to the command line options.
+type Job struct {
+	Name string `hcl:"name,label" yaml:"name"`
+
+	// Scopes
+	// The resource ids, each of which can also be "@<path>" to read the resource ids from a file, same as the command line.
+	ResourceIds    []string `hcl:"resource_ids,optional" yaml:"resource_ids"`
+	ResourceGroup  string   `hcl:"resource_group,optional" yaml:"resource_group"`
+	ResourceGroups []string `hcl:"resource_groups,optional" yaml:"resource_groups"`
+	Query          string   `hcl:"query,optional" yaml:"query"`
+	// The KQL query, or the path to a ".kql" file.
+	KQL             string `hcl:"kql,optional" yaml:"kql"`
+	MappingFile     string `hcl:"mapping_file,optional" yaml:"mapping_file"`
+	Subscription    bool   `hcl:"subscription,optional" yaml:"subscription"`
+	ManagementGroup string `hcl:"management_group,optional" yaml:"management_group"`
+
+	// The output directory, which defaults to the directory named by the job name, next to the spec file.
+	OutputDir      string `hcl:"output_dir,optional" yaml:"output_dir"`
+	SubscriptionId string `hcl:"subscription_id,optional" yaml:"subscription_id"`
+	Overwrite      *bool  `hcl:"overwrite,optional" yaml:"overwrite"`
+	Append         *bool  `hcl:"append,optional" yaml:"append"`
+
+	// Resource naming
+	NamePattern  string `hcl:"name_pattern,optional" yaml:"name_pattern"`
+	NamingPolicy string `hcl:"naming_policy,optional" yaml:"naming_policy"`
+	ResourceName string `hcl:"resource_name,optional" yaml:"resource_name"`
+	ResourceType string `hcl:"resource_type,optional" yaml:"resource_type"`
+
+	// Resource listing
+	Recursive                   *bool    `hcl:"recursive,optional" yaml:"recursive"`
+	IncludeResourceGroup        *bool    `hcl:"include_resource_group,optional" yaml:"include_resource_group"`
+	IncludeManagedResource      *bool    `hcl:"include_managed_resource,optional" yaml:"include_managed_resource"`
+	IncludeExtensions           []string `hcl:"include_extensions,optional" yaml:"include_extensions"`
+	ExcludeAzureResources       []string `hcl:"exclude_azure_resources,optional" yaml:"exclude_azure_resources"`
+	ExcludeTerraformResources   []string `hcl:"exclude_terraform_resources,optional" yaml:"exclude_terraform_resources"`
+	ARGTable                    string   `hcl:"arg_table,optional" yaml:"arg_table"`
+	ARGAuthorizationScopeFilter string   `hcl:"arg_authorization_scope_filter,optional" yaml:"arg_authorization_scope_filter"`
+	AdditionalSubscriptionIds   []string `hcl:"additional_subscription_ids,optional" yaml:"additional_subscription_ids"`
+	GovernanceOnly              *bool    `hcl:"governance_only,optional" yaml:"governance_only"`
+	// The tags (key to value) that the resources to export must have, an empty value matches any value. Only for the resource group and query scopes.
+	Tags map[string]string `hcl:"tags,optional" yaml:"tags"`
+
+	// Provider and backend
+	Provider        string            `hcl:"provider,optional" yaml:"provider"`
+	ProviderVersion string            `hcl:"provider_version,optional" yaml:"provider_version"`
+	BackendType     string            `hcl:"backend_type,optional" yaml:"backend_type"`
+	BackendConfig   map[string]string `hcl:"backend_config,optional" yaml:"backend_config"`
+
+	// Config generation
+	ConfigMode          string `hcl:"config_mode,optional" yaml:"config_mode"`
+	OutputFormat        string `hcl:"output_format,optional" yaml:"output_format"`
+	FileLayout          string `hcl:"file_layout,optional" yaml:"file_layout"`
+	HCLOnly             *bool  `hcl:"hcl_only,optional" yaml:"hcl_only"`
+	GenerateImportBlock *bool  `hcl:"generate_import_block,optional" yaml:"generate_import_block"`
+}
+
+type ScopeKind string
+
+const (
+	ScopeResource        ScopeKind = "resource"
+	ScopeResourceGroup   ScopeKind = "resource-group"
+	ScopeQuery           ScopeKind = "query"
+	ScopeKQL             ScopeKind = "kql"
+	ScopeMappingFile     ScopeKind = "mapping-file"
+	ScopeSubscription    ScopeKind = "subscription"
+	ScopeManagementGroup ScopeKind = "management-group"
+)
+
+// Scope returns the kind of the scope of the job, together with its arguments in the same form as the command line arguments of the corresponding subcommand.
+func (job Job) Scope() (ScopeKind, []string) {
+	switch {
+	case len(job.ResourceIds) != 0:
+		return ScopeResource, job.ResourceIds
+	case job.ResourceGroup != "" || len(job.ResourceGroups) != 0:
+		if job.ResourceGroup == "" {
+			return ScopeResourceGroup, job.ResourceGroups
+		}
+		return ScopeResourceGroup, append([]string{job.ResourceGroup}, job.ResourceGroups...)
+	case job.Query != "":
+		return ScopeQuery, []string{job.Query}
+	case job.KQL != "":
+		return ScopeKQL, []string{job.KQL}
+	case job.Subscription:
+		return ScopeSubscription, nil
+	case job.ManagementGroup != "":
+		return ScopeManagementGroup, []string{job.ManagementGroup}
+	default:
+		return ScopeMappingFile, []string{job.MappingFile}
+	}
+}
+
+// BackendConfigList returns the backend config in the form of "key=value", sorted by the keys.
+func (job Job) BackendConfigList() []string {
+	return keyValueList(job.BackendConfig)
+}
+
+// TagList returns the tags in the form of "key=value" (or "key" for an empty value), sorted by the keys.
+func (job Job) TagList() []string {
+	if job.Tags == nil {
+		return nil
+	}
+	var out []string
+	for _, kv := range keyValueList(job.Tags) {
+		out = append(out, strings.TrimSuffix(kv, "="))
+	}
+	return out
+}
+
+func keyValueList(m map[string]string) []string {
+	var out []string
+	for k, v := range m {
+		out = append(out, k+"="+v)
+	}
+	sort.Strings(out)
+	return out
+}
+
+func (job Job) validate() error {
+	var scopes []string
+	if len(job.ResourceIds) != 0 {
+		scopes = append(scopes, "resource_ids")
+	}
+	if job.ResourceGroup != "" || len(job.ResourceGroups) != 0 {
+		scopes = append(scopes, "resource_group")
+	}
+	if job.Query != "" {
+		scopes = append(scopes, "query")
+	}
+	if job.KQL != "" {
+		scopes = append(scopes, "kql")
+	}
+	if job.MappingFile != "" {
+		scopes = append(scopes, "mapping_file")
+	}
+	if job.Subscription {
+		scopes = append(scopes, "subscription")
+	}
+	if job.ManagementGroup != "" {
+		scopes = append(scopes, "management_group")
+	}
+	switch len(scopes) {
+	case 0:
+		return fmt.Errorf("one of resource_ids, resource_group (or resource_groups), query, kql, mapping_file, subscription and management_group must be specified")
+	case 1:
+	default:
+		return fmt.Errorf("only one of the followings can be specified: %s", strings.Join(scopes, ", "))
+	}
+
+	if job.ResourceName != "" || job.ResourceType != "" {
+		if len(job.ResourceIds) != 1 {
+			return fmt.Errorf("resource_name and resource_type can only be specified for a single resource")
+		}
+	}
+
+	if len(job.Tags) != 0 {
+		if kind, _ := job.Scope(); kind != ScopeResourceGroup && kind != ScopeQuery {
+			return fmt.Errorf("tags can only be specified for the resource_group (or resource_groups) and query scopes")
+		}
+	}
+	return nil
+}
+
+// DecodeFile decodes the file into v, which is in HCL, JSON (in the HCL JSON syntax) or YAML, determined by the file extension.
+// The YAML file is decoded strictly, i.e. the unknown fields are not allowed.
+func DecodeFile(path string, v any) error {
+	// #nosec G304
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("reading %s: %v", path, err)
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		dec := yaml.NewDecoder(bytes.NewReader(b))
+		dec.KnownFields(true)
+		if err := dec.Decode(v); err != nil && err != io.EOF {
+			return fmt.Errorf("unmarshalling %s: %v", path, err)
+		}
+	default:
+		parser := hclparse.NewParser()
+		parse := parser.ParseHCL
+		if strings.ToLower(filepath.Ext(path)) == ".json" {
+			parse = parser.ParseJSON
+		}
+		f, diags := parse(b, path)
+		if diags.HasErrors() {
+			return fmt.Errorf("parsing %s: %v", path, diags.Error())
+		}
+		if diags := gohcl.DecodeBody(f.Body, nil, v); diags.HasErrors() {
+			return fmt.Errorf("decoding %s: %v", path, diags.Error())
+		}
+	}
+	return nil
+}
+
+// ParseFile parses the spec file, whose format is determined by the file extension: ".yaml" and ".yml" for YAML, otherwise HCL (or the JSON variant of HCL for ".json").
+// The relative paths in the spec are resolved against the directory of the spec file.
+func ParseFile(path string) (*Spec, error) {
+	var spec Spec
+	if err := DecodeFile(path, &spec); err != nil {
+		return nil, err
+	}
+
+	if len(spec.Jobs) == 0 {
+		return nil, fmt.Errorf("no job defined in %s", path)
+	}
+	dir := filepath.Dir(path)
+	names := map[string]bool{}
+	for i := range spec.Jobs {
+		job := &spec.Jobs[i]
+		if job.Name == "" {
+			return nil, fmt.Errorf("the name of the job %d is not specified", i)
+		}
+		if names[job.Name] {
+			return nil, fmt.Errorf("duplicate job %q", job.Name)
+		}
+		names[job.Name] = true
+		if err := job.validate(); err != nil {
+			return nil, fmt.Errorf("invalid job %q: %v", job.Name, err)
+		}
+
+		if job.OutputDir == "" {
+			job.OutputDir = job.Name
+		}
+		if !filepath.IsAbs(job.OutputDir) {
+			job.OutputDir = filepath.Join(dir, job.OutputDir)
+		}
+		if job.MappingFile != "" && !filepath.IsAbs(job.MappingFile) {
+			job.MappingFile = filepath.Join(dir, job.MappingFile)
+		}
+		if strings.EqualFold(filepath.Ext(job.KQL), ".kql") && !filepath.IsAbs(job.KQL) {
+			job.KQL = filepath.Join(dir, job.KQL)
+		}
+		for i, id := range job.ResourceIds {
+			if p, ok := strings.CutPrefix(id, "@"); ok && !filepath.IsAbs(p) {
+				job.ResourceIds[i] = "@" + filepath.Join(dir, p)
+			}
+		}
+		if job.NamingPolicy != "" && !filepath.IsAbs(job.NamingPolicy) {
+			job.NamingPolicy = filepath.Join(dir, job.NamingPolicy)
+		}
+	}
+	return &spec, nil
+}
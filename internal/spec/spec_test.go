@@ -0,0 +1,279 @@
+package spec
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseFile(t *testing.T) {
+	overwrite := true
+
+	cases := []struct {
+		name     string
+		file     string
+		content  string
+		expected func(dir string) Spec
+		err      string
+	}{
+		{
+			name: "HCL",
+			file: "aztfexport.hcl",
+			content: `
+job "network" {
+  resource_group = "rg1"
+  name_pattern   = "net-"
+  naming_policy  = "naming.hcl"
+  overwrite      = true
+  backend_config = {
+    key = "network.tfstate"
+  }
+}
+
+job "vm" {
+  resource_ids  = ["/subscriptions/123/resourceGroups/rg2/providers/Microsoft.Compute/virtualMachines/vm1"]
+  resource_name = "vm"
+  output_dir    = "/tmp/vm"
+}
+
+job "mapping" {
+  mapping_file = "mapping/aztfexportResourceMapping.json"
+}
+`,
+			expected: func(dir string) Spec {
+				return Spec{Jobs: []Job{
+					{
+						Name:          "network",
+						ResourceGroup: "rg1",
+						NamePattern:   "net-",
+						NamingPolicy:  filepath.Join(dir, "naming.hcl"),
+						Overwrite:     &overwrite,
+						BackendConfig: map[string]string{"key": "network.tfstate"},
+						OutputDir:     filepath.Join(dir, "network"),
+					},
+					{
+						Name:         "vm",
+						ResourceIds:  []string{"/subscriptions/123/resourceGroups/rg2/providers/Microsoft.Compute/virtualMachines/vm1"},
+						ResourceName: "vm",
+						OutputDir:    "/tmp/vm",
+					},
+					{
+						Name:        "mapping",
+						MappingFile: filepath.Join(dir, "mapping", "aztfexportResourceMapping.json"),
+						OutputDir:   filepath.Join(dir, "mapping"),
+					},
+				}}
+			},
+		},
+		{
+			name: "YAML",
+			file: "aztfexport.yaml",
+			content: `
+jobs:
+  - name: network
+    query: type =~ "microsoft.network/"
+    include_extensions:
+      - Microsoft.Authorization/roleAssignments
+    overwrite: true
+`,
+			expected: func(dir string) Spec {
+				return Spec{Jobs: []Job{
+					{
+						Name:              "network",
+						Query:             `type =~ "microsoft.network/"`,
+						IncludeExtensions: []string{"Microsoft.Authorization/roleAssignments"},
+						Overwrite:         &overwrite,
+						OutputDir:         filepath.Join(dir, "network"),
+					},
+				}}
+			},
+		},
+		{
+			name: "Relative paths of the scopes",
+			file: "aztfexport.hcl",
+			content: `
+job "ids" {
+  resource_ids = ["@ids.txt", "/subscriptions/123/resourceGroups/rg1"]
+}
+
+job "kql" {
+  kql = "queries/network.kql"
+}
+
+job "sub" {
+  subscription    = true
+  governance_only = true
+}
+
+job "mg" {
+  management_group = "mg1"
+}
+`,
+			expected: func(dir string) Spec {
+				governanceOnly := true
+				return Spec{Jobs: []Job{
+					{
+						Name:        "ids",
+						ResourceIds: []string{"@" + filepath.Join(dir, "ids.txt"), "/subscriptions/123/resourceGroups/rg1"},
+						OutputDir:   filepath.Join(dir, "ids"),
+					},
+					{
+						Name:      "kql",
+						KQL:       filepath.Join(dir, "queries", "network.kql"),
+						OutputDir: filepath.Join(dir, "kql"),
+					},
+					{
+						Name:           "sub",
+						Subscription:   true,
+						GovernanceOnly: &governanceOnly,
+						OutputDir:      filepath.Join(dir, "sub"),
+					},
+					{
+						Name:            "mg",
+						ManagementGroup: "mg1",
+						OutputDir:       filepath.Join(dir, "mg"),
+					},
+				}}
+			},
+		},
+		{
+			name: "Tags for a non-query scope",
+			file: "aztfexport.hcl",
+			content: `
+job "foo" {
+  resource_ids = ["/subscriptions/123/resourceGroups/rg1"]
+  tags = {
+    env = "prod"
+  }
+}
+`,
+			err: `invalid job "foo": tags can only be specified for the resource_group (or resource_groups) and query scopes`,
+		},
+		{
+			name:    "No job",
+			file:    "aztfexport.hcl",
+			content: ``,
+			err:     "no job defined",
+		},
+		{
+			name: "No scope",
+			file: "aztfexport.hcl",
+			content: `
+job "foo" {}
+`,
+			err: `invalid job "foo": one of resource_ids, resource_group (or resource_groups), query, kql, mapping_file, subscription and management_group must be specified`,
+		},
+		{
+			name: "Multiple scopes",
+			file: "aztfexport.yml",
+			content: `
+jobs:
+  - name: foo
+    resource_group: rg1
+    query: type =~ "microsoft.network/"
+`,
+			err: `invalid job "foo": only one of the followings can be specified: resource_group, query`,
+		},
+		{
+			name: "Resource name for multiple resources",
+			file: "aztfexport.hcl",
+			content: `
+job "foo" {
+  resource_ids  = ["/subscriptions/123/resourceGroups/rg1", "/subscriptions/123/resourceGroups/rg2"]
+  resource_name = "rg"
+}
+`,
+			err: `invalid job "foo": resource_name and resource_type can only be specified for a single resource`,
+		},
+		{
+			name: "Duplicate jobs",
+			file: "aztfexport.hcl",
+			content: `
+job "foo" {
+  resource_group = "rg1"
+}
+job "foo" {
+  resource_group = "rg2"
+}
+`,
+			err: `duplicate job "foo"`,
+		},
+		{
+			name: "Unknown attribute",
+			file: "aztfexport.hcl",
+			content: `
+job "foo" {
+  resource_group = "rg1"
+  foo            = "bar"
+}
+`,
+			err: `Unsupported argument`,
+		},
+		{
+			name: "Unknown YAML key",
+			file: "aztfexport.yaml",
+			content: `
+jobs:
+  - name: foo
+    resource_group: rg1
+    foo: bar
+`,
+			err: `field foo not found`,
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			path := filepath.Join(dir, tt.file)
+			require.NoError(t, os.WriteFile(path, []byte(tt.content), 0644))
+			spec, err := ParseFile(path)
+			if tt.err != "" {
+				require.ErrorContains(t, err, tt.err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.expected(dir), *spec)
+		})
+	}
+}
+
+func TestJob_Scope(t *testing.T) {
+	kind, args := Job{ResourceIds: []string{"id1", "id2"}}.Scope()
+	require.Equal(t, ScopeResource, kind)
+	require.Equal(t, []string{"id1", "id2"}, args)
+
+	kind, args = Job{ResourceGroup: "rg1"}.Scope()
+	require.Equal(t, ScopeResourceGroup, kind)
+	require.Equal(t, []string{"rg1"}, args)
+
+	kind, args = Job{ResourceGroup: "rg1", ResourceGroups: []string{"app-*"}}.Scope()
+	require.Equal(t, ScopeResourceGroup, kind)
+	require.Equal(t, []string{"rg1", "app-*"}, args)
+
+	kind, args = Job{Query: "foo"}.Scope()
+	require.Equal(t, ScopeQuery, kind)
+	require.Equal(t, []string{"foo"}, args)
+
+	kind, args = Job{KQL: "resources | where type =~ 'microsoft.network/virtualnetworks'"}.Scope()
+	require.Equal(t, ScopeKQL, kind)
+	require.Equal(t, []string{"resources | where type =~ 'microsoft.network/virtualnetworks'"}, args)
+
+	kind, args = Job{MappingFile: "map.json"}.Scope()
+	require.Equal(t, ScopeMappingFile, kind)
+	require.Equal(t, []string{"map.json"}, args)
+
+	kind, args = Job{Subscription: true}.Scope()
+	require.Equal(t, ScopeSubscription, kind)
+	require.Empty(t, args)
+
+	kind, args = Job{ManagementGroup: "mg1"}.Scope()
+	require.Equal(t, ScopeManagementGroup, kind)
+	require.Equal(t, []string{"mg1"}, args)
+
+	require.Equal(t, []string{"a=1", "b=2"}, Job{BackendConfig: map[string]string{"b": "2", "a": "1"}}.BackendConfigList())
+	require.Equal(t, []string{"env=prod", "owner"}, Job{Tags: map[string]string{"owner": "", "env": "prod"}}.TagList())
+	require.Nil(t, Job{}.TagList())
+}
@@ -3,20 +3,34 @@ package tfaddr
 import (
 	"fmt"
 	"strings"
+
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/zclconf/go-cty/cty"
 )
 
 type TFAddr struct {
 	Type string
 	Name string
+	// Key is the instance key of a resource with "for_each" set. It is empty for a single instance resource.
+	Key string
 }
 
 func (res TFAddr) String() string {
 	if res.Type == "" {
 		return ""
 	}
+	if res.Key != "" {
+		// The key is rendered as an HCL string literal, which escapes differently from Go (e.g. the template sequences).
+		return fmt.Sprintf("%s.%s[%s]", res.Type, res.Name, hclwrite.TokensForValue(cty.StringVal(res.Key)).Bytes())
+	}
 	return res.Type + "." + res.Name
 }
 
+// ResourceAddr returns the address of the resource block, i.e. without the instance key.
+func (res TFAddr) ResourceAddr() TFAddr {
+	return TFAddr{Type: res.Type, Name: res.Name}
+}
+
 func ParseTFResourceAddr(v string) (*TFAddr, error) {
 	segs := strings.Split(v, ".")
 	if len(segs) != 2 || segs[0] == "" || segs[1] == "" {
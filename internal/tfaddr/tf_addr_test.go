@@ -0,0 +1,24 @@
+package tfaddr
+
+import (
+	"testing"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTFAddrString(t *testing.T) {
+	require.Equal(t, "", TFAddr{}.String())
+	require.Equal(t, "azurerm_resource_group.res-0", TFAddr{Type: "azurerm_resource_group", Name: "res-0"}.String())
+	require.Equal(t, `azurerm_resource_group.res-0["rg1"]`, TFAddr{Type: "azurerm_resource_group", Name: "res-0", Key: "rg1"}.String())
+
+	// The address round-trips with the keys that need to be escaped in HCL.
+	for _, key := range []string{`a"b`, `a\b`, "${foo}", "%{foo}", "中文"} {
+		addr := TFAddr{Type: "azurerm_resource_group", Name: "res-0", Key: key}
+		traversal, diags := hclsyntax.ParseTraversalAbs([]byte(addr.String()), "", hcl.InitialPos)
+		require.False(t, diags.HasErrors(), diags.Error())
+		require.Len(t, traversal, 3)
+		require.Equal(t, key, traversal[2].(hcl.TraverseIndex).Key.AsString())
+	}
+}
@@ -48,6 +48,15 @@ type ExportSkippedResourcesDoneMsg struct {
 	List meta.ImportList
 }
 
+type ListAmbiguousDependenciesDoneMsg struct {
+	List meta.ImportList
+	Deps []meta.AmbiguousDependency
+}
+
+type ResolveDependenciesDoneMsg struct {
+	List meta.ImportList
+}
+
 type GenerateCfgDoneMsg struct{}
 
 type WorkspaceCleanupDoneMsg struct{}
@@ -100,7 +109,7 @@ func ImportItems(ctx context.Context, c meta.Meta, items []meta.ImportItem) tea.
 	return func() tea.Msg {
 		var l []*meta.ImportItem
 		for i := range items {
-			if items[i].Skip() || items[i].Imported {
+			if items[i].Skip() || items[i].Managed || items[i].Imported {
 				continue
 			}
 			l = append(l, &items[i])
@@ -163,6 +172,23 @@ func ExportSkippedResources(ctx context.Context, c meta.Meta, l meta.ImportList)
 	}
 }
 
+func ListAmbiguousDependencies(ctx context.Context, c meta.Meta, l meta.ImportList) tea.Cmd {
+	return func() tea.Msg {
+		deps, err := c.GetAmbiguousDependencies(ctx, l)
+		if err != nil {
+			return ErrMsg(err)
+		}
+		return ListAmbiguousDependenciesDoneMsg{List: l, Deps: deps}
+	}
+}
+
+func ResolveDependencies(c meta.Meta, l meta.ImportList, r meta.DependencyResolutions) tea.Cmd {
+	return func() tea.Msg {
+		c.SetDependencyResolutions(r)
+		return ResolveDependenciesDoneMsg{List: l}
+	}
+}
+
 func CleanTFState(addr string) tea.Cmd {
 	return func() tea.Msg {
 		return CleanTFStateMsg{addr}
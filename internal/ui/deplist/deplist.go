@@ -0,0 +1,112 @@
+package deplist
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Azure/aztfexport/pkg/meta"
+
+	"github.com/Azure/aztfexport/internal/ui/aztfexportclient"
+	"github.com/Azure/aztfexport/internal/ui/common"
+
+	"github.com/charmbracelet/bubbles/help"
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+var selectedStyle = lipgloss.NewStyle().Foreground(common.Fuschia)
+
+// Model lets the user resolve the ambiguous dependencies one by one, by choosing one of the candidates, or skipping it to keep it as a comment.
+type Model struct {
+	ctx  context.Context
+	c    meta.Meta
+	l    meta.ImportList
+	deps []meta.AmbiguousDependency
+
+	// The index of the current ambiguous dependency, and the cursor of its candidates.
+	idx    int
+	cursor int
+
+	resolutions meta.DependencyResolutions
+
+	keys keyMap
+	help help.Model
+}
+
+func NewModel(ctx context.Context, c meta.Meta, l meta.ImportList, deps []meta.AmbiguousDependency) Model {
+	return Model{
+		ctx:         ctx,
+		c:           c,
+		l:           l,
+		deps:        deps,
+		resolutions: meta.DependencyResolutions{},
+		keys:        newKeyMap(),
+		help:        help.New(),
+	}
+}
+
+func (m Model) Init() tea.Cmd {
+	return nil
+}
+
+func (m Model) Update(msg tea.Msg) (Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.help.Width = msg.Width
+		return m, nil
+	case tea.KeyMsg:
+		if m.idx >= len(m.deps) {
+			return m, nil
+		}
+		dep := m.deps[m.idx]
+		switch {
+		case key.Matches(msg, m.keys.up):
+			if m.cursor > 0 {
+				m.cursor--
+			}
+		case key.Matches(msg, m.keys.down):
+			if m.cursor < len(dep.Candidates)-1 {
+				m.cursor++
+			}
+		case key.Matches(msg, m.keys.choose):
+			m.resolutions.Set(dep.AzureResourceId, dep.TFResourceId, dep.Candidates[m.cursor].AzureResourceId)
+			return m.next()
+		case key.Matches(msg, m.keys.skip):
+			return m.next()
+		case key.Matches(msg, m.keys.skipAll):
+			m.idx = len(m.deps)
+			return m, aztfexportclient.ResolveDependencies(m.c, m.l, m.resolutions)
+		}
+	}
+	return m, nil
+}
+
+func (m Model) next() (Model, tea.Cmd) {
+	m.idx++
+	m.cursor = 0
+	if m.idx >= len(m.deps) {
+		return m, aztfexportclient.ResolveDependencies(m.c, m.l, m.resolutions)
+	}
+	return m, nil
+}
+
+func (m Model) View() string {
+	if m.idx >= len(m.deps) {
+		return ""
+	}
+	dep := m.deps[m.idx]
+
+	s := common.SubtitleStyle.Render(fmt.Sprintf(" Ambiguous Dependency (%d/%d) ", m.idx+1, len(m.deps))) + "\n\n"
+	s += fmt.Sprintf("%s references %s, which maps to multiple resources:\n\n", dep.TFAddr, dep.TFResourceId)
+	for i, candidate := range dep.Candidates {
+		line := fmt.Sprintf("%s (%s)", candidate.TFAddr, candidate.AzureResourceId)
+		if i == m.cursor {
+			s += selectedStyle.Render("> "+line) + "\n"
+		} else {
+			s += "  " + line + "\n"
+		}
+	}
+	s += "\n" + m.help.ShortHelpView(m.keys.ToBindings())
+	return s
+}
@@ -0,0 +1,46 @@
+package deplist
+
+import "github.com/charmbracelet/bubbles/key"
+
+type keyMap struct {
+	up      key.Binding
+	down    key.Binding
+	choose  key.Binding
+	skip    key.Binding
+	skipAll key.Binding
+}
+
+func newKeyMap() keyMap {
+	return keyMap{
+		up: key.NewBinding(
+			key.WithKeys("up", "k"),
+			key.WithHelp("↑/k", "up"),
+		),
+		down: key.NewBinding(
+			key.WithKeys("down", "j"),
+			key.WithHelp("↓/j", "down"),
+		),
+		choose: key.NewBinding(
+			key.WithKeys("enter"),
+			key.WithHelp("enter", "choose"),
+		),
+		skip: key.NewBinding(
+			key.WithKeys("s"),
+			key.WithHelp("s", "skip"),
+		),
+		skipAll: key.NewBinding(
+			key.WithKeys("S"),
+			key.WithHelp("S", "skip all"),
+		),
+	}
+}
+
+func (m keyMap) ToBindings() []key.Binding {
+	return []key.Binding{
+		m.up,
+		m.down,
+		m.choose,
+		m.skip,
+		m.skipAll,
+	}
+}
@@ -18,7 +18,7 @@ func (i Item) Title() string {
 		return common.WarningEmoji + i.v.TFResourceId
 	case i.v.ImportError != nil:
 		return common.ErrorEmoji + i.v.TFResourceId
-	case i.v.Imported:
+	case i.v.Imported, i.v.Managed:
 		return common.OKEmoji + i.v.TFResourceId
 	default:
 		if i.v.IsRecommended {
@@ -35,11 +35,14 @@ func (i Item) Description() string {
 	if i.v.Skip() {
 		return "(Skip)"
 	}
+	if i.v.Managed {
+		return "(Managed) " + i.textinput.Value()
+	}
 	return i.textinput.Value()
 }
 
 func (i Item) FilterValue() string {
-	if i.v.ValidateError == nil && i.v.ImportError == nil && !i.v.Imported && !i.v.IsRecommended {
+	if i.v.ValidateError == nil && i.v.ImportError == nil && !i.v.Imported && !i.v.Managed && !i.v.IsRecommended {
 		return i.v.TFResourceId
 	}
 	return " " + i.v.TFResourceId
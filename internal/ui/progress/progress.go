@@ -110,7 +110,7 @@ func (m Model) View() string {
 	msg := ""
 	if len(m.l) > m.idx {
 		item := m.l[m.idx]
-		if item.Skip() {
+		if item.Skip() || item.Managed {
 			msg = fmt.Sprintf(" Skipping %s...", item.TFResourceId)
 		} else {
 			msg = fmt.Sprintf(" Importing %s...", item.TFResourceId)
@@ -126,6 +126,8 @@ func (m Model) View() string {
 			switch {
 			case res.item.Skip():
 				s += fmt.Sprintf("%s %s skipped\n", res.emoji, res.item.TFResourceId)
+			case res.item.Managed:
+				s += fmt.Sprintf("%s %s already managed\n", res.emoji, res.item.TFResourceId)
 			default:
 				if res.item.ImportError == nil {
 					s += fmt.Sprintf("%s %s import successfully\n", res.emoji, res.item.TFResourceId)
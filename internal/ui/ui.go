@@ -11,6 +11,7 @@ import (
 
 	"github.com/Azure/aztfexport/internal/ui/aztfexportclient"
 	"github.com/Azure/aztfexport/internal/ui/common"
+	"github.com/Azure/aztfexport/internal/ui/deplist"
 	"github.com/mitchellh/go-wordwrap"
 
 	"github.com/muesli/reflow/indent"
@@ -40,6 +41,8 @@ const (
 	statusBuildingImportList
 	statusImporting
 	statusImportErrorMsg
+	statusListingAmbiguousDependencies
+	statusResolvingDependencies
 	statusGeneratingCfg
 	statusCleaningUpWorkspaceCfg
 	statusPushState
@@ -57,6 +60,8 @@ func (s status) String() string {
 		"building import list",
 		"importing",
 		"import error message",
+		"listing ambiguous dependencies",
+		"resolving dependencies",
 		"generating Terraform configuration",
 		"cleaning up output directory",
 		"pushing state",
@@ -83,6 +88,7 @@ type model struct {
 	importlist     importlist.Model
 	progress       progress.Model
 	importerrormsg aztfexportclient.ShowImportErrorMsg
+	deplist        deplist.Model
 }
 
 func newModel(ctx context.Context, cfg config.InteractiveModeConfig) (*model, error) {
@@ -179,6 +185,18 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.status = statusExportSkippedResources
 		return m, aztfexportclient.ExportSkippedResources(m.ctx, m.meta, msg.List)
 	case aztfexportclient.ExportSkippedResourcesDoneMsg:
+		m.status = statusListingAmbiguousDependencies
+		return m, aztfexportclient.ListAmbiguousDependencies(m.ctx, m.meta, msg.List)
+	case aztfexportclient.ListAmbiguousDependenciesDoneMsg:
+		if len(msg.Deps) == 0 {
+			m.status = statusGeneratingCfg
+			return m, aztfexportclient.GenerateCfg(m.ctx, m.meta, msg.List)
+		}
+		m.status = statusResolvingDependencies
+		m.deplist = deplist.NewModel(m.ctx, m.meta, msg.List, msg.Deps)
+		cmd := func() tea.Msg { return m.winsize }
+		return m, cmd
+	case aztfexportclient.ResolveDependenciesDoneMsg:
 		m.status = statusGeneratingCfg
 		return m, aztfexportclient.GenerateCfg(m.ctx, m.meta, msg.List)
 	case aztfexportclient.GenerateCfgDoneMsg:
@@ -217,6 +235,9 @@ func updateChildren(msg tea.Msg, m model) (model, tea.Cmd) {
 	case statusImporting:
 		m.progress, cmd = m.progress.Update(msg)
 		return m, cmd
+	case statusResolvingDependencies:
+		m.deplist, cmd = m.deplist.Update(msg)
+		return m, cmd
 	case statusSummary:
 		switch msg.(type) {
 		case tea.KeyMsg:
@@ -247,6 +268,10 @@ func (m model) View() string {
 		s += m.spinner.View() + " Exporting Resource Mapping..."
 	case statusExportSkippedResources:
 		s += m.spinner.View() + " Exporting Skipped Resources..."
+	case statusListingAmbiguousDependencies:
+		s += m.spinner.View() + " Listing Ambiguous Dependencies..."
+	case statusResolvingDependencies:
+		s += m.deplist.View()
 	case statusGeneratingCfg:
 		s += m.spinner.View() + " Generating Terraform Configurations..."
 	case statusCleaningUpWorkspaceCfg:
@@ -1,7 +1,6 @@
 package main
 
 import (
-	"bufio"
 	"bytes"
 	"context"
 	"encoding/json"
@@ -9,6 +8,7 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"slices"
 	"sort"
 	"strconv"
 	"strings"
@@ -22,7 +22,6 @@ import (
 
 	"github.com/Azure/aztfexport/pkg/config"
 
-	"github.com/magodo/armid"
 	"github.com/magodo/slog2hclog"
 	"github.com/magodo/terraform-client-go/tfclient"
 	"github.com/magodo/tfadd/providers/azapi"
@@ -92,7 +91,7 @@ func prepareConfigFile(ctx *cli.Context) error {
 	return nil
 }
 
-const namePatternUsage = `The pattern of the resource name. The pattern supports at most one index character, either '*' or '+' (exclusively): both expands to an incremental type-scoped index, '*' outputs no suffix for the first element, then 2, 3 and so on, where '+' output 1, 2, and so on. If none is specified, a '*' is implicitly appended at the end of the pattern. The pattern also supports a set of placeholders that are expanded per resource: {type} (the last Azure resource type segment, snake_cased, e.g. 'virtual_machines'), {rp} (the Azure resource provider namespace, snake_cased, e.g. 'microsoft_compute'), {name} (the last name segment of the Azure resource id, snake_cased), {root_scope} (the root scope of the resource, snake_cased, e.g. the resource group name). E.g. '{type}' may expand to 'virtual_machines', 'virtual_machines2', ...`
+const namePatternUsage = `The pattern of the resource name. The pattern supports at most one index character, either '*' or '+' (exclusively): both expands to an incremental type-scoped index, '*' outputs no suffix for the first element, then 2, 3 and so on, where '+' output 1, 2, and so on. If none is specified, a '*' is implicitly appended at the end of the pattern. The pattern also supports a set of placeholders that are expanded per resource: {type} (the last Azure resource type segment, snake_cased, e.g. 'virtual_machines'), {rp} (the Azure resource provider namespace, snake_cased, e.g. 'microsoft_compute'), {name} (the last name segment of the Azure resource id, snake_cased), {root_scope} (the root scope of the resource, snake_cased, e.g. the resource group name), {parent_name} (the name of the parent resource, snake_cased, or the root scope for a top level resource), {tag:<key>} (the value of the resource's tag of the key, snake_cased, or empty if absent). E.g. '{type}' may expand to 'virtual_machines', 'virtual_machines2', ... If not specified, the resources are named semantically, by combining the parent resource name (for child resources), the Azure resource name and a type hint (when the Terraform resource type doesn't tell), suffixed by a short hash of the resource id (e.g. 'vnet1_default_1a2b3c4d'), so that the names are collision free and stable across runs even when the resource set changes`
 
 func main() {
 	commonFlags := []cli.Flag{
@@ -127,6 +126,12 @@ func main() {
 			Usage:       "Imports to the existing state file if any and does not clean up the output directory",
 			Destination: &flagset.flagAppend,
 		},
+		&cli.BoolFlag{
+			Name:        "resume",
+			EnvVars:     []string{"AZTFEXPORT_RESUME"},
+			Usage:       "Resume an interrupted export from the checkpoint journal in the output directory, skipping the resources that have already been imported",
+			Destination: &flagset.flagResume,
+		},
 		&cli.BoolFlag{
 			Name:        "dev-provider",
 			EnvVars:     []string{"AZTFEXPORT_DEV_PROVIDER"},
@@ -165,6 +170,69 @@ func main() {
 			Value:       string(config.ConfigModeMinimal),
 			Destination: &flagset.flagConfigMode,
 		},
+		&cli.StringFlag{
+			Name:        "output-format",
+			EnvVars:     []string{"AZTFEXPORT_OUTPUT_FORMAT"},
+			Usage:       `The syntax of the generated Terraform config and import blocks. Possible values are "hcl" (e.g. main.tf) and "json" (e.g. main.tf.json)`,
+			Value:       string(config.OutputFormatHCL),
+			Destination: &flagset.flagOutputFormat,
+		},
+		&cli.StringFlag{
+			Name:        "file-layout",
+			EnvVars:     []string{"AZTFEXPORT_FILE_LAYOUT"},
+			Usage:       `How the generated Terraform config and import blocks are split into files. Possible values are "single" (all in main.tf), "resource-type" (e.g. azurerm_virtual_network.tf), "provider" (by the resource provider namespace, e.g. network.tf) and "resource-group" (e.g. rg1.tf)`,
+			Value:       string(config.FileLayoutSingle),
+			Destination: &flagset.flagFileLayout,
+		},
+		&cli.StringFlag{
+			Name:        "module-layout",
+			EnvVars:     []string{"AZTFEXPORT_MODULE_LAYOUT"},
+			Usage:       `How the exported resources are organized into local child modules under the "modules" directory, which are called by the root module. Possible values are "none", "resource-group" (e.g. module.rg1), "resource-type" (e.g. module.azurerm_virtual_network), "provider" (by the resource provider namespace, e.g. module.network) and "rule" (by the rules of "--module-rule-file"). The references across the modules are passed through the module outputs and variables`,
+			Value:       string(config.ModuleLayoutNone),
+			Destination: &flagset.flagModuleLayout,
+		},
+		&cli.StringFlag{
+			Name:        "module-rule-file",
+			EnvVars:     []string{"AZTFEXPORT_MODULE_RULE_FILE"},
+			Usage:       `The HCL or YAML file of the rules that group the resources into the child modules for "--module-layout=rule", where each resource goes to the module of the first matched rule (by the TF resource type, Azure resource type or resource id), or the root module if none matches`,
+			Destination: &flagset.flagModuleRuleFile,
+		},
+		&cli.BoolFlag{
+			Name:        "hoist-variables",
+			EnvVars:     []string{"AZTFEXPORT_HOIST_VARIABLES"},
+			Usage:       "Hoist the literal values repeated across the generated resources (e.g. location, tags and the subscription id inside resource ids) into variables defined in variables.tf",
+			Destination: &flagset.flagHoistVariables,
+		},
+		&cli.BoolFlag{
+			Name:        "data-source-for-external-refs",
+			EnvVars:     []string{"AZTFEXPORT_DATA_SOURCE_FOR_EXTERNAL_REFS"},
+			Usage:       "Substitute the references to the resources out of the export scope (e.g. a subnet in another resource group) by the data sources defined in data.tf, rather than the hard-coded resource ids. Only some azurerm data sources are supported",
+			Destination: &flagset.flagDataSourceForExternalRefs,
+		},
+		&cli.BoolFlag{
+			Name:        "generate-dependency-graph",
+			EnvVars:     []string{"AZTFEXPORT_GENERATE_DEPENDENCY_GRAPH"},
+			Usage:       "Write the dependency graph of the exported resources, with the edges labelled by the kinds of the inferred dependencies, as aztfexportDependencyGraph.dot (Graphviz DOT) and aztfexportDependencyGraph.json next to the resource mapping file",
+			Destination: &flagset.flagGenerateDependencyGraph,
+		},
+		&cli.BoolFlag{
+			Name:        "generate-outputs",
+			EnvVars:     []string{"AZTFEXPORT_GENERATE_OUTPUTS"},
+			Usage:       "Generate the outputs.tf that contains an output for the id of each exported resource",
+			Destination: &flagset.flagGenerateOutputs,
+		},
+		&cli.StringSliceFlag{
+			Name:        "output-attribute",
+			EnvVars:     []string{"AZTFEXPORT_OUTPUT_ATTRIBUTE"},
+			Usage:       `The extra attribute to output for a TF resource type, in the form of "<type>.<attribute>" (e.g. "azurerm_user_assigned_identity.principal_id"). This must be used together with --generate-outputs`,
+			Destination: &flagset.flagOutputAttribute,
+		},
+		&cli.BoolFlag{
+			Name:        "consolidate-for-each",
+			EnvVars:     []string{"AZTFEXPORT_CONSOLIDATE_FOR_EACH"},
+			Usage:       "Consolidate the homogeneous resources of the same type, which only differ in a few attributes, into a single resource with for_each",
+			Destination: &flagset.flagConsolidateForEach,
+		},
 		&cli.BoolFlag{
 			Name:        "mask-sensitive",
 			EnvVars:     []string{"AZTFEXPORT_MASK_SENSITIVE"},
@@ -224,6 +292,18 @@ func main() {
 			Usage:       `Whether to generate the import.tf that contains the "import" blocks for the Terraform official plannable importing`,
 			Destination: &flagset.flagGenerateImportBlock,
 		},
+		&cli.StringFlag{
+			Name:        "previous-mapping-file",
+			EnvVars:     []string{"AZTFEXPORT_PREVIOUS_MAPPING_FILE"},
+			Usage:       `The resource mapping file of a previous export, against which the "moved" blocks are generated for the resources whose addresses are changed (e.g. by a different name pattern)`,
+			Destination: &flagset.flagPreviousMappingFile,
+		},
+		&cli.StringFlag{
+			Name:        "dependency-resolution-file",
+			EnvVars:     []string{"AZTFEXPORT_DEPENDENCY_RESOLUTION_FILE"},
+			Usage:       `The file that resolves the ambiguous dependencies (i.e. the referenced resource ids that map to multiple resources) to the chosen resources, e.g. the aztfexportDependencyResolution.json written by a previous interactive export`,
+			Destination: &flagset.flagDependencyResolutionFile,
+		},
 		&cli.StringFlag{
 			Name:        "log-path",
 			EnvVars:     []string{"AZTFEXPORT_LOG_PATH"},
@@ -424,9 +504,14 @@ func main() {
 			EnvVars:     []string{"AZTFEXPORT_NAME_PATTERN"},
 			Aliases:     []string{"p"},
 			Usage:       namePatternUsage + " (only works for multi-resource mode).",
-			Value:       "res-+",
 			Destination: &flagset.flagPattern,
 		},
+		&cli.StringFlag{
+			Name:        "naming-policy",
+			EnvVars:     []string{"AZTFEXPORT_NAMING_POLICY"},
+			Usage:       "The path of the naming policy file (HCL or YAML), whose rules (matching the TF type, Azure type, resource id regex or tags) map the resources to name templates. The first matched rule takes precedence over the name pattern",
+			Destination: &flagset.flagNamingPolicy,
+		},
 		&cli.BoolFlag{
 			Name:        "recursive",
 			EnvVars:     []string{"AZTFEXPORT_RECURSIVE"},
@@ -448,9 +533,20 @@ func main() {
 			EnvVars:     []string{"AZTFEXPORT_NAME_PATTERN"},
 			Aliases:     []string{"p"},
 			Usage:       namePatternUsage,
-			Value:       "res",
 			Destination: &flagset.flagPattern,
 		},
+		&cli.StringFlag{
+			Name:        "naming-policy",
+			EnvVars:     []string{"AZTFEXPORT_NAMING_POLICY"},
+			Usage:       "The path of the naming policy file (HCL or YAML), whose rules (matching the TF type, Azure type, resource id regex or tags) map the resources to name templates. The first matched rule takes precedence over the name pattern",
+			Destination: &flagset.flagNamingPolicy,
+		},
+		&cli.StringSliceFlag{
+			Name:        "tag",
+			EnvVars:     []string{"AZTFEXPORT_TAG"},
+			Usage:       "Only export the resources having the tag, in form of `key=value`, or `key` to match any value. Can be specified multiple times, which are all required to match",
+			Destination: &flagset.flagTag,
+		},
 	}, commonFlags...)
 
 	queryFlags := append([]cli.Flag{
@@ -459,9 +555,14 @@ func main() {
 			EnvVars:     []string{"AZTFEXPORT_NAME_PATTERN"},
 			Aliases:     []string{"p"},
 			Usage:       namePatternUsage,
-			Value:       "res-+",
 			Destination: &flagset.flagPattern,
 		},
+		&cli.StringFlag{
+			Name:        "naming-policy",
+			EnvVars:     []string{"AZTFEXPORT_NAMING_POLICY"},
+			Usage:       "The path of the naming policy file (HCL or YAML), whose rules (matching the TF type, Azure type, resource id regex or tags) map the resources to name templates. The first matched rule takes precedence over the name pattern",
+			Destination: &flagset.flagNamingPolicy,
+		},
 		&cli.BoolFlag{
 			Name:        "recursive",
 			EnvVars:     []string{"AZTFEXPORT_RECURSIVE"},
@@ -487,10 +588,103 @@ func main() {
 			Usage:       `The Azure Resource Graph Authorization Scope Filter parameter. Possible values are: "AtScopeAndBelow", "AtScopeAndAbove", "AtScopeAboveAndBelow" and "AtScopeExact"`,
 			Destination: &flagset.flagARGAuthorizationScopeFilter,
 		},
+		&cli.StringSliceFlag{
+			Name:        "additional-subscription-id",
+			EnvVars:     []string{"AZTFEXPORT_ADDITIONAL_SUBSCRIPTION_ID"},
+			Usage:       "The additional subscriptions to query, whose resources are managed by an aliased provider per subscription",
+			Destination: &flagset.flagAdditionalSubscriptionId,
+		},
+		&cli.StringSliceFlag{
+			Name:        "tag",
+			EnvVars:     []string{"AZTFEXPORT_TAG"},
+			Usage:       "Only export the resources having the tag, in form of `key=value`, or `key` to match any value. Can be specified multiple times, which are all required to match",
+			Destination: &flagset.flagTag,
+		},
 	}, commonFlags...)
 
+	// The table and the tag filter are specified in the KQL query itself.
+	kqlFlags := withoutFlags(queryFlags, "arg-table", "tag")
+
 	mappingFileFlags := append([]cli.Flag{}, commonFlags...)
 
+	scopeFlags := append([]cli.Flag{
+		&cli.StringFlag{
+			Name:        "name-pattern",
+			EnvVars:     []string{"AZTFEXPORT_NAME_PATTERN"},
+			Aliases:     []string{"p"},
+			Usage:       namePatternUsage,
+			Destination: &flagset.flagPattern,
+		},
+		&cli.StringFlag{
+			Name:        "naming-policy",
+			EnvVars:     []string{"AZTFEXPORT_NAMING_POLICY"},
+			Usage:       "The path of the naming policy file (HCL or YAML), whose rules (matching the TF type, Azure type, resource id regex or tags) map the resources to name templates. The first matched rule takes precedence over the name pattern",
+			Destination: &flagset.flagNamingPolicy,
+		},
+		&cli.BoolFlag{
+			Name:        "governance-only",
+			EnvVars:     []string{"AZTFEXPORT_GOVERNANCE_ONLY"},
+			Usage:       "Only export the governance objects (i.e. custom policy (set) definitions, policy assignments and exemptions, custom role definitions and role assignments at the management group, subscription or resource group scope) and budgets",
+			Destination: &flagset.flagGovernanceOnly,
+		},
+	}, commonFlags...)
+
+	runFlags := append([]cli.Flag{}, commonFlags...)
+
+	// The diff command only lists the resources, the flags about importing and generating config make no sense to it.
+	diffExcludedFlags := []string{
+		"output-dir",
+		"overwrite",
+		"append",
+		"resume",
+		"dev-provider",
+		"provider-version",
+		"backend-type",
+		"backend-config",
+		"config-mode",
+		"output-format",
+		"file-layout",
+		"module-layout",
+		"module-rule-file",
+		"hoist-variables",
+		"data-source-for-external-refs",
+		"generate-dependency-graph",
+		"generate-outputs",
+		"output-attribute",
+		"consolidate-for-each",
+		"mask-sensitive",
+		"non-interactive",
+		"plain-ui",
+		"continue",
+		"generate-mapping-file",
+		"hcl-only",
+		"module-path",
+		"generate-import-block",
+		"previous-mapping-file",
+		"dependency-resolution-file",
+		"name-pattern",
+		"naming-policy",
+		"mock-client",
+		"tfclient-plugin-path",
+	}
+	diffFlags := []cli.Flag{
+		&cli.StringFlag{
+			Name:        "format",
+			EnvVars:     []string{"AZTFEXPORT_DIFF_FORMAT"},
+			Usage:       `The format of the diff report. Possible values are "text" and "json"`,
+			Value:       internal.DiffOutputFormatText,
+			Destination: &flagset.flagDiffFormat,
+		},
+		&cli.BoolFlag{
+			Name:        "exit-code",
+			EnvVars:     []string{"AZTFEXPORT_DIFF_EXIT_CODE"},
+			Usage:       "Exit with a non-zero code if there is any difference",
+			Destination: &flagset.flagDiffExitCode,
+		},
+	}
+	diffResourceGroupFlags := append(append([]cli.Flag{}, diffFlags...), withoutFlags(resourceGroupFlags, diffExcludedFlags...)...)
+	diffQueryFlags := append(append([]cli.Flag{}, diffFlags...), withoutFlags(queryFlags, diffExcludedFlags...)...)
+
 	app := &cli.App{
 		Name:      "aztfexport",
 		Version:   getVersion(),
@@ -563,96 +757,25 @@ func main() {
 				Flags:     resourceFlags,
 				Before:    commandBeforeFunc(&flagset, ModeResource),
 				Action: func(c *cli.Context) error {
-					if c.NArg() == 0 {
-						return fmt.Errorf("No resource id specified")
-					}
-
-					var resIds []string
-					for _, arg := range c.Args().Slice() {
-						if !strings.HasPrefix(arg, "@") {
-							if _, err := armid.ParseResourceId(arg); err != nil {
-								return fmt.Errorf("invalid resource id: %v", err)
-							}
-							resIds = append(resIds, arg)
-							continue
-						}
-
-						path := strings.TrimPrefix(arg, "@")
-						// #nosec G304
-						f, err := os.Open(path)
-						if err != nil {
-							return fmt.Errorf("failed to open file %q: %v", path, err)
-						}
-
-						if err := func() error {
-							defer f.Close()
-							scanner := bufio.NewScanner(f)
-							for scanner.Scan() {
-								resId := strings.TrimSpace(scanner.Text())
-								if _, err := armid.ParseResourceId(resId); err != nil {
-									return fmt.Errorf("invalid resource id (contained in %q): %v", path, err)
-								}
-								resIds = append(resIds, resId)
-							}
-							return scanner.Err()
-						}(); err != nil {
-							return fmt.Errorf("scanning %q: %v", path, err)
-						}
-					}
-
-					commonConfig, err := flagset.BuildCommonConfig()
+					cfg, err := flagset.BuildConfig(ModeResource, c.Args().Slice())
 					if err != nil {
 						return err
 					}
-
-					// Initialize the config
-					cfg := config.Config{
-						CommonConfig:           commonConfig,
-						ResourceIds:            resIds,
-						TFResourceName:         flagset.flagResName,
-						TFResourceType:         flagset.flagResType,
-						ResourceNamePattern:    flagset.flagPattern,
-						RecursiveQuery:         flagset.flagRecursive,
-						IncludeResourceGroup:   flagset.flagIncludeResourceGroup,
-						IncludeExtensions:      flagset.flagIncludeExtension.Value(),
-						IncludeManagedResource: flagset.flagIncludeManagedResource,
-					}
-
 					return realMain(c.Context, cfg, flagset.flagNonInteractive, flagset.hflagMockClient, flagset.flagPlainUI, flagset.flagGenerateMappingFile, flagset.hflagProfile, flagset.DescribeCLI(ModeResource), flagset.hflagTFClientPluginPath)
 				},
 			},
 			{
 				Name:      string(ModeResourceGroup),
 				Aliases:   []string{"rg"},
-				Usage:     "Exporting a resource group and the nested resources resides within it.",
-				UsageText: "aztfexport resource-group [option] <resource group name>",
+				Usage:     "Exporting one or more resource groups and the nested resources resides within them. The arguments can be resource group names, or glob patterns (e.g. `app-*`) of the names.",
+				UsageText: "aztfexport resource-group [option] <resource group name | pattern>...",
 				Flags:     resourceGroupFlags,
 				Before:    commandBeforeFunc(&flagset, ModeResourceGroup),
 				Action: func(c *cli.Context) error {
-					if c.NArg() == 0 {
-						return fmt.Errorf("No resource group specified")
-					}
-					if c.NArg() > 1 {
-						return fmt.Errorf("More than one resource groups specified")
-					}
-
-					rg := c.Args().First()
-
-					commonConfig, err := flagset.BuildCommonConfig()
+					cfg, err := flagset.BuildConfig(ModeResourceGroup, c.Args().Slice())
 					if err != nil {
 						return err
 					}
-
-					// Initialize the config
-					cfg := config.Config{
-						CommonConfig:           commonConfig,
-						ResourceGroupName:      rg,
-						ResourceNamePattern:    flagset.flagPattern,
-						RecursiveQuery:         true,
-						IncludeExtensions:      flagset.flagIncludeExtension.Value(),
-						IncludeManagedResource: flagset.flagIncludeManagedResource,
-					}
-
 					return realMain(c.Context, cfg, flagset.flagNonInteractive, flagset.hflagMockClient, flagset.flagPlainUI, flagset.flagGenerateMappingFile, flagset.hflagProfile, flagset.DescribeCLI(ModeResourceGroup), flagset.hflagTFClientPluginPath)
 				},
 			},
@@ -663,36 +786,27 @@ func main() {
 				Flags:     queryFlags,
 				Before:    commandBeforeFunc(&flagset, ModeQuery),
 				Action: func(c *cli.Context) error {
-					if c.NArg() == 0 {
-						return fmt.Errorf("No query specified")
-					}
-					if c.NArg() > 1 {
-						return fmt.Errorf("More than one queries specified. Use `and` with double quotes to run multiple query parameters.")
-					}
-
-					predicate := c.Args().First()
-
-					commonConfig, err := flagset.BuildCommonConfig()
+					cfg, err := flagset.BuildConfig(ModeQuery, c.Args().Slice())
 					if err != nil {
 						return err
 					}
-
-					// Initialize the config
-					cfg := config.Config{
-						CommonConfig:                commonConfig,
-						ARGPredicate:                predicate,
-						ResourceNamePattern:         flagset.flagPattern,
-						RecursiveQuery:              flagset.flagRecursive,
-						IncludeExtensions:           flagset.flagIncludeExtension.Value(),
-						IncludeManagedResource:      flagset.flagIncludeManagedResource,
-						IncludeResourceGroup:        flagset.flagIncludeResourceGroup,
-						ARGTable:                    flagset.flagARGTable,
-						ARGAuthorizationScopeFilter: flagset.flagARGAuthorizationScopeFilter,
-					}
-
 					return realMain(c.Context, cfg, flagset.flagNonInteractive, flagset.hflagMockClient, flagset.flagPlainUI, flagset.flagGenerateMappingFile, flagset.hflagProfile, flagset.DescribeCLI(ModeQuery), flagset.hflagTFClientPluginPath)
 				},
 			},
+			{
+				Name:      string(ModeKQL),
+				Usage:     "Exporting a customized scope of resources determined by a full Azure Resource Graph (KQL) query, or a \".kql\" file containing the query. Each returned \"id\" column identifies a resource to export.",
+				UsageText: "aztfexport kql [option] <KQL query | KQL file>",
+				Flags:     kqlFlags,
+				Before:    commandBeforeFunc(&flagset, ModeKQL),
+				Action: func(c *cli.Context) error {
+					cfg, err := flagset.BuildConfig(ModeKQL, c.Args().Slice())
+					if err != nil {
+						return err
+					}
+					return realMain(c.Context, cfg, flagset.flagNonInteractive, flagset.hflagMockClient, flagset.flagPlainUI, flagset.flagGenerateMappingFile, flagset.hflagProfile, flagset.DescribeCLI(ModeKQL), flagset.hflagTFClientPluginPath)
+				},
+			},
 			{
 				Name:      string(ModeMappingFile),
 				Aliases:   []string{"map"},
@@ -700,28 +814,143 @@ func main() {
 				UsageText: "aztfexport mapping-file [option] <resource mapping file>",
 				Flags:     mappingFileFlags,
 				Before:    commandBeforeFunc(&flagset, ModeMappingFile),
+				Action: func(c *cli.Context) error {
+					cfg, err := flagset.BuildConfig(ModeMappingFile, c.Args().Slice())
+					if err != nil {
+						return err
+					}
+					return realMain(c.Context, cfg, flagset.flagNonInteractive, flagset.hflagMockClient, flagset.flagPlainUI, flagset.flagGenerateMappingFile, flagset.hflagProfile, flagset.DescribeCLI(ModeMappingFile), flagset.hflagTFClientPluginPath)
+				},
+			},
+			{
+				Name:      string(ModeSubscription),
+				Aliases:   []string{"sub"},
+				Usage:     "Exporting the whole subscription (specified by `--subscription-id`), including the subscription level resources (e.g. resource groups, governance objects like policies and role assignments, and budgets).",
+				UsageText: "aztfexport subscription [option]",
+				Flags:     scopeFlags,
+				Before:    commandBeforeFunc(&flagset, ModeSubscription),
+				Action: func(c *cli.Context) error {
+					cfg, err := flagset.BuildConfig(ModeSubscription, c.Args().Slice())
+					if err != nil {
+						return err
+					}
+					return realMain(c.Context, cfg, flagset.flagNonInteractive, flagset.hflagMockClient, flagset.flagPlainUI, flagset.flagGenerateMappingFile, flagset.hflagProfile, flagset.DescribeCLI(ModeSubscription), flagset.hflagTFClientPluginPath)
+				},
+			},
+			{
+				Name:      string(ModeManagementGroup),
+				Aliases:   []string{"mg"},
+				Usage:     "Exporting all the subscriptions under a management group (including its descendant management groups), together with the governance objects (e.g. policies and role assignments) of the management group.",
+				UsageText: "aztfexport management-group [option] <management group name>",
+				Flags:     scopeFlags,
+				Before:    commandBeforeFunc(&flagset, ModeManagementGroup),
+				Action: func(c *cli.Context) error {
+					cfg, err := flagset.BuildConfig(ModeManagementGroup, c.Args().Slice())
+					if err != nil {
+						return err
+					}
+					return realMain(c.Context, cfg, flagset.flagNonInteractive, flagset.hflagMockClient, flagset.flagPlainUI, flagset.flagGenerateMappingFile, flagset.hflagProfile, flagset.DescribeCLI(ModeManagementGroup), flagset.hflagTFClientPluginPath)
+				},
+			},
+			{
+				Name:      "run",
+				Usage:     "Running the export jobs defined in a spec file (HCL or YAML) in sequence. The options specified in the command line apply to all the jobs, unless overridden by the job.",
+				UsageText: "aztfexport run [option] <spec file>",
+				Flags:     runFlags,
 				Action: func(c *cli.Context) error {
 					if c.NArg() == 0 {
-						return fmt.Errorf("No resource mapping file specified")
+						return fmt.Errorf("No spec file specified")
 					}
 					if c.NArg() > 1 {
-						return fmt.Errorf("More than one resource mapping files specified")
+						return fmt.Errorf("More than one spec files specified")
 					}
+					return runSpec(c, flagset, c.Args().First())
+				},
+			},
+			{
+				Name:      "diff",
+				Usage:     "Comparing the resource mapping file of a previous export with the live resources of a scope, reporting the added, removed and re-typed resources.",
+				UsageText: "aztfexport diff [subcommand]",
+				Subcommands: []*cli.Command{
+					{
+						Name:      string(ModeResourceGroup),
+						Aliases:   []string{"rg"},
+						Usage:     "Comparing against a resource group and the nested resources resides within it.",
+						UsageText: "aztfexport diff resource-group [option] <resource mapping file> <resource group name>",
+						Flags:     diffResourceGroupFlags,
+						Before:    diffCommandBeforeFunc(&flagset, ModeDiffResourceGroup),
+						Action: func(c *cli.Context) error {
+							if c.NArg() != 2 {
+								return fmt.Errorf("Please specify a resource mapping file and a resource group name")
+							}
 
-					mapFile := c.Args().First()
+							mapFile := c.Args().Get(0)
+							rg := c.Args().Get(1)
 
-					commonConfig, err := flagset.BuildCommonConfig()
-					if err != nil {
-						return err
-					}
+							commonConfig, err := flagset.BuildCommonConfig()
+							if err != nil {
+								return err
+							}
 
-					// Initialize the config
-					cfg := config.Config{
-						CommonConfig: commonConfig,
-						MappingFile:  mapFile,
-					}
+							tagFilter, err := flagset.BuildTagFilter()
+							if err != nil {
+								return err
+							}
 
-					return realMain(c.Context, cfg, flagset.flagNonInteractive, flagset.hflagMockClient, flagset.flagPlainUI, flagset.flagGenerateMappingFile, flagset.hflagProfile, flagset.DescribeCLI(ModeMappingFile), flagset.hflagTFClientPluginPath)
+							// Initialize the config
+							cfg := config.Config{
+								CommonConfig:           commonConfig,
+								ResourceGroupName:      rg,
+								TagFilter:              tagFilter,
+								RecursiveQuery:         true,
+								IncludeExtensions:      flagset.flagIncludeExtension.Value(),
+								IncludeManagedResource: flagset.flagIncludeManagedResource,
+							}
+
+							return diffMain(c.Context, cfg, mapFile, flagset.hflagProfile, flagset.DescribeCLI(ModeDiffResourceGroup))
+						},
+					},
+					{
+						Name:      string(ModeQuery),
+						Usage:     "Comparing against a customized scope of resources determined by an Azure Resource Graph where predicate.",
+						UsageText: "aztfexport diff query [option] <resource mapping file> <ARG where predicate>",
+						Flags:     diffQueryFlags,
+						Before:    diffCommandBeforeFunc(&flagset, ModeDiffQuery),
+						Action: func(c *cli.Context) error {
+							if c.NArg() != 2 {
+								return fmt.Errorf("Please specify a resource mapping file and a query")
+							}
+
+							mapFile := c.Args().Get(0)
+							predicate := c.Args().Get(1)
+
+							commonConfig, err := flagset.BuildCommonConfig()
+							if err != nil {
+								return err
+							}
+
+							tagFilter, err := flagset.BuildTagFilter()
+							if err != nil {
+								return err
+							}
+
+							// Initialize the config
+							cfg := config.Config{
+								CommonConfig:                commonConfig,
+								ARGPredicate:                predicate,
+								TagFilter:                   tagFilter,
+								RecursiveQuery:              flagset.flagRecursive,
+								IncludeExtensions:           flagset.flagIncludeExtension.Value(),
+								IncludeManagedResource:      flagset.flagIncludeManagedResource,
+								IncludeResourceGroup:        flagset.flagIncludeResourceGroup,
+								ARGTable:                    flagset.flagARGTable,
+								ARGAuthorizationScopeFilter: flagset.flagARGAuthorizationScopeFilter,
+								AdditionalSubscriptionIds:   flagset.flagAdditionalSubscriptionId.Value(),
+							}
+
+							return diffMain(c.Context, cfg, mapFile, flagset.hflagProfile, flagset.DescribeCLI(ModeDiffQuery))
+						},
+					},
 				},
 			},
 		},
@@ -735,6 +964,17 @@ func main() {
 	}
 }
 
+// withoutFlags returns the flags except the ones with the specified names.
+func withoutFlags(flags []cli.Flag, names ...string) []cli.Flag {
+	var out []cli.Flag
+	for _, flag := range flags {
+		if !slices.Contains(names, flag.Names()[0]) {
+			out = append(out, flag)
+		}
+	}
+	return out
+}
+
 func subscriptionIdFromCLI() (string, error) {
 	var stderr bytes.Buffer
 	var stdout bytes.Buffer
@@ -833,3 +1073,38 @@ func realMain(ctx context.Context, cfg config.Config, batch, mockMeta, plainUI,
 	}
 	return nil
 }
+
+func diffMain(ctx context.Context, cfg config.Config, mappingFile string, profileType string, effectiveCLI string) (result error) {
+	switch strings.ToLower(profileType) {
+	case "cpu":
+		defer profile.Start(profile.CPUProfile, profile.ProfilePath("."), profile.NoShutdownHook).Stop()
+	case "mem":
+		defer profile.Start(profile.MemProfile, profile.ProfilePath("."), profile.NoShutdownHook).Stop()
+	}
+
+	tc := cfg.TelemetryClient
+
+	defer func() {
+		if result == nil {
+			cfg.Logger.Info("aztfexport diff ends")
+			tc.Trace(telemetry.Info, "aztfexport diff ends")
+		} else {
+			cfg.Logger.Error("aztfexport diff ends with error", "error", result)
+			tc.Trace(telemetry.Error, fmt.Sprintf("aztfexport diff ends with error"))
+			tc.Trace(telemetry.Error, fmt.Sprintf("Error detail: %v", result))
+		}
+		tc.Close()
+	}()
+
+	cfg.Logger.Info("aztfexport diff starts", "config", fmt.Sprintf("%#v", cfg))
+	tc.Trace(telemetry.Info, "aztfexport diff starts")
+	tc.Trace(telemetry.Info, "Effective CLI: "+effectiveCLI)
+
+	dcfg := internalconfig.DiffConfig{
+		Config:          cfg,
+		BaseMappingFile: mappingFile,
+		OutputFormat:    flagset.flagDiffFormat,
+		ExitCode:        flagset.flagDiffExitCode,
+	}
+	return internal.Diff(ctx, dcfg)
+}
@@ -34,10 +34,20 @@ type OutputFileNames struct {
 	TerraformFileName string
 	// The filename for the generated "provider.tf" (default)
 	ProviderFileName string
-	// The filename for the generated "main.tf" (default)
+	// The filename for the generated "main.tf" (default), or "main.tf.json" (default) for the JSON output format
 	MainFileName string
-	// The filename for the generated "import.tf" (default)
+	// The filename for the generated "import.tf" (default), or "import.tf.json" (default) for the JSON output format
 	ImportBlockFileName string
+	// The filename for the generated "moved.tf" (default), or "moved.tf.json" (default) for the JSON output format
+	MovedBlockFileName string
+	// The filename for the generated "data.tf" (default), or "data.tf.json" (default) for the JSON output format
+	DataSourceFileName string
+	// The filename for the generated "variables.tf" (default), or "variables.tf.json" (default) for the JSON output format
+	VariableFileName string
+	// The filename for the generated "outputs.tf" (default), or "outputs.tf.json" (default) for the JSON output format
+	OutputFileName string
+	// The filename for the generated "modules.tf" (default) that calls the child modules, or "modules.tf.json" (default) for the JSON output format
+	ModuleFileName string
 }
 
 // ConfigMode controls how aggressively the generated Terraform configuration
@@ -61,6 +71,58 @@ const (
 	ConfigModeFull ConfigMode = "full"
 )
 
+// OutputFormat controls the Terraform configuration syntax of the generated config.
+type OutputFormat string
+
+const (
+	// OutputFormatHCL generates the config in the Terraform native syntax (e.g. "main.tf").
+	OutputFormatHCL OutputFormat = "hcl"
+
+	// OutputFormatJSON generates the config in the Terraform JSON syntax (e.g. "main.tf.json"),
+	// where the references are kept as interpolation sequences (e.g. "${azurerm_resource_group.res-0.name}").
+	OutputFormatJSON OutputFormat = "json"
+)
+
+// FileLayout controls how the generated config (and the import blocks) are split into files.
+type FileLayout string
+
+const (
+	// FileLayoutSingle generates all the config into the main file (e.g. "main.tf").
+	FileLayoutSingle FileLayout = "single"
+
+	// FileLayoutResourceType generates the config into one file per TF resource type (e.g. "azurerm_virtual_network.tf").
+	FileLayoutResourceType FileLayout = "resource-type"
+
+	// FileLayoutProvider generates the config into one file per Azure resource provider namespace, with the "Microsoft." prefix trimmed (e.g. "network.tf").
+	FileLayoutProvider FileLayout = "provider"
+
+	// FileLayoutResourceGroup generates the config into one file per resource group (e.g. "rg1.tf").
+	// The resources that are not within a resource group are generated into the main file.
+	FileLayoutResourceGroup FileLayout = "resource-group"
+)
+
+// ModuleLayout controls how the exported resources are organized into local child modules.
+type ModuleLayout string
+
+const (
+	// ModuleLayoutNone generates all the resources into the target module.
+	ModuleLayoutNone ModuleLayout = "none"
+
+	// ModuleLayoutResourceGroup generates the resources into one child module per resource group (e.g. "module.rg1").
+	// The resources that are not within a resource group are generated into the root module.
+	ModuleLayoutResourceGroup ModuleLayout = "resource-group"
+
+	// ModuleLayoutResourceType generates the resources into one child module per TF resource type (e.g. "module.azurerm_virtual_network").
+	ModuleLayoutResourceType ModuleLayout = "resource-type"
+
+	// ModuleLayoutProvider generates the resources into one child module per Azure resource provider namespace, with the "Microsoft." prefix trimmed (e.g. "module.network").
+	ModuleLayoutProvider ModuleLayout = "provider"
+
+	// ModuleLayoutRule generates the resources into the child modules named by the rules of the module rule file (see CommonConfig.ModuleRuleFile).
+	// The resources that match no rule are generated into the root module.
+	ModuleLayoutRule ModuleLayout = "rule"
+)
+
 type CommonConfig struct {
 	Logger *slog.Logger
 	// AuthConfig specifies the authentication config for provider
@@ -96,6 +158,43 @@ type CommonConfig struct {
 	ProviderConfig map[string]cty.Value
 	// ConfigMode controls how aggressively the generated TF config is trimmed. Defaults to ConfigModeMinimal.
 	ConfigMode ConfigMode
+	// OutputFormat specifies the syntax of the generated TF config and import blocks. Defaults to OutputFormatHCL.
+	// The "terraform.tf" and "provider.tf" are always generated in HCL.
+	OutputFormat OutputFormat
+	// FileLayout specifies how the generated TF config and import blocks are split into files. Defaults to FileLayoutSingle.
+	// For the other layouts, each config file is named by its key with the extension of the main file name, e.g. "network.tf", while each import block file
+	// is named by its key followed by the import block file name, e.g. "network.import.tf". The keys are sanitized, and suffixed (e.g. "rg_1_2") in case
+	// they collide with each other or with the other generated files.
+	FileLayout FileLayout
+	// ModuleLayout specifies how the exported resources are organized into local child modules. Defaults to ModuleLayoutNone.
+	// For the other layouts, each child module is generated under the "modules" directory of the output directory (e.g. "modules/rg1"),
+	// which is called by the root module via the module file, and the resources are imported under the matching module address (e.g. "module.rg1").
+	// The references to the resources in the other modules are passed through the module outputs, the module call arguments and the module variables,
+	// which are generated into the output, module and variable files. The resources must belong to the subscription in use, as the aliased provider configs
+	// aren't passed to the child modules. This conflicts with ModulePath, HoistVariables and DataSourceForExternalRefs.
+	ModuleLayout ModuleLayout
+	// ModuleRuleFile specifies the module rule file, which groups the resources into the child modules by the user defined rules. See meta.ModuleRules for the format.
+	// This is required by, and only applies to ModuleLayoutRule.
+	ModuleRuleFile string
+	// HoistVariables specifies whether to hoist the literal values repeated across the generated TF configs (e.g. location, tags and the subscription id inside resource ids)
+	// into variables, which are written to the variable file with the values as defaults. This only applies to WriteTerraformCfg.
+	HoistVariables bool
+	// DataSourceForExternalRefs specifies whether to substitute the references to the resources out of the export scope (i.e. the resource ids that don't match any exported resource)
+	// by the data sources of these resources, which are written to the data source file. Only the azurerm data sources that are looked up by the names are supported (e.g. the subnets),
+	// while the other references are kept as is. This only applies to WriteTerraformCfg.
+	DataSourceForExternalRefs bool
+	// GenerateDependencyGraph specifies whether to write the dependency graph of the exported resources (i.e. the inferred id references, resource group name references,
+	// parent relations and the ambiguous references) in both Graphviz DOT and JSON, next to the resource mapping file. This only applies to WriteTerraformCfg.
+	GenerateDependencyGraph bool
+	// GenerateOutputs specifies whether to generate an output for the id of each exported resource into the output file. This only applies to WriteTerraformCfg.
+	GenerateOutputs bool
+	// OutputAttributes specifies the extra attributes (e.g. "identity[0].principal_id") to output per TF resource type, when GenerateOutputs is set.
+	// The key is the TF resource type. Note that sensitive attributes can't be output without being marked as sensitive, which is not done by aztfexport.
+	OutputAttributes map[string][]string
+	// ConsolidateForEach specifies whether to consolidate the homogeneous resources of the same TF resource type, which only differ in a few literal attributes,
+	// into a single resource with "for_each" over a generated local map. The references and import blocks are updated to the instance addresses.
+	// Unless HCLOnly is set, "moved" blocks are generated to move the imported resources to the instance addresses.
+	ConsolidateForEach bool
 	// MaskSensitive specifies whether to mask sensitive attributes when generating TF configs.
 	MaskSensitive bool
 	// Parallelism specifies the parallelism for the process
@@ -117,10 +216,22 @@ type CommonConfig struct {
 	TelemetryClient telemetry.Client
 	// GenerateImportBlock controls whether the export process ends up with a import.tf file that contains the "import" blocks
 	GenerateImportBlock bool
+	// PreviousMappingFile specifies the resource mapping file of a previous export. The resources are matched by the Azure resource ids against it,
+	// and a moved.tf file that contains the "moved" blocks is generated for the resources whose addresses are changed, so that the existing state can be migrated.
+	// The previous addresses are assumed to be in the root module, as the resource mapping doesn't record the modules.
+	PreviousMappingFile string
+	// DependencyResolutionFile specifies the dependency resolution file, which resolves the ambiguous dependencies (i.e. the references to the TF resource ids that
+	// map to multiple exported resources) to the chosen resources. See meta.DependencyResolutions for the format. The resolved dependencies are applied as the id references,
+	// while the unresolved ones remain as the comments in the "depends_on". This only applies to WriteTerraformCfg.
+	DependencyResolutionFile string
 	// Azure resource ID patterns (regexp, case insensitive) to exclude
 	ExcludeAzureResources []string
 	// Terrraform resource types to exclude
 	ExcludeTerraformResources []string
+	// Resume specifies whether to resume an interrupted export from the checkpoint journal in the output directory.
+	// The resources that have been imported by the previous run are skipped from importing.
+	// This can't be used together with TFClient.
+	Resume bool
 }
 
 type Config struct {
@@ -132,8 +243,17 @@ type Config struct {
 	ResourceIds []string
 	// ResourceGroupName specifies the name of the resource group, this indicates the resource group mode.
 	ResourceGroupName string
+	// ResourceGroupNames specifies the names of multiple resource groups, which are exported into one workspace. This indicates the resource group mode as well.
+	// Both ResourceGroupName and ResourceGroupNames can be a glob pattern (e.g. "app-*"), which is matched against the resource group names case insensitively.
+	ResourceGroupNames []string
 	// ARGPredicate specifies the ARG where predicate, this indicates the query mode.
 	ARGPredicate string
+	// ARGQuery specifies a full ARG (KQL) query, whose "id" column identifies the resources to export, this indicates the KQL mode.
+	ARGQuery string
+	// ExportSubscription specifies to export the whole subscription in use, including the subscription level resources, this indicates the subscription mode.
+	ExportSubscription bool
+	// ManagementGroupName specifies the name of the management group, whose subscriptions (including the ones under the descendant management groups) are exported as a whole, this indicates the management group mode.
+	ManagementGroupName string
 	// MappingFile specifies the path of mapping file, this indicates the map file mode.
 	MappingFile string
 
@@ -156,10 +276,23 @@ type Config struct {
 	//   {rp}           - Azure resource provider namespace, snake_cased (e.g. "microsoft_compute")
 	//   {name}         - last name segment of the Azure resource id
 	//   {root_scope}   - the root scope of the resource (e.g. resource group name)
+	//   {parent_name}  - the name of the parent resource, or the root scope for a top level resource
+	//   {tag:<key>}    - the value of the resource's tag of the key (case insensitive), or empty if absent
 	//
 	// Each expanded value is sanitized to be a valid Terraform identifier.
+	//
+	// If empty, the resources are named semantically instead, by combining the
+	// parent resource name (for child resources), the Azure resource name and a
+	// type hint (when the TF resource type doesn't tell the Azure resource type).
+	// The names are always suffixed by a short hash of the resource id, rather than
+	// an index, so that they are collision free and stable across runs, even when
+	// the resource set changes.
 	ResourceNamePattern string
 
+	// NamingPolicyFile specifies the path of the naming policy file, whose rules map the resources to the name templates.
+	// The template of the first matched rule takes precedence over the ResourceNamePattern. See meta.NamingPolicy for details.
+	NamingPolicyFile string
+
 	// IncludeExtensions specifies the set of extension resource types to include for the exported resources.
 	// Supported values are defined in the meta package (e.g. "role-assignment").
 	IncludeExtensions []string
@@ -167,6 +300,20 @@ type Config struct {
 	// IncludeManagedResource specifies whether to allow service team/3rd party managed resources to be exported
 	IncludeManagedResource bool
 
+	/////////////////////////
+	// Scope: rg, query
+
+	// TagFilter specifies the tags (key to value) that the resources to export must have. An empty value matches any value of the tag.
+	// For the resource group mode, the resource groups themselves are exported regardless of the tags.
+	TagFilter map[string]string
+
+	/////////////////////////
+	// Scope: subscription, management group
+
+	// GovernanceOnly specifies to only export the governance objects (i.e. the custom policy (set) definitions, policy assignments and exemptions,
+	// custom role definitions and the role assignments at the management group, subscription and resource group scopes), together with the budgets.
+	GovernanceOnly bool
+
 	/////////////////////////
 	// Scope: res (single)
 
@@ -176,7 +323,7 @@ type Config struct {
 	TFResourceType string
 
 	/////////////////////////
-	// Scope: res, query
+	// Scope: res, query, kql
 
 	// RecursiveQuery specifies whether to recursively list the child/proxy resources of the ARG resulted or user specified resource list
 	RecursiveQuery bool
@@ -191,4 +338,7 @@ type Config struct {
 	ARGTable string
 	//  ARGAuthorizationScopeFilter specifies the AuthorizationScopeFilter parameter. Possible values are: "AtScopeAndBelow", "AtScopeAndAbove", "AtScopeAboveAndBelow" and "AtScopeExact"
 	ARGAuthorizationScopeFilter string
+	// AdditionalSubscriptionIds specifies the subscriptions to query in addition to the one in use.
+	// The resources in these subscriptions are managed by the aliased provider configs, one per subscription.
+	AdditionalSubscriptionIds []string
 }
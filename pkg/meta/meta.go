@@ -10,19 +10,27 @@ import (
 
 type ImportItem = meta.ImportItem
 type ImportList = meta.ImportList
+type AmbiguousDependency = meta.AmbiguousDependency
+type DependencyResolutions = meta.DependencyResolutions
 
 type Meta interface {
 	meta.BaseMeta
 	// ScopeName returns a string indicating current scope/mode.
 	ScopeName() string
-	// ListResource lists the resources belong to current scope.
+	// ListResource lists the resources belong to current scope. The resources that are already managed by the base state are marked as managed, which won't be imported.
 	ListResource(ctx context.Context) (meta.ImportList, error)
 }
 
 func NewMeta(cfg config.Config) (Meta, error) {
 	switch {
-	case cfg.ResourceGroupName != "":
+	case cfg.ResourceGroupName != "" || len(cfg.ResourceGroupNames) != 0:
 		return meta.NewMetaResourceGroup(cfg)
+	case cfg.ExportSubscription:
+		return meta.NewMetaSubscription(cfg)
+	case cfg.ManagementGroupName != "":
+		return meta.NewMetaManagementGroup(cfg)
+	case cfg.ARGQuery != "":
+		return meta.NewMetaKQL(cfg)
 	case cfg.ARGPredicate != "":
 		return meta.NewMetaQuery(cfg)
 	case cfg.MappingFile != "":
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+
+	"github.com/Azure/aztfexport/internal/spec"
+	"github.com/urfave/cli/v2"
+)
+
+// applySpecJob returns a copy of the flag set, overridden by the settings of the spec job, together with the mode of the job.
+func (f FlagSet) applySpecJob(job spec.Job) (FlagSet, Mode) {
+	setString := func(p *string, v string) {
+		if v != "" {
+			*p = v
+		}
+	}
+	setBool := func(p *bool, v *bool) {
+		if v != nil {
+			*p = *v
+		}
+	}
+	setStringSlice := func(p *cli.StringSlice, v []string) {
+		if v != nil {
+			*p = *cli.NewStringSlice(v...)
+		}
+	}
+
+	f.flagOutputDir = job.OutputDir
+	setString(&f.flagSubscriptionId, job.SubscriptionId)
+	setBool(&f.flagOverwrite, job.Overwrite)
+	setBool(&f.flagAppend, job.Append)
+	setString(&f.flagPattern, job.NamePattern)
+	setString(&f.flagNamingPolicy, job.NamingPolicy)
+	setString(&f.flagResName, job.ResourceName)
+	setString(&f.flagResType, job.ResourceType)
+	setBool(&f.flagRecursive, job.Recursive)
+	setBool(&f.flagIncludeResourceGroup, job.IncludeResourceGroup)
+	setBool(&f.flagIncludeManagedResource, job.IncludeManagedResource)
+	setStringSlice(&f.flagIncludeExtension, job.IncludeExtensions)
+	setStringSlice(&f.flagExcludeAzureResource, job.ExcludeAzureResources)
+	setStringSlice(&f.flagExcludeTerraformResource, job.ExcludeTerraformResources)
+	setString(&f.flagARGTable, job.ARGTable)
+	setString(&f.flagARGAuthorizationScopeFilter, job.ARGAuthorizationScopeFilter)
+	setStringSlice(&f.flagAdditionalSubscriptionId, job.AdditionalSubscriptionIds)
+	setBool(&f.flagGovernanceOnly, job.GovernanceOnly)
+	setStringSlice(&f.flagTag, job.TagList())
+	setString(&f.flagProviderName, job.Provider)
+	setString(&f.flagProviderVersion, job.ProviderVersion)
+	setString(&f.flagBackendType, job.BackendType)
+	setStringSlice(&f.flagBackendConfig, job.BackendConfigList())
+	setString(&f.flagConfigMode, job.ConfigMode)
+	setString(&f.flagOutputFormat, job.OutputFormat)
+	setString(&f.flagFileLayout, job.FileLayout)
+	setBool(&f.flagHCLOnly, job.HCLOnly)
+	setBool(&f.flagGenerateImportBlock, job.GenerateImportBlock)
+
+	var mode Mode
+	switch kind, _ := job.Scope(); kind {
+	case spec.ScopeResource:
+		mode = ModeResource
+	case spec.ScopeResourceGroup:
+		mode = ModeResourceGroup
+	case spec.ScopeQuery:
+		mode = ModeQuery
+	case spec.ScopeKQL:
+		mode = ModeKQL
+	case spec.ScopeMappingFile:
+		mode = ModeMappingFile
+	case spec.ScopeSubscription:
+		mode = ModeSubscription
+	case spec.ScopeManagementGroup:
+		mode = ModeManagementGroup
+	}
+	return f, mode
+}
+
+// runSpec runs the jobs defined in the spec file in sequence, each of which is run in the same way as its corresponding subcommand.
+func runSpec(c *cli.Context, fset FlagSet, path string) error {
+	sp, err := spec.ParseFile(path)
+	if err != nil {
+		return err
+	}
+
+	for _, job := range sp.Jobs {
+		jobFlagSet, mode := fset.applySpecJob(job)
+
+		// Run the job with the scope arguments, as if they are specified to the subcommand.
+		_, args := job.Scope()
+		set := flag.NewFlagSet(job.Name, flag.ContinueOnError)
+		if err := set.Parse(append([]string{"--"}, args...)); err != nil {
+			return fmt.Errorf("job %q: %v", job.Name, err)
+		}
+		if err := commandBeforeFunc(&jobFlagSet, mode)(cli.NewContext(c.App, set, c)); err != nil {
+			return fmt.Errorf("job %q: %v", job.Name, err)
+		}
+
+		cfg, err := jobFlagSet.BuildConfig(mode, args)
+		if err != nil {
+			return fmt.Errorf("job %q: %v", job.Name, err)
+		}
+		if err := realMain(c.Context, cfg, jobFlagSet.flagNonInteractive, jobFlagSet.hflagMockClient, jobFlagSet.flagPlainUI, jobFlagSet.flagGenerateMappingFile, jobFlagSet.hflagProfile, jobFlagSet.DescribeCLI(mode), jobFlagSet.hflagTFClientPluginPath); err != nil {
+			return fmt.Errorf("job %q: %v", job.Name, err)
+		}
+	}
+	return nil
+}
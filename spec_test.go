@@ -0,0 +1,64 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/Azure/aztfexport/internal/spec"
+	"github.com/stretchr/testify/require"
+	"github.com/urfave/cli/v2"
+)
+
+func TestApplySpecJob(t *testing.T) {
+	overwrite := true
+	fset := FlagSet{
+		flagSubscriptionId:   "123",
+		flagOutputDir:        "/tmp/out",
+		flagProviderName:     "azurerm",
+		flagPattern:          "res-",
+		flagIncludeExtension: *cli.NewStringSlice("Microsoft.Authorization/roleAssignments"),
+		flagNonInteractive:   true,
+	}
+
+	jobFlagSet, mode := fset.applySpecJob(spec.Job{
+		Name:          "network",
+		ResourceGroup: "rg1",
+		OutputDir:     "/tmp/network",
+		Overwrite:     &overwrite,
+		Provider:      "azapi",
+		BackendType:   "azurerm",
+		BackendConfig: map[string]string{"key": "network.tfstate", "container_name": "tfstate"},
+		Tags:          map[string]string{"env": "prod"},
+	})
+	require.Equal(t, ModeResourceGroup, mode)
+	require.Equal(t, "123", jobFlagSet.flagSubscriptionId)
+	require.Equal(t, "/tmp/network", jobFlagSet.flagOutputDir)
+	require.True(t, jobFlagSet.flagOverwrite)
+	require.True(t, jobFlagSet.flagNonInteractive)
+	require.Equal(t, "azapi", jobFlagSet.flagProviderName)
+	require.Equal(t, "res-", jobFlagSet.flagPattern)
+	require.Equal(t, []string{"Microsoft.Authorization/roleAssignments"}, jobFlagSet.flagIncludeExtension.Value())
+	require.Equal(t, "azurerm", jobFlagSet.flagBackendType)
+	require.Equal(t, []string{"container_name=tfstate", "key=network.tfstate"}, jobFlagSet.flagBackendConfig.Value())
+	require.Equal(t, []string{"env=prod"}, jobFlagSet.flagTag.Value())
+
+	// The original flag set is not changed
+	require.Equal(t, "/tmp/out", fset.flagOutputDir)
+	require.False(t, fset.flagOverwrite)
+	require.Equal(t, "azurerm", fset.flagProviderName)
+
+	_, mode = fset.applySpecJob(spec.Job{ResourceIds: []string{"/subscriptions/123/resourceGroups/rg1"}})
+	require.Equal(t, ModeResource, mode)
+	_, mode = fset.applySpecJob(spec.Job{Query: `type =~ "microsoft.network/"`})
+	require.Equal(t, ModeQuery, mode)
+	_, mode = fset.applySpecJob(spec.Job{KQL: "resources"})
+	require.Equal(t, ModeKQL, mode)
+	jobFlagSet, mode = fset.applySpecJob(spec.Job{MappingFile: "/tmp/mapping.json", IncludeExtensions: []string{}})
+	require.Equal(t, ModeMappingFile, mode)
+	require.Empty(t, jobFlagSet.flagIncludeExtension.Value())
+	governanceOnly := true
+	jobFlagSet, mode = fset.applySpecJob(spec.Job{Subscription: true, GovernanceOnly: &governanceOnly})
+	require.Equal(t, ModeSubscription, mode)
+	require.True(t, jobFlagSet.flagGovernanceOnly)
+	_, mode = fset.applySpecJob(spec.Job{ManagementGroup: "mg1"})
+	require.Equal(t, ModeManagementGroup, mode)
+}